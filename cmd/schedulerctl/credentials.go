@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// apiKeyEnv holds the API key, it takes precedence over the config file
+const apiKeyEnv = "LOADTESTS_API_KEY"
+
+// config is the config file of schedulerctl:
+//
+//	{"api_key": "..."}
+type config struct {
+	APIKey string `json:"api_key"`
+}
+
+// defaultConfigFile is where the config file is looked for by default.
+func defaultConfigFile() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".schedulerctl.json")
+}
+
+// apiKey returns the key schedulerctl authenticates with, empty if there is
+// none. A missing config file is fine, the scheduler might not authenticate
+// its callers.
+func apiKey(configFile string) (string, error) {
+	if key := os.Getenv(apiKeyEnv); key != "" {
+		return key, nil
+	}
+	if configFile == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("invalid config file %s: %v", configFile, err)
+	}
+	return cfg.APIKey, nil
+}
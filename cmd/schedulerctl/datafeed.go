@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// readDataFeed reads a data feed, its format is told by the extension of the
+// file: .csv, or .ndjson and .jsonl for a JSON object per line.
+func readDataFeed(filename, mode string) (*pb.DataFeed, error) {
+	feed := &pb.DataFeed{}
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".csv":
+		feed.Format = pb.DataFeed_CSV
+	case ".ndjson", ".jsonl":
+		feed.Format = pb.DataFeed_NDJSON
+	default:
+		return nil, fmt.Errorf("data feed %q: unknown extension %q, want .csv, .ndjson or .jsonl", filename, ext)
+	}
+	m, ok := pb.DataFeed_Mode_value[strings.ToUpper(mode)]
+	if !ok {
+		return nil, fmt.Errorf("data feed: unknown mode %q, want sequential, random or unique", mode)
+	}
+	feed.Mode = pb.DataFeed_Mode(m)
+
+	content, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	feed.Content = content
+	return feed, nil
+}
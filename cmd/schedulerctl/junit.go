@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// A JUnit report has a test case per threshold, so CI systems show which
+// ones a load test didn't meet.
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+func writeJUnit(filename, scriptName string, elapsed time.Duration, verdict *pb.Verdict) error {
+	suite := junitSuite{
+		Name: scriptName,
+		Time: elapsed.Seconds(),
+	}
+	for _, threshold := range verdict.GetThresholds() {
+		c := junitCase{ClassName: "thresholds", Name: threshold.Expression}
+		if !threshold.Passed {
+			c.Failure = &junitFailure{Message: fmt.Sprintf("observed %s", threshold.Observed)}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Tests = len(suite.Cases)
+
+	fd, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if _, err := fd.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(fd)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err = fd.WriteString("\n")
+	return err
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"golang.org/x/net/context"
+)
+
+// loadTestCommands inspect and control the load tests known to the
+// scheduler. `client` is only dialed once the app starts.
+func loadTestCommands(client *pb.SchedulerClient) []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "cancel",
+			Usage: "cancel <id>: halt a load test, its executors flush their metrics before being destroyed",
+			Action: func(ctx *cli.Context) {
+				id := requireID(ctx)
+				res, err := (*client).CancelLoadTest(context.Background(), &pb.CancelLoadTestReq{Id: id})
+				if err != nil {
+					log.Fatalf("cancelling load test: %v", err)
+				}
+				log.Printf("load test %q is %v", id, res.LoadTest.State)
+			},
+		},
+		{
+			Name:  "pause",
+			Usage: "pause <id>: stop generating load until the load test is resumed",
+			Action: func(ctx *cli.Context) {
+				control(*client, requireID(ctx), &pb.ControlLoadTestReq{Action: pb.ControlLoadTestReq_PAUSE})
+			},
+		},
+		{
+			Name:  "resume",
+			Usage: "resume <id>: generate load again after a pause",
+			Action: func(ctx *cli.Context) {
+				control(*client, requireID(ctx), &pb.ControlLoadTestReq{Action: pb.ControlLoadTestReq_RESUME})
+			},
+		},
+		{
+			Name:  "rate",
+			Usage: "rate <id>: change the rate, or the workers, of a running load test. Its growth or profile no longer apply",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "rps", Usage: "requests per second of the whole load test"},
+				cli.IntFlag{Name: "workers", Usage: "workers of the whole load test"},
+			},
+			Action: func(ctx *cli.Context) {
+				control(*client, requireID(ctx), &pb.ControlLoadTestReq{
+					Action:            pb.ControlLoadTestReq_SET_RATE,
+					RequestsPerSecond: int32(ctx.Int("rps")),
+					Workers:           int32(ctx.Int("workers")),
+				})
+			},
+		},
+		{
+			Name:  "list",
+			Usage: "list the load tests the scheduler runs, and the last 100 it ran in the past day",
+			Action: func(ctx *cli.Context) {
+				res, err := (*client).ListLoadTests(context.Background(), &pb.ListLoadTestsReq{})
+				if err != nil {
+					log.Fatalf("listing load tests: %v", err)
+				}
+				tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(tw, "ID\tSCRIPT\tSTATE\tEXECUTORS\tSTARTED\tOWNER")
+				for _, test := range res.LoadTests {
+					fmt.Fprintf(tw, "%s\t%s\t%v\t%d\t%s\t%s\n",
+						test.Id,
+						test.ScriptName,
+						test.State,
+						test.Executors,
+						time.Unix(test.StartedAt, 0).Format(time.RFC3339),
+						test.Owner,
+					)
+				}
+				tw.Flush()
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "status <id>: show the state of a load test",
+			Action: func(ctx *cli.Context) {
+				id := requireID(ctx)
+				res, err := (*client).GetLoadTest(context.Background(), &pb.GetLoadTestReq{Id: id})
+				if err != nil {
+					log.Fatalf("getting load test: %v", err)
+				}
+				printLoadTest(res.LoadTest)
+			},
+		},
+	}
+}
+
+func control(client pb.SchedulerClient, id string, req *pb.ControlLoadTestReq) {
+	req.Id = id
+	res, err := client.ControlLoadTest(context.Background(), req)
+	if err != nil {
+		log.Fatalf("controlling load test: %v", err)
+	}
+	state := res.LoadTest.State.String()
+	if res.LoadTest.Paused {
+		state += " (paused)"
+	}
+	log.Printf("load test %q is %s", id, state)
+}
+
+func requireID(ctx *cli.Context) string {
+	id := ctx.Args().First()
+	if id == "" {
+		log.Fatalf("the id of a load test is required")
+	}
+	return id
+}
+
+func printLoadTest(test *pb.LoadTest) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "id:\t%s\n", test.Id)
+	fmt.Fprintf(tw, "script:\t%s\n", test.ScriptName)
+	fmt.Fprintf(tw, "target:\t%s\n", test.Url)
+	if test.Paused {
+		fmt.Fprintf(tw, "state:\t%v (paused)\n", test.State)
+	} else {
+		fmt.Fprintf(tw, "state:\t%v\n", test.State)
+	}
+	fmt.Fprintf(tw, "executors:\t%d\n", test.Executors)
+	if test.Owner != "" {
+		fmt.Fprintf(tw, "owner:\t%s\n", test.Owner)
+	}
+	fmt.Fprintf(tw, "started:\t%s\n", time.Unix(test.StartedAt, 0).Format(time.RFC3339))
+	if test.EndedAt != 0 {
+		ended := time.Unix(test.EndedAt, 0)
+		fmt.Fprintf(tw, "ended:\t%s (after %v)\n", ended.Format(time.RFC3339), ended.Sub(time.Unix(test.StartedAt, 0)))
+	}
+	if test.Error != "" {
+		fmt.Fprintf(tw, "error:\t%s\n", test.Error)
+	}
+	tw.Flush()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/flynn/flynn/controller/name"
 	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"github.com/lgpeterson/loadtests/scheduler/rpcauth"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 )
@@ -29,14 +31,27 @@ func init() {
 }
 
 var (
-	addrFlag = cli.StringFlag{Name: "addr", Usage: "address where the scheduler service can be reached"}
-	tgtFlag  = cli.StringFlag{Name: "tgt", Usage: "target URL to execute the load test against"}
+	addrFlag   = cli.StringFlag{Name: "addr", Usage: "address where the scheduler service can be reached"}
+	configFlag = cli.StringFlag{Name: "config", Value: defaultConfigFile(), Usage: "config file holding the API key, like {\"api_key\": \"...\"}. The " + apiKeyEnv + " environment variable takes precedence"}
+	tlsFlag    = cli.BoolFlag{Name: "tls", Usage: "talk to the scheduler over TLS, the API key is only sent over TLS"}
+	caCertFlag = cli.StringFlag{Name: "ca.cert", Usage: "if specified, the PEM certificate of the scheduler, or of its CA, trusted instead of those of the system. Implies --tls"}
+	tgtFlag    = cli.StringFlag{Name: "tgt", Usage: "target URL to execute the load test against"}
 
 	scriptNameFlag    = cli.StringFlag{Name: "script.name", Usage: "name of the script"}
 	scriptFileFlag    = cli.StringFlag{Name: "script.file", Usage: "if specified, the file where the source of the script can be found. Otherwise uses stdin"}
 	scriptConfigFlag  = cli.StringFlag{Name: "script.config", Usage: "if specified, the file where the source of the config can be found.", Value: ""}
 	runTimeFlag       = cli.DurationFlag{Name: "duration", Value: time.Minute, Usage: "how long to perform the load test for"}
 	maxExecPerSecFlag = cli.IntFlag{Name: "max.exec.ps", Value: 100, Usage: "number of executions per second"}
+	keepCookiesFlag   = cli.BoolFlag{Name: "keep.cookies", Usage: "keep the cookies of each worker from one execution of the script to the next"}
+	profileFlag       = cli.StringFlag{Name: "profile", Usage: "if specified, the file where the stages of the load profile can be found. They replace the duration and the rate"}
+	thresholdFlag     = cli.StringSliceFlag{Name: "threshold", Value: &cli.StringSlice{}, Usage: "condition the load test must meet to pass, like 'p95(step:login) < 300ms', 'error_rate < 1%', 'check_rate >= 99%' or 'rps >= 500'. Steps and checks of scenarios are named like 'checkout/pay'. Can be repeated"}
+	abortFlag         = cli.StringSliceFlag{Name: "abort", Value: &cli.StringSlice{}, Usage: "condition that halts the load test as soon as it holds, like 'error_rate > 5% over 30s' or 'p99 > 2s over 1m'. Without a window it covers the whole run. It isn't judged on fewer than 50 executions before the load test ran for 10s, and for the window. Can be repeated"}
+	junitFlag         = cli.StringFlag{Name: "junit", Usage: "if specified, the file where a JUnit report of the thresholds is written"}
+	rollupsFlag       = cli.BoolFlag{Name: "rollups", Usage: "persist rollups of the requests and steps every 10s instead of a point for each of them, for long or heavy load tests"}
+	openModelFlag     = cli.BoolFlag{Name: "open.model", Usage: "start executions on schedule even if earlier ones haven't completed, dropping those no worker is free to run"}
+	dataFileFlag      = cli.StringFlag{Name: "data.file", Usage: "if specified, a CSV or NDJSON file of records the script reads with data.next()"}
+	scenariosFileFlag = cli.StringFlag{Name: "scenarios.file", Usage: "if specified, a JSON file of scenarios run side by side instead of the script, each with a script, a config, and a weight, a rate or a profile of its own"}
+	dataModeFlag      = cli.StringFlag{Name: "data.mode", Value: "sequential", Usage: "how records are handed out: sequential, random or unique (each used once)"}
 
 	growthFactorFlag              = cli.Float64Flag{Name: "extra.growth.factor", Value: 1.5}
 	timeBetweenGrowthFlag         = cli.DurationFlag{Name: "extra.time.between.growth", Value: time.Second}
@@ -62,19 +77,53 @@ func newApp() *cli.App {
 	var client pb.SchedulerClient
 	app.Flags = []cli.Flag{
 		addrFlag,
+		configFlag,
+		tlsFlag,
+		caCertFlag,
 		tgtFlag,
 		scriptNameFlag,
 		scriptFileFlag,
 		scriptConfigFlag,
 		runTimeFlag,
 		maxExecPerSecFlag,
+		profileFlag,
+		thresholdFlag,
+		abortFlag,
+		junitFlag,
+		keepCookiesFlag,
+		openModelFlag,
+		rollupsFlag,
+		dataFileFlag,
+		dataModeFlag,
+		scenariosFileFlag,
 		growthFactorFlag,
 		timeBetweenGrowthFlag,
 		startingRequestsPerSecondFlag,
 	}
+	app.Commands = loadTestCommands(&client)
 	app.Before = func(ctx *cli.Context) error {
 		addr := ctx.GlobalString(addrFlag.Name)
-		cc, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithTimeout(2*time.Second), grpc.WithBlock())
+		key, err := apiKey(ctx.GlobalString(configFlag.Name))
+		if err != nil {
+			return err
+		}
+		opts := []grpc.DialOption{grpc.WithTimeout(2 * time.Second), grpc.WithBlock()}
+		caCert := ctx.GlobalString(caCertFlag.Name)
+		if ctx.GlobalBool(tlsFlag.Name) || caCert != "" {
+			creds, err := rpcauth.ClientTLS(caCert)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, grpc.WithTransportCredentials(creds))
+		} else if key != "" {
+			return fmt.Errorf("the API key is only sent over TLS, use --%s or --%s", tlsFlag.Name, caCertFlag.Name)
+		} else {
+			opts = append(opts, grpc.WithInsecure())
+		}
+		if key != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(rpcauth.BearerToken(key)))
+		}
+		cc, err := grpc.Dial(addr, opts...)
 		if err != nil {
 			return err
 		}
@@ -86,13 +135,20 @@ func newApp() *cli.App {
 		if ctx.GlobalString(scriptNameFlag.Name) == "" {
 			log.Fatalf("param %q required to run script", scriptNameFlag.Name)
 		}
-		script, err := readFileOrStdin(ctx, scriptFileFlag)
-		if err != nil {
-			log.Fatal(err)
-		}
-		scriptConfig, err := readFileIfExists(ctx, scriptConfigFlag)
-		if err != nil {
-			log.Fatal(err)
+		var script, scriptConfig []byte
+		var scenarios []*pb.Scenario
+		var err error
+		if filename := ctx.GlobalString(scenariosFileFlag.Name); filename != "" {
+			if scenarios, err = readScenarios(filename); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			if script, err = readFileOrStdin(ctx, scriptFileFlag); err != nil {
+				log.Fatal(err)
+			}
+			if scriptConfig, err = readFileIfExists(ctx, scriptConfigFlag); err != nil {
+				log.Fatal(err)
+			}
 		}
 
 		in := &pb.LoadTestReq{
@@ -105,6 +161,34 @@ func newApp() *cli.App {
 			TimeBetweenGrowth:         ctx.Duration(timeBetweenGrowthFlag.Name).Seconds(),
 			StartingRequestsPerSecond: int32(ctx.GlobalInt(maxExecPerSecFlag.Name)),
 			ScriptConfig:              string(scriptConfig),
+			KeepCookies:               ctx.GlobalBool(keepCookiesFlag.Name),
+			OpenModel:                 ctx.GlobalBool(openModelFlag.Name),
+			Thresholds:                ctx.GlobalStringSlice(thresholdFlag.Name),
+			AbortConditions:           ctx.GlobalStringSlice(abortFlag.Name),
+			Rollups:                   ctx.GlobalBool(rollupsFlag.Name),
+			Scenarios:                 scenarios,
+		}
+		if filename := ctx.GlobalString(dataFileFlag.Name); filename != "" {
+			if in.DataFeed, err = readDataFeed(filename, ctx.GlobalString(dataModeFlag.Name)); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if filename := ctx.GlobalString(profileFlag.Name); filename != "" {
+			profile, err := readFile(filename)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if in.Stages, err = parseProfile(profile); err != nil {
+				log.Fatal(err)
+			}
+			in.RunTime = 0
+			in.MaxRequestsPerSecond = 0
+			for _, stage := range in.Stages {
+				in.RunTime += int32(stage.Duration)
+				if stage.TargetRequestsPerSecond > in.MaxRequestsPerSecond {
+					in.MaxRequestsPerSecond = stage.TargetRequestsPerSecond
+				}
+			}
 		}
 		if in.StartingRequestsPerSecond == 0 {
 			in.StartingRequestsPerSecond = in.MaxRequestsPerSecond
@@ -123,16 +207,24 @@ func newApp() *cli.App {
 			in.ScriptName,
 			humanize.IBytes(uint64(len(in.Script))),
 		)
+		for _, scenario := range in.Scenarios {
+			log.Printf("  scenario %q (%v)", scenario.Name, humanize.IBytes(uint64(len(scenario.Script))))
+		}
 		now := time.Now()
 		srv, err := client.LoadTest(context.Background(), in)
 		if err != nil {
 			log.Fatalf("issuing load test request: %v", err)
 		}
+		// CI pipelines rely on the exit code to tell if the load test passed
+		passed := false
 		for {
 			res, err := srv.Recv()
 			switch err {
 			case io.EOF:
 				log.Print("done")
+				if !passed {
+					os.Exit(1)
+				}
 				return
 			default:
 				log.Fatalf("waiting for response: %v", err)
@@ -140,11 +232,27 @@ func newApp() *cli.App {
 			}
 			switch {
 			case res.GetPreparing() != nil:
-				log.Printf("%s: load test is preparing %d workers...", time.Since(now), res.GetPreparing().Count)
+				log.Printf("%s: load test %q is preparing %d workers...", time.Since(now), res.GetPreparing().Id, res.GetPreparing().Count)
 			case res.GetStart() != nil:
 				log.Printf("%s: load test started!", time.Since(now))
 			case res.GetFinish() != nil:
 				log.Printf("%s: load test finished!", time.Since(now))
+				verdict := res.GetFinish().Verdict
+				passed = verdict == nil || verdict.Passed
+				logChecks(res.GetFinish().Checks)
+				logVerdict(verdict)
+				if filename := ctx.GlobalString(junitFlag.Name); filename != "" {
+					if err := writeJUnit(filename, in.ScriptName, time.Since(now), verdict); err != nil {
+						log.Fatalf("writing JUnit report: %v", err)
+					}
+				}
+			case res.GetProgress() != nil:
+				logProgress(time.Since(now), res.GetProgress())
+			case res.GetCancel() != nil:
+				log.Printf("%s: load test cancelled!", time.Since(now))
+			case res.GetError().GetAbort() != nil:
+				abort := res.GetError().GetAbort()
+				log.Printf("%s: load test aborted: %s (observed %s)", time.Since(now), abort.Condition, abort.Observed)
 			case res.GetError() != nil:
 				log.Printf("%s: load test had an error: %v", time.Since(now), res.GetError().Error)
 			default:
@@ -156,6 +264,45 @@ func newApp() *cli.App {
 	return app
 }
 
+func logProgress(elapsed time.Duration, progress *pb.LoadTestResp_Progress) {
+	log.Printf("%s: %.1f req/s, %d executions, %d errors",
+		elapsed, progress.RequestsPerSecond, progress.Executions, progress.Errors)
+	secs := func(s float64) time.Duration { return time.Duration(s * float64(time.Second)) }
+	for _, step := range progress.Steps {
+		log.Printf("%s:   step %q: %d runs, %d errors, p50=%v p95=%v p99=%v max=%v",
+			elapsed, step.Name, step.Count, step.Errors,
+			secs(step.P50), secs(step.P95), secs(step.P99), secs(step.Max))
+	}
+	for _, check := range progress.Checks {
+		log.Printf("%s:   check %q: %d passed, %d failed", elapsed, check.Name, check.Passes, check.Fails)
+	}
+}
+
+func logChecks(checks []*pb.Check) {
+	for _, check := range checks {
+		rate := 100 * float64(check.Passes) / float64(check.Passes+check.Fails)
+		log.Printf("check %q: %.2f%% passed (%d of %d)", check.Name, rate, check.Passes, check.Passes+check.Fails)
+	}
+}
+
+func logVerdict(verdict *pb.Verdict) {
+	if verdict == nil {
+		return
+	}
+	for _, threshold := range verdict.Thresholds {
+		result := "PASS"
+		if !threshold.Passed {
+			result = "FAIL"
+		}
+		log.Printf("%s: %s (observed %s)", result, threshold.Expression, threshold.Observed)
+	}
+	if verdict.Passed {
+		log.Print("every threshold was met")
+	} else {
+		log.Print("some thresholds were not met")
+	}
+}
+
 func readFileOrStdin(ctx *cli.Context, fileFlag cli.StringFlag) ([]byte, error) {
 	filename := ctx.GlobalString(fileFlag.Name)
 	if filename == "" || filename == "-" {
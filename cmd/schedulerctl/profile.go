@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// profileStage is how a stage is written in a profile file:
+//
+//	[
+//	  {"duration": "1m", "target": 500},
+//	  {"duration": "10m", "target": 500},
+//	  {"duration": "30s", "target": 2000, "interpolation": "step"},
+//	  {"duration": "1m", "target": 0}
+//	]
+type profileStage struct {
+	Duration      string `json:"duration"`
+	Target        int32  `json:"target"`
+	Interpolation string `json:"interpolation"`
+}
+
+func parseProfile(data []byte) ([]*pb.Stage, error) {
+	var stages []profileStage
+	if err := json.Unmarshal(data, &stages); err != nil {
+		return nil, fmt.Errorf("invalid profile: %v", err)
+	}
+	var out []*pb.Stage
+	for i, stage := range stages {
+		dur, err := time.ParseDuration(stage.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: invalid duration: %v", i, err)
+		}
+		interpolation, ok := pb.Stage_Interpolation_value[strings.ToUpper(stage.Interpolation)]
+		if !ok && stage.Interpolation != "" {
+			return nil, fmt.Errorf("stage %d: unknown interpolation %q", i, stage.Interpolation)
+		}
+		out = append(out, &pb.Stage{
+			Duration:                dur.Seconds(),
+			TargetRequestsPerSecond: stage.Target,
+			Interpolation:           pb.Stage_Interpolation(interpolation),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    []*pb.Stage
+		wantErr string
+	}{
+		{
+			profile: `[
+				{"duration": "1m", "target": 500},
+				{"duration": "1m30s", "target": 500, "interpolation": "linear"},
+				{"duration": "30s", "target": 2000, "interpolation": "Step"},
+				{"duration": "1m", "target": 0}
+			]`,
+			want: []*pb.Stage{
+				{Duration: 60, TargetRequestsPerSecond: 500, Interpolation: pb.Stage_LINEAR},
+				{Duration: 90, TargetRequestsPerSecond: 500, Interpolation: pb.Stage_LINEAR},
+				{Duration: 30, TargetRequestsPerSecond: 2000, Interpolation: pb.Stage_STEP},
+				{Duration: 60, TargetRequestsPerSecond: 0, Interpolation: pb.Stage_LINEAR},
+			},
+		},
+		{profile: `[]`},
+		{profile: `{"duration": "1m"}`, wantErr: "invalid profile"},
+		{profile: `[{"duration": "a minute", "target": 1}]`, wantErr: "stage 0: invalid duration"},
+		{profile: `[{"duration": "1m"}, {"duration": "1m", "interpolation": "cubic"}]`, wantErr: `stage 1: unknown interpolation "cubic"`},
+	}
+	for _, tt := range tests {
+		got, err := parseProfile([]byte(tt.profile))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: want an error with %q, got %v", tt.profile, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.profile, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: want %d stages, got %d", tt.profile, len(tt.want), len(got))
+			continue
+		}
+		for i := range got {
+			if *got[i] != *tt.want[i] {
+				t.Errorf("%s: stage %d: want %v, got %v", tt.profile, i, tt.want[i], got[i])
+			}
+		}
+	}
+}
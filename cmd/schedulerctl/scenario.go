@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// scenarioEntry is how a scenario is written in a scenarios file, the paths
+// being relative to the file:
+//
+//	[
+//	  {"name": "browse", "script": "browse.lua", "weight": 70},
+//	  {"name": "search", "script": "search.lua", "config": "search.json", "weight": 25},
+//	  {"name": "checkout", "script": "checkout.lua", "rps": 20},
+//	  {"name": "spike", "script": "spike.lua", "profile": "spike.json"}
+//	]
+//
+// Scenarios with a weight share the rate, or the profile, of the load test.
+type scenarioEntry struct {
+	Name    string  `json:"name"`
+	Script  string  `json:"script"`
+	Config  string  `json:"config"`
+	Weight  float64 `json:"weight"`
+	RPS     int32   `json:"rps"`
+	Profile string  `json:"profile"`
+}
+
+func readScenarios(filename string) ([]*pb.Scenario, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var entries []scenarioEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid scenarios: %v", err)
+	}
+	dir := filepath.Dir(filename)
+	read := func(name string) ([]byte, error) {
+		if name == "" || filepath.IsAbs(name) {
+			return readFile(name)
+		}
+		return readFile(filepath.Join(dir, name))
+	}
+
+	var scenarios []*pb.Scenario
+	for _, entry := range entries {
+		scenario := &pb.Scenario{
+			Name:              entry.Name,
+			Weight:            entry.Weight,
+			RequestsPerSecond: entry.RPS,
+		}
+		script, err := read(entry.Script)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %q: %v", entry.Name, err)
+		}
+		scenario.Script = string(script)
+		if entry.Config != "" {
+			config, err := read(entry.Config)
+			if err != nil {
+				return nil, fmt.Errorf("scenario %q: %v", entry.Name, err)
+			}
+			scenario.ScriptConfig = string(config)
+		}
+		if entry.Profile != "" {
+			profile, err := read(entry.Profile)
+			if err != nil {
+				return nil, fmt.Errorf("scenario %q: %v", entry.Name, err)
+			}
+			if scenario.Stages, err = parseProfile(profile); err != nil {
+				return nil, fmt.Errorf("scenario %q: %v", entry.Name, err)
+			}
+		}
+		scenarios = append(scenarios, scenario)
+	}
+	return scenarios, nil
+}
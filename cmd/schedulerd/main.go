@@ -6,22 +6,33 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/digitalocean/go-metadata"
 	"github.com/digitalocean/godo"
+	"github.com/flynn/flynn/controller/name"
 	"github.com/ianschenck/envflag"
 	"github.com/lgpeterson/loadtests/scheduler"
 	"github.com/lgpeterson/loadtests/scheduler/pb"
 	"golang.org/x/oauth2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+func init() {
+	// load tests are named after it
+	name.SetSeed([]byte(time.Now().Format(time.UnixDate))[:10])
+}
+
 func main() {
 
 	var (
 		executorBinaryFilepath = flag.String("executor.binary.filepath", "", "path where the Go binary for the executor can be found")
+		provider               = flag.String("provider", "digitalocean", "where executors are launched, either 'digitalocean' or 'local' to run them as processes of this machine")
+		localPidFile           = flag.String("local.pidfile", filepath.Join(os.TempDir(), "loadtests-executors.pid"), "file where the pids of the executors run by the 'local' provider are kept, so that they are killed by the next scheduler")
 
 		port = flag.Int("port", 0, "port on which to listen for service requests")
 
@@ -33,39 +44,94 @@ func main() {
 		maxWaitExecutorOnline = flag.Duration("max.wait.executor.online", 2*time.Minute, "max duration to wait for before giving up on an executor to register itself")
 		maxWorkerPerExecutor  = flag.Int("max.worker.per.executor", 100, "max number of threads scheduled on a single executor")
 		maxExecPSPerExecutor  = flag.Int("max.rps.per.executor", 500, "max number of requests per second requests of a single executor")
+		hooksTimeout          = flag.Duration("hooks.timeout", time.Minute, "max duration of the setup, and of the teardown, of a script")
 
 		influxAddr     = flag.String("influx.addr", "", "address where the influx DB can be found")
 		influxUsername = flag.String("influx.username", "", "username to use when connecting to influx DB")
 		influxPassword = flag.String("influx.password", "", "password to authenticate with influx DB")
 		influxDBName   = flag.String("influx.db.name", "", "name of the influx DB to which metrics are sent")
 		influxSSL      = flag.Bool("influx.use.ssl", false, "whether to use SSL when talking to influx DB")
+
+		verificationToken = flag.String("target.verification.token", "", "token a target must serve at "+scheduler.VerificationPath+", or publish in a DNS TXT record, before being load tested. Any target can be load tested if empty")
+		verificationTTL   = flag.Duration("target.verification.ttl", 24*time.Hour, "how long a verified target can be load tested before being verified again")
+		targetAllowlist   = flag.String("target.allowlist", "", "comma separated hosts, on any port, or host:port that can be load tested without being verified")
+
+		keysFile = flag.String("keys.file", "", "JSON file of the API keys allowed to use the scheduler, -tls.cert and -tls.key are required with it. Anyone who can reach the scheduler can use it if empty")
+		addKey   = flag.String("keys.add", "", "if specified, add a key with this name to the keys file, print it and exit")
+		keyRoles = flag.String("keys.roles", string(scheduler.RoleRun), "comma separated roles of the key added with -keys.add: run, cancel-others or admin")
+
+		tlsCert = flag.String("tls.cert", "", "PEM certificate the scheduler serves over TLS with, valid for the address it listens on. The executors are given it to register")
+		tlsKey  = flag.String("tls.key", "", "PEM private key of -tls.cert")
 	)
-	envflag.StringVar(executorBinaryFilepath, "EXECUTOR_BINARY_FILEPATH", "", "")
-	envflag.IntVar(port, "PORT", 0, "")
-	envflag.StringVar(token, "DO_TOKEN", "", "")
-	envflag.StringVar(dropletRegion, "DROPLET_REGION", "", "")
-	envflag.StringVar(dropletSize, "DROPLET_SIZE", "", "")
-	envflag.StringVar(dropletImageSlug, "DROPLET_IMAGE", "", "")
-	envflag.DurationVar(maxWaitExecutorOnline, "MAX_WAIT_EXECUTOR_ONLINE", 0, "")
-	envflag.IntVar(maxWorkerPerExecutor, "MAX_WORKER_PER_EXECUTOR", 0, "")
-	envflag.IntVar(maxExecPSPerExecutor, "MAX_RPS_PER_EXECUTOR", 0, "")
-	envflag.StringVar(influxAddr, "INFLUX_ADDR", "", "")
-	envflag.StringVar(influxUsername, "INFLUX_USERNAME", "", "")
-	envflag.StringVar(influxPassword, "INFLUX_PASSWORD", "", "")
-	envflag.StringVar(influxDBName, "INFLUX_DB_NAME", "", "")
-	envflag.BoolVar(influxSSL, "INFLUX_USE_SSL", false, "")
+	envflag.StringVar(executorBinaryFilepath, "EXECUTOR_BINARY_FILEPATH", *executorBinaryFilepath, "")
+	envflag.StringVar(provider, "PROVIDER", *provider, "")
+	envflag.StringVar(localPidFile, "LOCAL_PIDFILE", *localPidFile, "")
+	envflag.IntVar(port, "PORT", *port, "")
+	envflag.StringVar(token, "DO_TOKEN", *token, "")
+	envflag.StringVar(dropletRegion, "DROPLET_REGION", *dropletRegion, "")
+	envflag.StringVar(dropletSize, "DROPLET_SIZE", *dropletSize, "")
+	envflag.StringVar(dropletImageSlug, "DROPLET_IMAGE", *dropletImageSlug, "")
+	envflag.DurationVar(maxWaitExecutorOnline, "MAX_WAIT_EXECUTOR_ONLINE", *maxWaitExecutorOnline, "")
+	envflag.IntVar(maxWorkerPerExecutor, "MAX_WORKER_PER_EXECUTOR", *maxWorkerPerExecutor, "")
+	envflag.IntVar(maxExecPSPerExecutor, "MAX_RPS_PER_EXECUTOR", *maxExecPSPerExecutor, "")
+	envflag.DurationVar(hooksTimeout, "HOOKS_TIMEOUT", *hooksTimeout, "")
+	envflag.StringVar(influxAddr, "INFLUX_ADDR", *influxAddr, "")
+	envflag.StringVar(influxUsername, "INFLUX_USERNAME", *influxUsername, "")
+	envflag.StringVar(influxPassword, "INFLUX_PASSWORD", *influxPassword, "")
+	envflag.StringVar(influxDBName, "INFLUX_DB_NAME", *influxDBName, "")
+	envflag.BoolVar(influxSSL, "INFLUX_USE_SSL", *influxSSL, "")
+	envflag.StringVar(verificationToken, "TARGET_VERIFICATION_TOKEN", *verificationToken, "")
+	envflag.DurationVar(verificationTTL, "TARGET_VERIFICATION_TTL", *verificationTTL, "")
+	envflag.StringVar(targetAllowlist, "TARGET_ALLOWLIST", *targetAllowlist, "")
+	envflag.StringVar(keysFile, "KEYS_FILE", *keysFile, "")
+	envflag.StringVar(tlsCert, "TLS_CERT", *tlsCert, "")
+	envflag.StringVar(tlsKey, "TLS_KEY", *tlsKey, "")
 
 	envflag.Parse()
 	flag.Parse()
 
-	md, err := metadata.NewClient().Metadata()
-	if err != nil {
-		logrus.WithError(err).Fatal("can't reach DO metadata service")
+	var keys *scheduler.KeyStore
+	if *keysFile != "" {
+		var err error
+		if keys, err = scheduler.OpenKeyStore(*keysFile); err != nil {
+			logrus.WithError(err).Fatal("can't open the keys file")
+		}
+	}
+	if *addKey != "" {
+		if keys == nil {
+			logrus.Fatal("a keys file is required to add a key")
+		}
+		roles, err := scheduler.ParseRoles(splitList(*keyRoles))
+		if err != nil {
+			logrus.WithError(err).Fatal("invalid roles")
+		}
+		key, err := keys.Add(*addKey, roles)
+		if err != nil {
+			logrus.WithError(err).Fatal("can't add key")
+		}
+		fmt.Println(key)
+		return
+	}
+
+	var serverOpts []grpc.ServerOption
+	if *tlsCert != "" || *tlsKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(*tlsCert, *tlsKey)
+		if err != nil {
+			logrus.WithError(err).Fatal("can't load the TLS certificate")
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	} else if keys != nil {
+		logrus.Fatal("the keys would be sent in clear, -tls.cert and -tls.key are required with a keys file")
 	}
-	iface := md.Interfaces["public"][0].IPv4.IPAddress
 
-	addr := startExecutorBinaryFileserver(iface, *executorBinaryFilepath)
-	cloud, sshKeys := connectToDO(*token)
+	iface := "127.0.0.1"
+	if *provider == "digitalocean" {
+		md, err := metadata.NewClient().Metadata()
+		if err != nil {
+			logrus.WithError(err).Fatal("can't reach DO metadata service")
+		}
+		iface = md.Interfaces["public"][0].IPv4.IPAddress
+	}
 
 	svcl, err := net.Listen("tcp", fmt.Sprintf("%s:%d", iface, *port))
 	if err != nil {
@@ -74,9 +140,7 @@ func main() {
 	defer svcl.Close()
 
 	cfg := &scheduler.Config{
-		PullExecutorBinaryURL: fmt.Sprintf("http://%s", addr.String()),
-		AdvertiseListenAddr:   svcl.Addr().String(),
-		SSHKeyIDs:             sshKeys,
+		AdvertiseListenAddr: svcl.Addr().String(),
 
 		DropletRegion:    *dropletRegion,
 		DropletSize:      *dropletSize,
@@ -85,21 +149,59 @@ func main() {
 		MaxWaitExecutorOnline: *maxWaitExecutorOnline,
 		MaxWorkerPerExecutor:  *maxWorkerPerExecutor,
 		MaxExecPSPerExecutor:  *maxExecPSPerExecutor,
+		HooksTimeout:          *hooksTimeout,
 
 		InfluxAddr:     *influxAddr,
 		InfluxUsername: *influxUsername,
 		InfluxPassword: *influxPassword,
 		InfluxDBName:   *influxDBName,
 		InfluxSSL:      *influxSSL,
+
+		TargetVerificationToken: *verificationToken,
+		TargetVerificationTTL:   *verificationTTL,
+
+		TLSCertFile: *tlsCert,
+	}
+	cfg.TargetAllowlist = splitList(*targetAllowlist)
+	if keys != nil {
+		if cfg.ExecutorToken, err = scheduler.NewExecutorToken(); err != nil {
+			logrus.WithError(err).Fatal("can't generate the token of the executors")
+		}
+	} else {
+		logrus.Warn("callers aren't authenticated, anyone who can reach the scheduler can use it")
+	}
+	if cfg.TargetVerificationToken == "" {
+		logrus.Warn("targets aren't verified, anything can be load tested")
 	}
 
-	db, err := scheduler.NewDB(cfg, cloud)
+	var executors scheduler.Provider
+	switch *provider {
+	case "digitalocean":
+		addr := startExecutorBinaryFileserver(iface, *executorBinaryFilepath)
+		cloud, sshKeys := connectToDO(*token)
+		cfg.PullExecutorBinaryURL = fmt.Sprintf("http://%s", addr.String())
+		cfg.SSHKeyIDs = sshKeys
+		executors = scheduler.NewDigitalOcean(cfg, cloud)
+	case "local":
+		if _, err := os.Stat(*executorBinaryFilepath); err != nil {
+			logrus.WithError(err).WithField("path", *executorBinaryFilepath).Fatal("can't run given path as an executor binary file")
+		}
+		executors = scheduler.NewLocal(*executorBinaryFilepath, cfg.AdvertiseListenAddr, cfg.TLSCertFile, cfg.ExecutorToken, *localPidFile)
+	default:
+		logrus.WithField("provider", *provider).Fatal("unknown provider")
+	}
+
+	db, err := scheduler.NewDB(cfg, executors)
 	if err != nil {
 		logrus.WithError(err).Fatal("can't prepare DB")
 	}
 	svc := scheduler.NewServer(cfg, db)
-	srv := grpc.NewServer()
-	pb.RegisterSchedulerServer(srv, svc)
+	srv := grpc.NewServer(serverOpts...)
+	if keys != nil {
+		pb.RegisterSchedulerServer(srv, svc.Authenticate(keys))
+	} else {
+		pb.RegisterSchedulerServer(srv, svc)
+	}
 
 	logrus.WithField("addr", svcl.Addr().String()).Info("scheduler RPC listening")
 	if err := srv.Serve(svcl); err != nil {
@@ -107,6 +209,17 @@ func main() {
 	}
 }
 
+// splitList splits a comma separated list, ignoring empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func startExecutorBinaryFileserver(iface, filepath string) *net.TCPAddr {
 	_, err := os.Stat(filepath)
 	if err != nil {
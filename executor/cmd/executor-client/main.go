@@ -64,6 +64,11 @@ func sendTestMesage(message *exgrpc.CommandMessage, ip string) (*exgrpc.StatusMe
 	if err = client.Send(message); err != nil {
 		return nil, err
 	}
-	mes, err := client.Recv()
-	return mes, err
+	for {
+		// skip the snapshots sent during the execution
+		mes, err := client.Recv()
+		if err != nil || mes.Snapshot == nil {
+			return mes, err
+		}
+	}
 }
@@ -2,8 +2,11 @@ package main
 
 import (
 	"flag"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/benbjohnson/clock"
@@ -19,12 +22,24 @@ func main() {
 		addr      = flag.String("scheduler_addr", "localhost:50045", "the IP and port to connect to")
 		port      = flag.Int("port", 50053, "The port for grpc to listen on")
 		dropletId = flag.Int("dropletId", -1, "If you want to override the droplet Id being sent to the scheduler")
+		certFile  = flag.String("scheduler_cert", "", "If specified, the certificate of the scheduler, or of its CA, to register over TLS")
+		tokenFile = flag.String("scheduler_token_file", "", "If specified, the file holding the token to register with, which the commands must present too, instead of $SCHEDULER_TOKEN")
 	)
 	flag.Parse()
 
-	start(*addr, *port, *dropletId)
+	// The scheduler launched the executor with the token it registers with,
+	// and commands it with
+	token := os.Getenv("SCHEDULER_TOKEN")
+	if *tokenFile != "" {
+		data, err := ioutil.ReadFile(*tokenFile)
+		if err != nil {
+			log.Fatalf("error reading the token %v", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	start(*addr, *certFile, token, *port, *dropletId)
 }
-func start(schedulerAddr string, port int, dropletId int) {
+func start(schedulerAddr, schedulerCert, token string, port int, dropletId int) {
 	persister := &persister.InfluxPersister{}
 
 	// Loop forever, because I will wait for commands from the grpc server
@@ -35,7 +50,7 @@ func start(schedulerAddr string, port int, dropletId int) {
 		}
 		dropletId = id
 	}
-	s, err := controller.NewGRPCExecutorStarter(persister, schedulerAddr, port, dropletId, clock.New())
+	s, err := controller.NewGRPCExecutorStarter(persister, schedulerAddr, schedulerCert, token, port, dropletId, clock.New())
 	if err != nil {
 		log.Fatalf("err starting grpc server %v", err)
 	}
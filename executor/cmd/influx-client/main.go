@@ -24,7 +24,7 @@ func testIflux(ip string) {
 	if err != nil {
 		log.Fatalf("Error creating influx persistor: %v", err)
 	}
-	metrics.IncrHTTPGet("http://localhost/foo", 200, time.Millisecond/10)
+	metrics.IncrHTTPRequest("GET", "http://localhost/foo", 200, time.Millisecond/10)
 
 	pass := os.Getenv("INFLUX_PWD")
 	user := os.Getenv("INFLUX_USER")
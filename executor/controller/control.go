@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	executor "github.com/lgpeterson/loadtests/executor/pb"
+)
+
+// pendingControls is how many controls can wait to be applied
+const pendingControls = 16
+
+// control is a change of a running execution asked by the scheduler.
+type control struct {
+	pause  bool
+	resume bool
+	// zero keeps the current value
+	requestsPerSecond int
+	workers           int32
+}
+
+// readControl reads a command received during an execution of `params`.
+func readControl(in *executor.CommandMessage, params *executor.ScriptParams) (control, error) {
+	switch cmd := in.Kind.(type) {
+	case *executor.CommandMessage_Pause:
+		return control{pause: true}, nil
+	case *executor.CommandMessage_Resume:
+		return control{resume: true}, nil
+	case *executor.CommandMessage_SetRate:
+		rate, workers := cmd.SetRate.RequestsPerSecond, cmd.SetRate.Workers
+		if rate < 0 || workers < 0 || rate == 0 && workers == 0 {
+			return control{}, withCode(executor.ErrorCode_INVALID_COMMAND,
+				fmt.Errorf("a rate or workers greater than 0 must be given"))
+		}
+		if max := maxWorkers(params); workers > max {
+			return control{}, withCode(executor.ErrorCode_INVALID_COMMAND,
+				fmt.Errorf("the execution has at most %d workers, not %d", max, workers))
+		}
+		return control{requestsPerSecond: int(rate), workers: workers}, nil
+	case *executor.CommandMessage_Run:
+		return control{}, withCode(executor.ErrorCode_INVALID_COMMAND,
+			fmt.Errorf("the execution is already running"))
+	default:
+		return control{}, withCode(executor.ErrorCode_UNSUPPORTED_COMMAND,
+			fmt.Errorf("only Halt, Pause, Resume and SetRate are supported during an execution"))
+	}
+}
+
+// maxWorkers is how many workers the execution of `params` has, all its
+// scenarios together.
+func maxWorkers(params *executor.ScriptParams) int32 {
+	if len(params.Scenarios) == 0 {
+		return params.MaxWorkers
+	}
+	var workers int32
+	for _, scenario := range params.Scenarios {
+		workers += scenario.Params.MaxWorkers
+	}
+	return workers
+}
+
+// scenarioShares are the parts of the rate and of the workers every scenario
+// has, and so of the controls it applies. The parts are even when the
+// scenarios have no rate, or no workers, at all.
+func scenarioShares(scenarios []*executor.Scenario) (rates, workers []float64) {
+	var totalRate, totalWorkers float64
+	for _, scenario := range scenarios {
+		totalRate += float64(scenario.Params.MaxRequestsPerSecond)
+		totalWorkers += float64(scenario.Params.MaxWorkers)
+	}
+	part := func(value, total float64) float64 {
+		if total == 0 {
+			return 1 / float64(len(scenarios))
+		}
+		return value / total
+	}
+	for _, scenario := range scenarios {
+		rates = append(rates, part(float64(scenario.Params.MaxRequestsPerSecond), totalRate))
+		workers = append(workers, part(float64(scenario.Params.MaxWorkers), totalWorkers))
+	}
+	return rates, workers
+}
+
+// split is the part of the control every scenario applies, the scenario
+// `i` having `rates[i]` of the rate and `workers[i]` of the workers.
+func (c control) split(rates, workers []float64) []control {
+	shares := make([]control, len(rates))
+	for i := range shares {
+		shares[i] = c
+	}
+	if c.requestsPerSecond > 0 {
+		for i, rate := range splitValue(c.requestsPerSecond, rates) {
+			shares[i].requestsPerSecond = rate
+		}
+	}
+	if c.workers > 0 {
+		for i, w := range splitValue(int(c.workers), workers) {
+			shares[i].workers = int32(w)
+		}
+	}
+	return shares
+}
+
+// splitValue splits `total` along `parts`, so that the values add up to it:
+// what rounding down leaves over goes to the values below 1 first, as every
+// one keeps at least 1, then to the first ones.
+func splitValue(total int, parts []float64) []int {
+	values := make([]int, len(parts))
+	left := total
+	for i, part := range parts {
+		values[i] = int(math.Floor(float64(total) * part))
+		left -= values[i]
+	}
+	for i := range values {
+		if values[i] < 1 {
+			values[i] = 1
+			left--
+		}
+	}
+	for i := 0; left > 0; i = (i + 1) % len(values) {
+		values[i]++
+		left--
+	}
+	return values
+}
+
+// String describes the control, for the annotations.
+func (c control) String() string {
+	switch {
+	case c.pause:
+		return "paused"
+	case c.resume:
+		return "resumed"
+	case c.workers == 0:
+		return fmt.Sprintf("rate set to %drps", c.requestsPerSecond)
+	case c.requestsPerSecond == 0:
+		return fmt.Sprintf("workers set to %d", c.workers)
+	default:
+		return fmt.Sprintf("rate set to %drps on %d workers", c.requestsPerSecond, c.workers)
+	}
+}
+
+// workerLimit is how many of the workers take jobs, the others wait for it
+// to change.
+type workerLimit struct {
+	lock    sync.Mutex
+	workers int32
+	changed chan struct{}
+}
+
+func newWorkerLimit(workers int32) *workerLimit {
+	return &workerLimit{workers: workers, changed: make(chan struct{})}
+}
+
+func (l *workerLimit) set(workers int32) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.workers = workers
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// allows tells if the worker `id` takes jobs, if not the channel is closed
+// once the limit changes.
+func (l *workerLimit) allows(id int32) (bool, <-chan struct{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return id < l.workers, l.changed
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lgpeterson/loadtests/executor/pb"
+)
+
+func TestScenarioShares(t *testing.T) {
+	scenario := func(rate, workers int32) *executorGRPC.Scenario {
+		return &executorGRPC.Scenario{Params: &executorGRPC.ScriptParams{MaxRequestsPerSecond: rate, MaxWorkers: workers}}
+	}
+	tests := []struct {
+		name      string
+		scenarios []*executorGRPC.Scenario
+		// the control every scenario applies
+		ctrl control
+		want string
+	}{
+		{
+			name:      "weighted and fixed rates",
+			scenarios: []*executorGRPC.Scenario{scenario(75, 6), scenario(25, 2), scenario(100, 2)},
+			ctrl:      control{requestsPerSecond: 100, workers: 5},
+			want:      "[rate set to 38rps on 3 workers rate set to 12rps on 1 workers rate set to 50rps on 1 workers]",
+		},
+		{
+			name:      "every scenario keeps a worker",
+			scenarios: []*executorGRPC.Scenario{scenario(90, 9), scenario(10, 1)},
+			ctrl:      control{workers: 2},
+			want:      "[workers set to 1 workers set to 1]",
+		},
+		{
+			name:      "the leftover goes to the first scenarios",
+			scenarios: []*executorGRPC.Scenario{scenario(10, 1), scenario(10, 1), scenario(10, 1)},
+			ctrl:      control{requestsPerSecond: 11, workers: 5},
+			want:      "[rate set to 4rps on 2 workers rate set to 4rps on 2 workers rate set to 3rps on 1 workers]",
+		},
+		{
+			name:      "no rate at all",
+			scenarios: []*executorGRPC.Scenario{scenario(0, 3), scenario(0, 1)},
+			ctrl:      control{requestsPerSecond: 10, workers: 4},
+			want:      "[rate set to 5rps on 3 workers rate set to 5rps on 1 workers]",
+		},
+		{
+			name:      "no workers at all",
+			scenarios: []*executorGRPC.Scenario{scenario(0, 0), scenario(0, 0)},
+			ctrl:      control{workers: 4},
+			want:      "[workers set to 2 workers set to 2]",
+		},
+		{
+			name:      "pausing isn't shared",
+			scenarios: []*executorGRPC.Scenario{scenario(10, 1), scenario(0, 0)},
+			ctrl:      control{pause: true},
+			want:      "[paused paused]",
+		},
+	}
+	for _, tt := range tests {
+		rates, workers := scenarioShares(tt.scenarios)
+		var got []string
+		for _, share := range tt.ctrl.split(rates, workers) {
+			got = append(got, share.String())
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%s: want %s, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package controller
 
 import (
-	"encoding/json"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -17,8 +17,16 @@ import (
 type Controller struct {
 	Command *executorGRPC.ScriptParams
 	Config  string
-	Server  executorGRPC.Commander_ExecuteCommandServer
 	Clock   clock.Clock
+	// Scenario tags the metrics when the command is a scenario of a load
+	// test with several
+	Scenario string
+
+	// the conversation with the scheduler, the controllers of the
+	// scenarios share it
+	session *session
+	// controls are applied to the execution while it runs
+	controls chan control
 }
 
 // Persister is an interface to save whatever data is grabbed from the executor
@@ -34,33 +42,130 @@ var (
 
 // RunInstructions will get the IP from the file it found and send it to the pinger
 func (f *Controller) RunInstructions(persister Persister, dropletId int, halt chan struct{}) error {
+	if len(f.Command.Scenarios) > 0 {
+		return f.runScenarios(persister, dropletId, halt)
+	}
 	script := strings.NewReader(f.Command.Script)
-	_, err := engine.Lua(script)
+	prgm, err := engine.Lua(script)
 	if err != nil {
-		return err
+		return withCode(executorGRPC.ErrorCode_INVALID_SCRIPT, err)
 	}
-	if f.Config != "" {
-		cfg := make(map[string]interface{})
-		if err = json.Unmarshal([]byte(f.Config), &cfg); err != nil {
-			return err
+	if f.Command.SetupData != "" {
+		if err := prgm.SetSetupData([]byte(f.Command.SetupData)); err != nil {
+			return withCode(executorGRPC.ErrorCode_INVALID_SCRIPT, err)
 		}
-		if err = engine.VerifyConfig(cfg); err != nil {
-			return err
+	}
+	var cfg map[string]interface{}
+	if f.Config != "" {
+		if cfg, err = engine.ParseConfig(f.Config); err != nil {
+			return withCode(executorGRPC.ErrorCode_INVALID_SCRIPT, err)
 		}
 	}
-	bps, err := f.runScript(dropletId, persister, halt)
+	feeds, err := dataFeeds(f.Command.DataFeed, int(f.Command.MaxWorkers))
+	if err != nil {
+		return withCode(executorGRPC.ErrorCode_INVALID_COMMAND, err)
+	}
+	bps, err := f.runScript(dropletId, cfg, feeds, persister, halt)
 	if err != nil {
 		return err
 	}
-	return sendBatchPoints(persister, bps)
+	return withCode(executorGRPC.ErrorCode_PERSISTENCE, sendBatchPoints(persister, bps))
 }
 
-func (f *Controller) runScript(dropletId int, persister Persister, halt chan struct{}) (client.BatchPoints, error) {
-	jobChannel := make(chan struct{}, f.Command.MaxRequestsPerSecond)
+// runScenarios runs every scenario side by side, each like the command of
+// a load test of its own.
+func (f *Controller) runScenarios(persister Persister, dropletId int, halt chan struct{}) error {
+	var scenarios []*Controller
+	for _, scenario := range f.Command.Scenarios {
+		scenarios = append(scenarios, &Controller{
+			Command:  scenario.Params,
+			Config:   scenario.ScriptConfig,
+			Clock:    f.Clock,
+			Scenario: scenario.Name,
+			session:  f.session,
+			controls: make(chan control, pendingControls),
+		})
+	}
+	rates, workers := scenarioShares(f.Command.Scenarios)
+	ended := make([]chan struct{}, len(scenarios))
+	for i := range ended {
+		ended[i] = make(chan struct{})
+	}
+
+	// Every scenario gets its share of the controls. A scenario with too
+	// many pending controls holds the others back, until the controls of
+	// the execution are full and the new ones rejected.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case ctrl := <-f.controls:
+				for i, share := range ctrl.split(rates, workers) {
+					select {
+					case scenarios[i].controls <- share:
+					case <-ended[i]:
+					case <-done:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	errs := make(chan error, len(scenarios))
+	for i, c := range scenarios {
+		go func(c *Controller, ended chan struct{}) {
+			defer close(ended)
+			if err := c.RunInstructions(persister, dropletId, halt); err != nil {
+				errs <- withCode(errorCode(err), fmt.Errorf("scenario %q: %v", c.Scenario, err))
+				return
+			}
+			errs <- nil
+		}(c, ended[i])
+	}
+	var firstErr error
+	for range scenarios {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f *Controller) runScript(dropletId int, cfg map[string]interface{}, feeds []engine.DataFeed, persister Persister, halt chan struct{}) (client.BatchPoints, error) {
+	// I want to send jobs every 100 miliseconds
+	tickTimer := time.Millisecond * 100
+
+	// In the open model, jobs must start on schedule, so at most a tick's
+	// worth of them can wait for a worker. The others are dropped.
+	jobCapacity := int(f.Command.MaxRequestsPerSecond)
+	if f.Command.OpenModel {
+		jobCapacity = getNumberOfIterations(tickTimer, jobCapacity) + 1
+	}
+	jobChannel := make(chan job, jobCapacity)
 	done := make(chan struct{})
 	var completeChannels []chan struct{}
 	var metricsList []*MetricsGatherer
 	var wg sync.WaitGroup
+	limit := newWorkerLimit(f.Command.MaxWorkers)
+
+	// Metrics that aren't about any worker in particular
+	controllerMetrics, err := NewMetricsGatherer(f.Command.ScriptId, dropletId, -1)
+	if err != nil {
+		return nil, err
+	}
+	controllerMetrics.Scenario = f.Scenario
+	metricsList = append(metricsList, controllerMetrics)
+	live := newLiveStats()
+	// When rollups are wanted, the workers only aggregate the measurements
+	var roll *rollup
+	if f.Command.Rollups {
+		roll = newRollup()
+		roll.scenario = f.Scenario
+	}
 
 	// Create all the workers that will listen for jobs
 	for i := int32(0); i < f.Command.MaxWorkers; i++ {
@@ -69,14 +174,20 @@ func (f *Controller) runScript(dropletId int, persister Persister, halt chan str
 		if err != nil {
 			return nil, err
 		}
+		metrics.Live = live
+		metrics.Rollup = roll
+		metrics.Scenario = f.Scenario
 		w := &worker{
 			WorkerId:   i,
 			Command:    f.Command,
-			Config:     f.Config,
+			Config:     cfg,
+			DataFeed:   feeds[i],
 			Metrics:    metrics,
+			Clock:      f.Clock,
 			Wait:       &wg,
 			JobChannel: jobChannel,
 			Done:       workerDone,
+			Limit:      limit,
 		}
 		completeChannels = append(completeChannels, workerDone)
 		metricsList = append(metricsList, metrics)
@@ -84,21 +195,78 @@ func (f *Controller) runScript(dropletId int, persister Persister, halt chan str
 	}
 
 	requestsPerSecond := int(f.Command.StartingRequestsPerSecond)
+	runTime := time.Second * time.Duration(f.Command.RunTime)
+	profile := loadProfile(f.Command.Stages)
+	if len(profile) > 0 {
+		requestsPerSecond = int(profile.rateAt(0))
+		runTime = profile.duration()
+	}
 
-	// I want to send jobs every 100 miliseconds
-	tickTimer := time.Millisecond * 100
-	// Find how many jobs to send every tick
-	iterations := getNumberOfIterations(tickTimer, requestsPerSecond)
+	// Jobs owed from previous ticks, as the rate is rarely a multiple of the
+	// ticks
+	owed := 0.0
 
 	ticker := f.Clock.Ticker(tickTimer)
 	defer ticker.Stop()
 
-	growthTicker := f.Clock.Ticker(time.Second * time.Duration(f.Command.TimeBetweenGrowth))
-	defer growthTicker.Stop()
+	// The stages of a profile decide of the rate instead of the growth
+	var growth <-chan time.Time
+	if len(profile) == 0 {
+		growthTicker := f.Clock.Ticker(time.Second * time.Duration(f.Command.TimeBetweenGrowth))
+		defer growthTicker.Stop()
+		growth = growthTicker.C
+	}
 	growthActive := true
+	// Paused, no job is handed out
+	paused := false
+
+	snapshots := f.Clock.Ticker(snapshotInterval)
+	defer snapshots.Stop()
+	lastSnapshot := f.Clock.Now()
 
+	var rollups <-chan time.Time
+	if roll != nil {
+		rollupTicker := f.Clock.Ticker(rollupInterval)
+		defer rollupTicker.Stop()
+		rollups = rollupTicker.C
+	}
+	lastRollup := f.Clock.Now()
+	flushRollup := func(now time.Time) {
+		if roll != nil {
+			controllerMetrics.addPoints(roll.points(f.Command.ScriptId, dropletId, now, now.Sub(lastRollup)))
+			lastRollup = now
+		}
+	}
+
+	applyControl := func(ctrl control) {
+		switch {
+		case ctrl.pause:
+			paused = true
+		case ctrl.resume:
+			paused = false
+		default:
+			if ctrl.requestsPerSecond > 0 {
+				// The rate is now the one of the scheduler
+				requestsPerSecond = ctrl.requestsPerSecond
+				profile = nil
+				growthActive = false
+			}
+			if ctrl.workers > 0 {
+				limit.set(ctrl.workers)
+			}
+		}
+		log.Printf("Execution %v", ctrl)
+		controllerMetrics.AddAnnotation(ctrl.String())
+	}
+
+	start := f.Clock.Now()
+	if f.session != nil {
+		if err := f.session.started(); err != nil {
+			log.Printf("Error sending start: %v", err)
+		}
+	}
 	go func() {
-		f.Clock.Sleep(time.Second * time.Duration(f.Command.RunTime))
+		f.Clock.Sleep(runTime)
 		close(done)
 	}()
 
@@ -108,27 +276,69 @@ func (f *Controller) runScript(dropletId int, persister Persister, halt chan str
 		select {
 		case <-halt:
 			close(jobChannel)
-			return stopWorkers(completeChannels, metricsList, &wg)
+			stopWorkers(completeChannels, &wg)
+			// What happened since the last snapshot and rollup
+			f.sendSnapshot(live.snapshot(f.Clock.Now().Sub(lastSnapshot)))
+			flushRollup(f.Clock.Now())
+			return getBatchPoints(metricsList)
 		case <-done:
 			close(jobChannel)
-			return stopWorkers(completeChannels, metricsList, &wg)
+			stopWorkers(completeChannels, &wg)
+			f.sendSnapshot(live.snapshot(f.Clock.Now().Sub(lastSnapshot)))
+			flushRollup(f.Clock.Now())
+			return getBatchPoints(metricsList)
 
-		case <-ticker.C:
-			totalIterations = totalIterations + iterations
-			for i := 1; i < iterations; i++ {
+		case now := <-ticker.C:
+			// The controls accepted before the tick apply to it
+			for pending := true; pending; {
 				select {
-				case jobChannel <- struct{}{}:
-				case <-done:
-					break select_again
-				case <-halt:
-					break select_again
+				case ctrl := <-f.controls:
+					applyControl(ctrl)
+				default:
+					pending = false
+				}
+			}
+			if paused {
+				break
+			}
+			rate := float64(requestsPerSecond)
+			if len(profile) > 0 {
+				// The rate of a stage isn't rounded, a slow ramp adds up
+				rate = profile.rateAt(now.Sub(start))
+			}
+			owed += rate * tickTimer.Seconds()
+			count := int(owed)
+			owed -= float64(count)
+			if f.Command.OpenModel {
+				dropped := dispatchOpen(jobChannel, now, tickTimer, count)
+				if dropped > 0 {
+					controllerMetrics.AddDroppedIterations(dropped)
+				}
+				totalIterations = totalIterations + count - dropped
+			} else {
+				for i := 0; i < count; i++ {
+					select {
+					case jobChannel <- job{scheduled: now}:
+						totalIterations++
+					case <-done:
+						break select_again
+					case <-halt:
+						break select_again
+					}
 				}
 			}
 			if totalIterations > numSavedExecutions {
 				totalIterations = 0
 				go sendData(metricsList, persister, &wg)
 			}
-		case <-growthTicker.C:
+		case now := <-snapshots.C:
+			f.sendSnapshot(live.snapshot(now.Sub(lastSnapshot)))
+			lastSnapshot = now
+		case now := <-rollups:
+			flushRollup(now)
+		case ctrl := <-f.controls:
+			applyControl(ctrl)
+		case <-growth:
 			if growthActive {
 				requestsPerSecond = int(float64(requestsPerSecond) * f.Command.GrowthFactor)
 				if requestsPerSecond > int(f.Command.MaxRequestsPerSecond) {
@@ -136,14 +346,42 @@ func (f *Controller) runScript(dropletId int, persister Persister, halt chan str
 					requestsPerSecond = int(f.Command.MaxRequestsPerSecond)
 					growthActive = false
 				}
-				// The number of jobs per tick will now have increased
-				iterations = getNumberOfIterations(tickTimer, requestsPerSecond)
 			}
 
 		}
 	}
 
 }
+
+// sendSnapshot tells the scheduler how the execution is going, it's only
+// informative so failing to do so isn't fatal.
+func (f *Controller) sendSnapshot(snap *executorGRPC.Snapshot) {
+	if f.session == nil {
+		return
+	}
+	snap.Scenario = f.Scenario
+	if err := f.session.progress(snap); err != nil {
+		log.Printf("Error sending snapshot: %v", err)
+	}
+}
+
+// dispatchOpen hands out the jobs of the next tick, their start spread evenly
+// over it. A job is never waited for: if it can't be queued, or no worker
+// picks it up before the tick after it was due, it is dropped. Returns how
+// many jobs couldn't be queued.
+func dispatchOpen(jobChannel chan<- job, now time.Time, tick time.Duration, count int) int {
+	dropped := 0
+	for i := 0; i < count; i++ {
+		scheduled := now.Add(tick * time.Duration(i) / time.Duration(count))
+		select {
+		case jobChannel <- job{scheduled: scheduled, deadline: scheduled.Add(tick)}:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
 func sendData(metricsList []*MetricsGatherer, persister Persister, wg *sync.WaitGroup) {
 	wg.Add(1)
 	defer wg.Done()
@@ -157,13 +395,12 @@ func sendData(metricsList []*MetricsGatherer, persister Persister, wg *sync.Wait
 	}
 }
 
-func stopWorkers(completeChannels []chan struct{}, metricsList []*MetricsGatherer, wg *sync.WaitGroup) (client.BatchPoints, error) {
+func stopWorkers(completeChannels []chan struct{}, wg *sync.WaitGroup) {
 	log.Println("Ending load test")
 	for _, workerDoneChannel := range completeChannels {
 		close(workerDoneChannel)
 	}
 	wg.Wait()
-	return getBatchPoints(metricsList)
 }
 
 func getBatchPoints(metricsList []*MetricsGatherer) (client.BatchPoints, error) {
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lgpeterson/loadtests/executor/engine"
+	"github.com/lgpeterson/loadtests/executor/pb"
+)
+
+// dataFeeds returns the feed of every worker. The workers share one feed,
+// except in the unique mode where each gets its own part of the records.
+// Without a data feed, all of them are nil.
+func dataFeeds(feed *executorGRPC.DataFeed, workers int) ([]engine.DataFeed, error) {
+	feeds := make([]engine.DataFeed, workers)
+	if feed == nil {
+		return feeds, nil
+	}
+	records := make([]map[string]interface{}, 0, len(feed.Records))
+	for i, raw := range feed.Records {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &record); err != nil {
+			return nil, fmt.Errorf("data feed: record %d isn't a JSON object: %v", i, err)
+		}
+		records = append(records, record)
+	}
+
+	var shared engine.DataFeed
+	switch feed.Mode {
+	case executorGRPC.DataFeed_SEQUENTIAL:
+		shared = engine.NewSequentialFeed(records)
+	case executorGRPC.DataFeed_RANDOM:
+		shared = engine.NewRandomFeed(records)
+	case executorGRPC.DataFeed_UNIQUE:
+		for i := range feeds {
+			feeds[i] = engine.NewUniqueFeed(records[i*len(records)/workers : (i+1)*len(records)/workers])
+		}
+		return feeds, nil
+	default:
+		return nil, fmt.Errorf("data feed: unknown mode %v", feed.Mode)
+	}
+	for i := range feeds {
+		feeds[i] = shared
+	}
+	return feeds, nil
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"net"
@@ -9,8 +10,11 @@ import (
 	"github.com/benbjohnson/clock"
 	executor "github.com/lgpeterson/loadtests/executor/pb"
 	scheduler "github.com/lgpeterson/loadtests/scheduler/pb"
+	"github.com/lgpeterson/loadtests/scheduler/rpcauth"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 )
 
 // GRPCExecutorStarter this will read what ip to ping from a file
@@ -18,11 +22,27 @@ type GRPCExecutorStarter struct {
 	persister Persister
 	clock     clock.Clock
 	dropletId int
+	// the commands must present the token, if set
+	token string
 }
 
-// NewGRPCExecutorStarter this creates a new GRPCExecutorStarter and sets the directory to look in
-func NewGRPCExecutorStarter(persister Persister, schedulerAddr string, port int, dropletId int, clock clock.Clock) (*grpc.Server, error) {
-	err := registerDroplet(dropletId, persister, schedulerAddr, port)
+// NewGRPCExecutorStarter this creates a new GRPCExecutorStarter and sets the directory to look in.
+// The executor registers with `token`, if the scheduler gave it one. It
+// registers over TLS, trusting `schedulerCert`, when given; it then serves
+// over TLS too, with a certificate of its own it registers with. The
+// commands must present the token too, only the scheduler has it.
+func NewGRPCExecutorStarter(persister Persister, schedulerAddr, schedulerCert, token string, port int, dropletId int, clock clock.Clock) (*grpc.Server, error) {
+	var opts []grpc.ServerOption
+	var certificate []byte
+	if schedulerCert != "" {
+		cert, err := rpcauth.NewCertificate()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+		certificate = cert.Certificate[0]
+	}
+	err := registerDroplet(dropletId, persister, schedulerAddr, schedulerCert, token, port, certificate)
 	if err != nil {
 		return nil, err
 	}
@@ -31,24 +51,38 @@ func NewGRPCExecutorStarter(persister Persister, schedulerAddr string, port int,
 		persister: persister,
 		clock:     clock,
 		dropletId: dropletId,
+		token:     token,
 	}
-	s := grpc.NewServer()
+	s := grpc.NewServer(opts...)
 	executor.RegisterCommanderServer(s, executorStarter)
 	return s, nil
 }
 
 func registerDroplet(dropletId int, persister Persister,
-	schedulerAddr string, port int) error {
+	schedulerAddr, schedulerCert, token string, port int, certificate []byte) error {
 
 	req := &scheduler.RegisterExecutorReq{
-		Port:      int64(port),
-		DropletId: int64(dropletId),
+		Port:            int64(port),
+		DropletId:       int64(dropletId),
+		ProtocolVersion: int32(protocolVersion),
+		Certificate:     certificate,
 	}
 
-	timeout := grpc.WithTimeout(15 * time.Second)
-	insecure := grpc.WithInsecure()
+	opts := []grpc.DialOption{grpc.WithTimeout(15 * time.Second)}
+	if schedulerCert != "" {
+		creds, err := rpcauth.ClientTLS(schedulerCert)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(rpcauth.BearerToken(token)))
+	}
 	// Set up a connection to the server.
-	conn, err := grpc.Dial(schedulerAddr, timeout, insecure)
+	conn, err := grpc.Dial(schedulerAddr, opts...)
 	if err != nil {
 		return err
 	}
@@ -69,33 +103,44 @@ func (s *GRPCExecutorStarter) ExecuteCommand(server executor.Commander_ExecuteCo
 	var halted = false
 	var serverErr error
 
+	// The commands hold the scripts of the callers of the scheduler, and
+	// their secrets
+	if s.token != "" && subtle.ConstantTimeCompare([]byte(rpcauth.Credentials(server.Context())), []byte(s.token)) != 1 {
+		log.Println("Refusing a command without the executor token")
+		return grpc.Errorf(codes.Unauthenticated, "invalid executor token")
+	}
+
 	in, err := server.Recv()
 	if err != nil {
 		log.Printf("Error from scheduler: %v", err)
+		return err
 	}
 	// Don't trust the user to give me what I want
-
-	if in.Command != "Run" {
-		// I will only accept the 'Run' command at this stage
-		err = server.Send(&executor.StatusMessage{Status: "Invalid"})
-		return err
+	sess := newSession(server, s.clock)
+	params, config, err := sess.readRun(in)
+	if err == nil {
+		err = verifyCommand(params)
 	}
-	if err = verifyCommand(in.ScriptParams); err != nil {
+	if err != nil {
 		log.Printf("Invalid Command Given: %v", err)
-		_ = server.Send(&executor.StatusMessage{Status: "Invalid: " + err.Error()})
+		_ = sess.failed(withCode(executor.ErrorCode_INVALID_COMMAND, err))
 		return err
 	}
 
 	log.Printf("Received command: %v", in)
-	executorController := &Controller{Command: in.ScriptParams, Server: server, Clock: s.clock, Config: in.ScriptConfig}
+	if err = sess.accepted(); err != nil {
+		return err
+	}
+	executorController := &Controller{Command: params, Clock: s.clock, Config: config, session: sess,
+		controls: make(chan control, pendingControls)}
 
-	go listenForHalt(halt, &halted, &serverErr, server)
+	go listenForHalt(halt, &halted, &serverErr, executorController)
 
 	err = executorController.RunInstructions(s.persister, s.dropletId, halt)
 
 	if err != nil {
 		log.Printf("Error executing: %v", err)
-		_ = server.Send(&executor.StatusMessage{Status: "Invalid: " + err.Error()})
+		_ = sess.failed(err)
 		return err
 	} else if serverErr != nil {
 		// If the recv wait gave an error I want to return it, if possible
@@ -103,15 +148,16 @@ func (s *GRPCExecutorStarter) ExecuteCommand(server executor.Commander_ExecuteCo
 	} else if halted {
 		// I want to tell the server I halted
 		log.Println("Halted")
-		err = server.Send(&executor.StatusMessage{Status: "Halted"})
-		return err
+		return sess.finished(true)
 	} else {
-		err = server.Send(&executor.StatusMessage{Status: "OK"})
-		return err
+		return sess.finished(false)
 	}
 }
 
-func listenForHalt(halt chan struct{}, halted *bool, serverErr *error, server executor.Commander_ExecuteCommandServer) {
+// listenForHalt reads the commands received during the execution, until it's
+// halted. The other commands are handed to the controller.
+func listenForHalt(halt chan struct{}, halted *bool, serverErr *error, controller *Controller) {
+	sess := controller.session
 	defer func() {
 		// This function will execute if the connection is closed
 		// There is no way to recv with polling, so I resort to catching the panic when the connection closes
@@ -121,21 +167,30 @@ func listenForHalt(halt chan struct{}, halted *bool, serverErr *error, server ex
 		}
 	}()
 	for {
-		mes, serverErr := server.Recv()
+		mes, serverErr := sess.server.Recv()
 		if serverErr != nil {
 			log.Printf("err from scheduler: %v", serverErr)
 			// If there is an error, I assume it means that the server may not be able to
 			// communicate with the executor, and halt the execution
 			return
 		} else if mes != nil {
-			if mes.Command == "Halt" {
+			if isHalt(mes) {
 				// Stop execution and turn the halted flag on so I know to send the 'Halted' message back
 				*halted = true
 				log.Println("Halting now")
 				return
-			} else {
+			} else if sess.version < executor.ProtocolVersion_V2 {
 				// I will only accept the 'Halt' command at this stage
-				server.Send(&executor.StatusMessage{Status: "Invalid"})
+				sess.rejected(executor.ErrorCode_UNSUPPORTED_COMMAND, fmt.Errorf("only Halt is supported during an execution"))
+			} else if ctrl, err := readControl(mes, controller.Command); err != nil {
+				sess.rejected(errorCode(err), err)
+			} else {
+				select {
+				case controller.controls <- ctrl:
+					sess.accepted()
+				default:
+					sess.rejected(executor.ErrorCode_INTERNAL, fmt.Errorf("too many controls waiting to be applied"))
+				}
 			}
 		}
 	}
@@ -151,11 +206,21 @@ func CreateListenPort(port int) (net.Listener, error) {
 }
 
 func verifyCommand(in *executor.ScriptParams) error {
+	if in == nil {
+		return fmt.Errorf("Script parameters must be given")
+	}
+	if len(in.Scenarios) > 0 {
+		return verifyScenarios(in.Scenarios)
+	}
 
 	// TODO find out the max goroutines the executor can handle
 	if in.MaxWorkers < 1 {
 		return fmt.Errorf("Max Workers must be greater than 0. Given MaxWorkers: %d", in.MaxWorkers)
 	}
+	if len(in.Stages) > 0 {
+		// The stages replace the run time and the growth parameters
+		return verifyProfile(in)
+	}
 	if in.RunTime <= 1 {
 		return fmt.Errorf("You must run for greater than 1 second. Given Runtime: %d", in.RunTime)
 	}
@@ -171,10 +236,32 @@ func verifyCommand(in *executor.ScriptParams) error {
 			in.MaxRequestsPerSecond, in.StartingRequestsPerSecond)
 	}
 	if in.GrowthFactor < 1 {
-		return fmt.Errorf("Growth Factor must be greater or equal to 1. Given GrowthFactor: %d", in.GrowthFactor)
+		return fmt.Errorf("Growth Factor must be greater or equal to 1. Given GrowthFactor: %v", in.GrowthFactor)
 	}
 	if in.TimeBetweenGrowth < 0.1 {
-		return fmt.Errorf("Time Between Growth must be greater or equal to 0.1. Given TimeBetweenGrowth: %d", in.TimeBetweenGrowth)
+		return fmt.Errorf("Time Between Growth must be greater or equal to 0.1. Given TimeBetweenGrowth: %v", in.TimeBetweenGrowth)
+	}
+	return nil
+}
+
+// verifyScenarios verifies that every scenario is a valid command on its own,
+// that can be told apart from the others.
+func verifyScenarios(scenarios []*executor.Scenario) error {
+	names := make(map[string]bool, len(scenarios))
+	for _, scenario := range scenarios {
+		if scenario.Name == "" {
+			return fmt.Errorf("Every scenario needs a name")
+		}
+		if names[scenario.Name] {
+			return fmt.Errorf("Scenario %q is given twice", scenario.Name)
+		}
+		names[scenario.Name] = true
+		if scenario.Params == nil || len(scenario.Params.Scenarios) > 0 {
+			return fmt.Errorf("Scenario %q must have parameters, and no scenarios", scenario.Name)
+		}
+		if err := verifyCommand(scenario.Params); err != nil {
+			return fmt.Errorf("Scenario %q: %v", scenario.Name, err)
+		}
 	}
 	return nil
 }
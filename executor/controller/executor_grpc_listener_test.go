@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"io"
+	"testing"
+
+	"github.com/lgpeterson/loadtests/executor/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// commandStream is a command stream without any command, which tells if
+// the executor read it.
+type commandStream struct {
+	executorGRPC.Commander_ExecuteCommandServer
+	ctx  context.Context
+	read bool
+}
+
+func (s *commandStream) Context() context.Context { return s.ctx }
+
+func (s *commandStream) Recv() (*executorGRPC.CommandMessage, error) {
+	s.read = true
+	return nil, io.EOF
+}
+
+func TestExecuteCommandToken(t *testing.T) {
+	starter := &GRPCExecutorStarter{token: "s3cr3t"}
+	as := func(token string) context.Context {
+		return metadata.NewContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{name: "without a token", ctx: context.Background(), want: codes.Unauthenticated},
+		{name: "with another token", ctx: as("not-it"), want: codes.Unauthenticated},
+		// The stream then ends before the command
+		{name: "with the token", ctx: as("s3cr3t"), want: codes.Unknown},
+	}
+	for _, tt := range tests {
+		stream := &commandStream{ctx: tt.ctx}
+		err := starter.ExecuteCommand(stream)
+		if grpc.Code(err) != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, err)
+		}
+		if refused := tt.want == codes.Unauthenticated; stream.read == refused {
+			t.Errorf("%s: want the command read %v, got %v", tt.name, !refused, stream.read)
+		}
+	}
+}
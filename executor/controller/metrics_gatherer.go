@@ -1,10 +1,12 @@
 package controller
 
 import (
+	"strings"
 	"sync"
 	"time"
 
 	client "github.com/influxdb/influxdb/client/v2"
+	"github.com/lgpeterson/loadtests/executor/engine"
 )
 
 type MetricsGatherer struct {
@@ -14,6 +16,18 @@ type MetricsGatherer struct {
 	WorkerId    int32
 	TestId      int
 	Mutex       *sync.Mutex
+	// Live is also told of what happens, for the snapshots sent to the
+	// scheduler
+	Live *liveStats
+	// Rollup, if set, aggregates executions, steps and requests instead of
+	// adding a point for each of them
+	Rollup *rollup
+	// Scenario, if set, tags every point
+	Scenario string
+	// StartDelay is how late the current execution started. In the open
+	// model, requests are measured from when they would have been sent had
+	// it started on time, so that a slow target doesn't hide its latency
+	StartDelay time.Duration
 }
 
 func NewMetricsGatherer(scriptId string, dropletId int, workerId int32) (*MetricsGatherer, error) {
@@ -43,10 +57,15 @@ func (m *MetricsGatherer) ClearBatchPoints() (client.BatchPoints, error) {
 }
 
 func (m *MetricsGatherer) IncrScriptExecution() {
+	m.Live.addExecution()
+	if m.Rollup != nil {
+		m.Rollup.addExecution()
+		return
+	}
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 	m.BatchPoints.AddPoint(client.NewPoint("ExecutionExecutionTable",
-		nil,
+		m.tags(nil),
 		map[string]interface{}{
 			"serverId": m.DropletId,
 			"threadId": m.WorkerId,
@@ -57,10 +76,15 @@ func (m *MetricsGatherer) IncrScriptExecution() {
 }
 
 func (m *MetricsGatherer) IncrStepExecution(step string, dur time.Duration) {
+	m.Live.addStep(step, dur)
+	if m.Rollup != nil {
+		m.Rollup.addStep(step, dur)
+		return
+	}
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 	m.BatchPoints.AddPoint(client.NewPoint("StepExecutionTable",
-		nil,
+		m.tags(nil),
 		map[string]interface{}{
 			"serverId":    m.DropletId,
 			"threadId":    m.WorkerId,
@@ -74,10 +98,15 @@ func (m *MetricsGatherer) IncrStepExecution(step string, dur time.Duration) {
 }
 
 func (m *MetricsGatherer) IncrStepError(step string) {
+	m.Live.addStepError(step)
+	if m.Rollup != nil {
+		m.Rollup.addStepError(step)
+		return
+	}
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 	m.BatchPoints.AddPoint(client.NewPoint("StepErrorTable",
-		nil,
+		m.tags(nil),
 		map[string]interface{}{
 			"serverId": m.DropletId,
 			"threadId": m.WorkerId,
@@ -89,11 +118,18 @@ func (m *MetricsGatherer) IncrStepError(step string) {
 	))
 }
 
-func (m *MetricsGatherer) IncrHTTPGet(url string, code int, duration time.Duration) {
+func (m *MetricsGatherer) IncrHTTPRequest(method, url string, code int, duration time.Duration) {
+	m.Live.addRequest(code >= 400)
+	method = requestMethod(method)
+	duration += m.StartDelay
+	if m.Rollup != nil {
+		m.Rollup.addRequest(method, url, code, duration)
+		return
+	}
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
-	m.BatchPoints.AddPoint(client.NewPoint("GetRequestTable",
-		nil,
+	m.BatchPoints.AddPoint(client.NewPoint(requestTable(method),
+		m.tags(map[string]string{"method": method}),
 		map[string]interface{}{
 			"serverId":    m.DropletId,
 			"threadId":    m.WorkerId,
@@ -102,40 +138,130 @@ func (m *MetricsGatherer) IncrHTTPGet(url string, code int, duration time.Durati
 			"url":         url,
 			"code":        code,
 			"duration_ns": duration.Nanoseconds(),
+			"delay_ns":    m.StartDelay.Nanoseconds(),
 		},
 		time.Now(),
 	))
 }
 
-func (m *MetricsGatherer) IncrHTTPPost(url string, code int, duration time.Duration) {
+// requestTable is the measurement of the requests of a method, which is also
+// their method tag. GET and POST requests stay in the GetRequestTable and
+// PostRequestTable of the existing dashboards, the other methods share the
+// RequestTable.
+func requestTable(method string) string {
+	switch method {
+	case "GET":
+		return "GetRequestTable"
+	case "POST":
+		return "PostRequestTable"
+	}
+	return "RequestTable"
+}
+
+// requestMethod is how the method of a request is tagged, whatever the case
+// the script used.
+func requestMethod(method string) string {
+	if method == "" {
+		return "GET"
+	}
+	return strings.ToUpper(method)
+}
+
+func (m *MetricsGatherer) IncrHTTPTimings(method, url string, timings engine.HTTPTimings) {
+	method = requestMethod(method)
+	if m.Rollup != nil {
+		m.Rollup.addTimings(method, url, timings)
+		return
+	}
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
-	m.BatchPoints.AddPoint(client.NewPoint("PostRequestTable",
-		nil,
+	m.BatchPoints.AddPoint(client.NewPoint("RequestTimingTable",
+		m.tags(nil),
 		map[string]interface{}{
 			"serverId":    m.DropletId,
 			"threadId":    m.WorkerId,
 			"testId":      m.TestId,
 			"id":          m.ScriptId,
+			"method":      method,
 			"url":         url,
-			"code":        code,
-			"duration_ns": duration.Nanoseconds(),
+			"dns_ns":      timings.DNS.Nanoseconds(),
+			"connect_ns":  timings.Connect.Nanoseconds(),
+			"tls_ns":      timings.TLS.Nanoseconds(),
+			"ttfb_ns":     timings.TTFB.Nanoseconds(),
+			"transfer_ns": timings.Transfer.Nanoseconds(),
+			"duration_ns": timings.Total.Nanoseconds(),
 		},
 		time.Now(),
 	))
 }
 
-func (m *MetricsGatherer) IncrHTTPError(url string) {
+// IncrHTTPError records a request that failed, the kind of error is a tag so
+// they can be told apart. Errors the script expected don't count as failures
+// in the snapshots.
+func (m *MetricsGatherer) IncrHTTPError(url string, err *engine.HTTPError) {
+	m.Live.addRequest(!err.Expected)
+	if m.Rollup != nil {
+		m.Rollup.addHTTPError(url, err)
+		return
+	}
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 	m.BatchPoints.AddPoint(client.NewPoint("ErrorRequestTable",
-		nil,
+		m.tags(map[string]string{"kind": err.Kind}),
 		map[string]interface{}{
 			"serverId": m.DropletId,
 			"threadId": m.WorkerId,
 			"testId":   m.TestId,
 			"id":       m.ScriptId,
 			"url":      url,
+			"message":  err.Message,
+			"expected": err.Expected,
+		},
+		time.Now(),
+	))
+}
+
+// IncrCheck records the outcome of a check of the script.
+func (m *MetricsGatherer) IncrCheck(name string, passed bool) {
+	m.Live.addCheck(name, passed)
+	if m.Rollup != nil {
+		m.Rollup.addCheck(name, passed)
+		return
+	}
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	m.BatchPoints.AddPoint(client.NewPoint("CheckTable",
+		m.tags(nil),
+		map[string]interface{}{
+			"serverId": m.DropletId,
+			"threadId": m.WorkerId,
+			"testId":   m.TestId,
+			"id":       m.ScriptId,
+			"check":    name,
+			"passed":   passed,
+		},
+		time.Now(),
+	))
+}
+
+// IncrCustomMetric records an update of a metric declared by the script, as
+// a point of its own measurement.
+func (m *MetricsGatherer) IncrCustomMetric(name, kind string, value float64, tags map[string]string) {
+	if m.Rollup != nil {
+		m.Rollup.addCustom(name, kind, value, tags)
+		return
+	}
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	m.BatchPoints.AddPoint(client.NewPoint(name,
+		m.tags(tags),
+		map[string]interface{}{
+			"serverId": m.DropletId,
+			"threadId": m.WorkerId,
+			"testId":   m.TestId,
+			"id":       m.ScriptId,
+			"kind":     kind,
+			"value":    value,
 		},
 		time.Now(),
 	))
@@ -149,10 +275,11 @@ func (m *MetricsGatherer) IncrLogFatal(msg interface{}) {
 }
 
 func (m *MetricsGatherer) AddLuaError(err error) {
+	m.Live.addError()
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 	m.BatchPoints.AddPoint(client.NewPoint("LuaErrorTable",
-		nil,
+		m.tags(nil),
 		map[string]interface{}{
 			"serverId": m.DropletId,
 			"threadId": m.WorkerId,
@@ -164,11 +291,94 @@ func (m *MetricsGatherer) AddLuaError(err error) {
 	))
 }
 
+// AddIterationLatency records an execution of the script, `delay` is how
+// late it started and `latency` how long it took to complete, both measured
+// from when it was scheduled to start.
+func (m *MetricsGatherer) AddIterationLatency(delay, latency time.Duration) {
+	if m.Rollup != nil {
+		m.Rollup.addIteration(latency)
+		return
+	}
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	m.BatchPoints.AddPoint(client.NewPoint("IterationTable",
+		m.tags(nil),
+		map[string]interface{}{
+			"serverId":    m.DropletId,
+			"threadId":    m.WorkerId,
+			"testId":      m.TestId,
+			"id":          m.ScriptId,
+			"delay_ns":    delay.Nanoseconds(),
+			"duration_ns": latency.Nanoseconds(),
+		},
+		time.Now(),
+	))
+}
+
+// AddDroppedIterations records executions of the script that were due but
+// couldn't start because no worker was free.
+func (m *MetricsGatherer) AddDroppedIterations(count int) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	m.BatchPoints.AddPoint(client.NewPoint("DroppedIterationTable",
+		m.tags(nil),
+		map[string]interface{}{
+			"serverId": m.DropletId,
+			"threadId": m.WorkerId,
+			"id":       m.ScriptId,
+			"dropped":  count,
+		},
+		time.Now(),
+	))
+}
+
+// AddAnnotation records a change of the execution, to be shown on the
+// timeline of the metrics.
+func (m *MetricsGatherer) AddAnnotation(text string) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	m.BatchPoints.AddPoint(client.NewPoint("AnnotationTable",
+		m.tags(nil),
+		map[string]interface{}{
+			"serverId": m.DropletId,
+			"id":       m.ScriptId,
+			"text":     text,
+		},
+		time.Now(),
+	))
+}
+
+// tags adds the scenario, if any, to the tags of a point.
+func (m *MetricsGatherer) tags(tags map[string]string) map[string]string {
+	return withScenario(tags, m.Scenario)
+}
+
+func withScenario(tags map[string]string, scenario string) map[string]string {
+	if scenario == "" {
+		return tags
+	}
+	withScenario := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		withScenario[k] = v
+	}
+	withScenario["scenario"] = scenario
+	return withScenario
+}
+
+// addPoints adds points that were made elsewhere, like from a rollup.
+func (m *MetricsGatherer) addPoints(points []*client.Point) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	for _, point := range points {
+		m.BatchPoints.AddPoint(point)
+	}
+}
+
 func (m *MetricsGatherer) logMsg(msg interface{}, level string) {
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
 	m.BatchPoints.AddPoint(client.NewPoint("LogTable",
-		nil,
+		m.tags(nil),
 		map[string]interface{}{
 			"serverId": m.DropletId,
 			"threadId": m.WorkerId,
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRequestTables(t *testing.T) {
+	m, err := NewMetricsGatherer("script", 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{"GET", "post", "Delete", "PURGE", ""} {
+		m.IncrHTTPRequest(method, "http://example.com", 200, time.Millisecond)
+	}
+	var got []string
+	for _, p := range m.BatchPoints.Points() {
+		got = append(got, p.Name()+"/"+p.Tags()["method"])
+	}
+	want := "[GetRequestTable/GET PostRequestTable/POST RequestTable/DELETE RequestTable/PURGE GetRequestTable/GET]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("want the requests in %s, got %v", want, got)
+	}
+}
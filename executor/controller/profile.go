@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lgpeterson/loadtests/executor/pb"
+)
+
+// loadProfile is the rate of executions over time described by the stages of
+// a command
+type loadProfile []*executorGRPC.Stage
+
+func stageDuration(stage *executorGRPC.Stage) time.Duration {
+	return time.Duration(stage.Duration * float64(time.Second))
+}
+
+// duration is how long it takes to go through every stage
+func (p loadProfile) duration() time.Duration {
+	var total time.Duration
+	for _, stage := range p {
+		total += stageDuration(stage)
+	}
+	return total
+}
+
+// rateAt is the number of executions per second due `elapsed` into the
+// profile, the target of the last stage is held once the profile is over.
+func (p loadProfile) rateAt(elapsed time.Duration) float64 {
+	from := 0.0
+	for _, stage := range p {
+		to := float64(stage.TargetRequestsPerSecond)
+		length := stageDuration(stage)
+		if elapsed < length {
+			if stage.Interpolation == executorGRPC.Stage_STEP {
+				return to
+			}
+			return from + (to-from)*float64(elapsed)/float64(length)
+		}
+		elapsed -= length
+		from = to
+	}
+	return from
+}
+
+// peak is the highest rate reached by the profile
+func (p loadProfile) peak() int32 {
+	var max int32
+	for _, stage := range p {
+		if stage.TargetRequestsPerSecond > max {
+			max = stage.TargetRequestsPerSecond
+		}
+	}
+	return max
+}
+
+func verifyProfile(in *executorGRPC.ScriptParams) error {
+	profile := loadProfile(in.Stages)
+	for i, stage := range profile {
+		if stage.Duration <= 0 {
+			return fmt.Errorf("Stage %d must last more than 0 seconds. Given Duration: %v", i, stage.Duration)
+		}
+		if stage.TargetRequestsPerSecond < 0 {
+			return fmt.Errorf("Stage %d can't target a negative rate. Given TargetRequestsPerSecond: %d", i, stage.TargetRequestsPerSecond)
+		}
+	}
+	if profile.duration() <= time.Second {
+		return fmt.Errorf("You must run for greater than 1 second. Given stages last: %v", profile.duration())
+	}
+	if in.MaxRequestsPerSecond < profile.peak() {
+		return fmt.Errorf("Max Requests per seconds must be at least the highest target of the stages. Given MaxRequestsPerSecond: %d TargetRequestsPerSecond: %d",
+			in.MaxRequestsPerSecond, profile.peak())
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lgpeterson/loadtests/executor/pb"
+)
+
+func TestRateAt(t *testing.T) {
+	profile := loadProfile{
+		{Duration: 10, TargetRequestsPerSecond: 100},
+		{Duration: 10, TargetRequestsPerSecond: 100},
+		{Duration: 5, TargetRequestsPerSecond: 300, Interpolation: executorGRPC.Stage_STEP},
+		{Duration: 10, TargetRequestsPerSecond: 0},
+	}
+	tests := []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		// Ramping up from 0
+		{0, 0},
+		{2500 * time.Millisecond, 25},
+		{5 * time.Second, 50},
+		// Holding
+		{10 * time.Second, 100},
+		{15 * time.Second, 100},
+		// Stepping right away
+		{20 * time.Second, 300},
+		{24 * time.Second, 300},
+		// Ramping down
+		{25 * time.Second, 300},
+		{30 * time.Second, 150},
+		// The last target is held once the profile is over
+		{35 * time.Second, 0},
+		{time.Hour, 0},
+	}
+	for _, tt := range tests {
+		if got := profile.rateAt(tt.elapsed); got != tt.want {
+			t.Errorf("rateAt(%v): want %v, got %v", tt.elapsed, tt.want, got)
+		}
+	}
+
+	held := loadProfile{{Duration: 10, TargetRequestsPerSecond: 40}}
+	if got := held.rateAt(time.Minute); got != 40 {
+		t.Errorf("want the last target held, got %v", got)
+	}
+	if got := (loadProfile{}).rateAt(time.Second); got != 0 {
+		t.Errorf("want no rate without stages, got %v", got)
+	}
+}
@@ -0,0 +1,342 @@
+package controller
+
+import (
+	"encoding/base64"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	client "github.com/influxdb/influxdb/client/v2"
+	"github.com/lgpeterson/loadtests/executor/engine"
+	"github.com/lgpeterson/loadtests/executor/stats"
+)
+
+// How often the rollups are turned into points
+var rollupInterval = 10 * time.Second
+
+// rollup aggregates the measurements of every worker of an executor, so that
+// only a few points per interval are persisted rather than one per request.
+type rollup struct {
+	lock sync.Mutex
+	// scenario, if set, tags every point
+	scenario   string
+	executions int64
+	iterations stats.Histogram
+	steps      map[string]*stepRollup
+	requests   map[requestKey]*stats.Histogram
+	timings    map[timingKey]*stats.Histogram
+	httpErrors map[errorKey]*errorRollup
+	checks     map[string]*checkCounts
+	custom     map[string]*customRollup
+}
+
+type stepRollup struct {
+	errors  int64
+	latency stats.Histogram
+}
+
+type requestKey struct {
+	method string
+	url    string
+	code   int
+}
+
+type errorKey struct {
+	url      string
+	kind     string
+	expected bool
+}
+
+type errorRollup struct {
+	count int64
+	// the last message, the others are likely much alike
+	message string
+}
+
+// timingKey is a phase of the requests to an URL, as in engine.HTTPTimings
+type timingKey struct {
+	method string
+	url    string
+	phase  string
+}
+
+// customRollup aggregates the updates of a custom metric with the same tags,
+// depending on its kind.
+type customRollup struct {
+	name string
+	kind string
+	tags map[string]string
+	// how many updates, and the sum of their values
+	count int64
+	sum   float64
+	// for gauges
+	last, min, max float64
+	// for trends, values are counted like milliseconds so that those from
+	// 0.001 to 36000000 keep the precision of the histogram
+	trend stats.Histogram
+}
+
+// customKey identifies a custom metric and its tags
+func customKey(name string, tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "," + strings.Join(pairs, ",")
+}
+
+func newRollup() *rollup {
+	return &rollup{
+		steps:      make(map[string]*stepRollup),
+		requests:   make(map[requestKey]*stats.Histogram),
+		timings:    make(map[timingKey]*stats.Histogram),
+		httpErrors: make(map[errorKey]*errorRollup),
+		checks:     make(map[string]*checkCounts),
+		custom:     make(map[string]*customRollup),
+	}
+}
+
+func (r *rollup) step(name string) *stepRollup {
+	step, ok := r.steps[name]
+	if !ok {
+		step = &stepRollup{}
+		r.steps[name] = step
+	}
+	return step
+}
+
+func (r *rollup) addExecution() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.executions++
+}
+
+func (r *rollup) addIteration(latency time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.iterations.Record(latency)
+}
+
+func (r *rollup) addStep(name string, dur time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.step(name).latency.Record(dur)
+}
+
+func (r *rollup) addStepError(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.step(name).errors++
+}
+
+func (r *rollup) addRequest(method, url string, code int, dur time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := requestKey{method: method, url: url, code: code}
+	h, ok := r.requests[key]
+	if !ok {
+		h = &stats.Histogram{}
+		r.requests[key] = h
+	}
+	h.Record(dur)
+}
+
+func (r *rollup) addTimings(method, url string, timings engine.HTTPTimings) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, phase := range []struct {
+		name string
+		dur  time.Duration
+	}{
+		{"dns", timings.DNS},
+		{"connect", timings.Connect},
+		{"tls", timings.TLS},
+		{"ttfb", timings.TTFB},
+		{"transfer", timings.Transfer},
+	} {
+		key := timingKey{method: method, url: url, phase: phase.name}
+		h, ok := r.timings[key]
+		if !ok {
+			h = &stats.Histogram{}
+			r.timings[key] = h
+		}
+		h.Record(phase.dur)
+	}
+}
+
+func (r *rollup) addHTTPError(url string, err *engine.HTTPError) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := errorKey{url: url, kind: err.Kind, expected: err.Expected}
+	e, ok := r.httpErrors[key]
+	if !ok {
+		e = &errorRollup{}
+		r.httpErrors[key] = e
+	}
+	e.count++
+	e.message = err.Message
+}
+
+func (r *rollup) addCheck(name string, passed bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	check, ok := r.checks[name]
+	if !ok {
+		check = &checkCounts{}
+		r.checks[name] = check
+	}
+	check.add(passed)
+}
+
+func (r *rollup) addCustom(name, kind string, value float64, tags map[string]string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	key := customKey(name, tags)
+	c, ok := r.custom[key]
+	if !ok {
+		c = &customRollup{name: name, kind: kind, tags: tags, min: value, max: value}
+		r.custom[key] = c
+	}
+	c.count++
+	c.sum += value
+	c.last = value
+	if value < c.min {
+		c.min = value
+	}
+	if value > c.max {
+		c.max = value
+	}
+	if kind == engine.MetricTrend {
+		c.trend.Record(time.Duration(value * float64(time.Millisecond)))
+	}
+}
+
+// points turns what was aggregated during the `interval` up to `now` into
+// points, and starts over.
+func (r *rollup) points(scriptId string, dropletId int, now time.Time, interval time.Duration) []*client.Point {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var points []*client.Point
+	newPoint := func(table string, tags map[string]string, fields map[string]interface{}) {
+		fields["serverId"] = dropletId
+		fields["id"] = scriptId
+		fields["interval_ns"] = interval.Nanoseconds()
+		points = append(points, client.NewPoint(table, withScenario(tags, r.scenario), fields, now))
+	}
+
+	if r.executions > 0 || r.iterations.Count() > 0 {
+		fields := map[string]interface{}{"count": r.executions}
+		addLatency(fields, &r.iterations)
+		newPoint("ExecutionRollupTable", nil, fields)
+	}
+	for name, step := range r.steps {
+		fields := map[string]interface{}{
+			"step":   name,
+			"errors": step.errors,
+		}
+		addLatency(fields, &step.latency)
+		newPoint("StepRollupTable", nil, fields)
+	}
+	for key, h := range r.requests {
+		fields := map[string]interface{}{
+			"url":  key.url,
+			"code": key.code,
+		}
+		addLatency(fields, h)
+		newPoint("RequestRollupTable", map[string]string{"method": key.method}, fields)
+	}
+	for key, h := range r.timings {
+		fields := map[string]interface{}{
+			"method": key.method,
+			"url":    key.url,
+			"phase":  key.phase,
+		}
+		addLatency(fields, h)
+		newPoint("TimingRollupTable", nil, fields)
+	}
+	for key, e := range r.httpErrors {
+		newPoint("ErrorRollupTable", map[string]string{"kind": key.kind}, map[string]interface{}{
+			"url":      key.url,
+			"expected": key.expected,
+			"count":    e.count,
+			"message":  e.message,
+		})
+	}
+	for name, check := range r.checks {
+		newPoint("CheckRollupTable", nil, map[string]interface{}{
+			"check":  name,
+			"passes": check.passes,
+			"fails":  check.fails,
+		})
+	}
+	for _, c := range r.custom {
+		fields := map[string]interface{}{
+			"kind":  c.kind,
+			"count": c.count,
+		}
+		switch c.kind {
+		case engine.MetricCounter:
+			fields["value"] = c.sum
+		case engine.MetricGauge:
+			fields["value"], fields["min"], fields["max"] = c.last, c.min, c.max
+		case engine.MetricRate:
+			fields["value"] = c.sum / float64(c.count)
+		case engine.MetricTrend:
+			addTrend(fields, c)
+		}
+		newPoint(c.name, c.tags, fields)
+	}
+
+	r.executions = 0
+	r.iterations = stats.Histogram{}
+	r.steps = make(map[string]*stepRollup)
+	r.requests = make(map[requestKey]*stats.Histogram)
+	r.timings = make(map[timingKey]*stats.Histogram)
+	r.httpErrors = make(map[errorKey]*errorRollup)
+	r.checks = make(map[string]*checkCounts)
+	r.custom = make(map[string]*customRollup)
+	return points
+}
+
+// addLatency describes a histogram with fields. The histogram itself is kept
+// so rollups can be merged over longer intervals, or across executors.
+func addLatency(fields map[string]interface{}, h *stats.Histogram) {
+	fields["count"] = h.Count()
+	fields["mean_ns"] = h.Mean().Nanoseconds()
+	fields["p50_ns"] = h.Quantile(0.50).Nanoseconds()
+	fields["p90_ns"] = h.Quantile(0.90).Nanoseconds()
+	fields["p95_ns"] = h.Quantile(0.95).Nanoseconds()
+	fields["p99_ns"] = h.Quantile(0.99).Nanoseconds()
+	fields["max_ns"] = h.Max().Nanoseconds()
+
+	encoded, err := proto.Marshal(h.Proto())
+	if err != nil {
+		log.Printf("Error encoding histogram: %v", err)
+		return
+	}
+	fields["histogram"] = base64.StdEncoding.EncodeToString(encoded)
+}
+
+// addTrend describes the values of a trend with fields, like addLatency.
+func addTrend(fields map[string]interface{}, c *customRollup) {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	fields["mean"] = c.sum / float64(c.count)
+	fields["min"] = c.min
+	fields["p50"] = ms(c.trend.Quantile(0.50))
+	fields["p90"] = ms(c.trend.Quantile(0.90))
+	fields["p95"] = ms(c.trend.Quantile(0.95))
+	fields["p99"] = ms(c.trend.Quantile(0.99))
+	fields["max"] = c.max
+
+	encoded, err := proto.Marshal(c.trend.Proto())
+	if err != nil {
+		log.Printf("Error encoding histogram: %v", err)
+		return
+	}
+	fields["histogram"] = base64.StdEncoding.EncodeToString(encoded)
+}
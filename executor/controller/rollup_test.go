@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	client "github.com/influxdb/influxdb/client/v2"
+	"github.com/lgpeterson/loadtests/executor/engine"
+)
+
+func TestRollupPoints(t *testing.T) {
+	r := newRollup()
+	r.scenario = "browse"
+	for i := 0; i < 3; i++ {
+		r.addExecution()
+		r.addIteration(time.Duration(i+1) * 100 * time.Millisecond)
+		r.addStep("login", 10*time.Millisecond)
+		r.addRequest("GET", "http://example.com", 200, 20*time.Millisecond)
+	}
+	r.addStepError("login")
+	r.addRequest("POST", "http://example.com", 500, 40*time.Millisecond)
+	r.addHTTPError("http://example.com", &engine.HTTPError{Kind: "timeout", Message: "first"})
+	r.addHTTPError("http://example.com", &engine.HTTPError{Kind: "timeout", Message: "last"})
+	r.addCheck("status is 200", true)
+	r.addCheck("status is 200", false)
+	r.addCheck("status is 200", true)
+	r.addCustom("carts", engine.MetricCounter, 2, nil)
+	r.addCustom("carts", engine.MetricCounter, 3, nil)
+	r.addCustom("queue", engine.MetricGauge, 5, map[string]string{"shop": "a"})
+	r.addCustom("queue", engine.MetricGauge, 1, map[string]string{"shop": "a"})
+	r.addCustom("queue", engine.MetricGauge, 7, map[string]string{"shop": "b"})
+	r.addCustom("hits", engine.MetricRate, 1, nil)
+	r.addCustom("hits", engine.MetricRate, 0, nil)
+	r.addCustom("price", engine.MetricTrend, 10, nil)
+	r.addCustom("price", engine.MetricTrend, 30, nil)
+
+	now := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	points := r.points("script", 7, now, 10*time.Second)
+
+	byTable := make(map[string][]*client.Point)
+	for _, p := range points {
+		if !p.Time().Equal(now) {
+			t.Errorf("%s: want the point at %v, got %v", p.Name(), now, p.Time())
+		}
+		if p.Tags()["scenario"] != "browse" {
+			t.Errorf("%s: want the scenario tag, got %v", p.Name(), p.Tags())
+		}
+		fields := p.Fields()
+		if fields["id"] != "script" || fields["serverId"] != int64(7) || fields["interval_ns"] != int64(10*time.Second) {
+			t.Errorf("%s: unexpected fields %v", p.Name(), fields)
+		}
+		byTable[p.Name()] = append(byTable[p.Name()], p)
+	}
+	one := func(table string) map[string]interface{} {
+		if len(byTable[table]) != 1 {
+			t.Fatalf("want a point in %s, got %d", table, len(byTable[table]))
+		}
+		return byTable[table][0].Fields()
+	}
+
+	if f := one("ExecutionRollupTable"); f["count"] != int64(3) || f["max_ns"] != int64(300*time.Millisecond) || f["mean_ns"] != int64(200*time.Millisecond) {
+		t.Errorf("unexpected executions: %v", f)
+	}
+	if f := one("StepRollupTable"); f["step"] != "login" || f["count"] != int64(3) || f["errors"] != int64(1) {
+		t.Errorf("unexpected steps: %v", f)
+	}
+	if len(byTable["RequestRollupTable"]) != 2 {
+		t.Fatalf("want a point per method, URL and code, got %d", len(byTable["RequestRollupTable"]))
+	}
+	for _, p := range byTable["RequestRollupTable"] {
+		want := map[string]int64{"GET": 3, "POST": 1}[p.Tags()["method"]]
+		if p.Fields()["count"] != want {
+			t.Errorf("%s: want %d requests, got %v", p.Tags()["method"], want, p.Fields()["count"])
+		}
+	}
+	if f := one("ErrorRollupTable"); f["count"] != int64(2) || f["message"] != "last" {
+		t.Errorf("unexpected errors: %v", f)
+	}
+	if f := one("CheckRollupTable"); f["passes"] != int64(2) || f["fails"] != int64(1) {
+		t.Errorf("unexpected checks: %v", f)
+	}
+	if f := one("carts"); f["value"] != 5.0 || f["count"] != int64(2) {
+		t.Errorf("want the counter summed, got %v", f)
+	}
+	if len(byTable["queue"]) != 2 {
+		t.Fatalf("want a gauge per tags, got %d", len(byTable["queue"]))
+	}
+	for _, p := range byTable["queue"] {
+		if p.Tags()["shop"] == "a" && (p.Fields()["value"] != 1.0 || p.Fields()["min"] != 1.0 || p.Fields()["max"] != 5.0) {
+			t.Errorf("want the last value of the gauge, got %v", p.Fields())
+		}
+	}
+	if f := one("hits"); f["value"] != 0.5 {
+		t.Errorf("want the rate of true values, got %v", f)
+	}
+	if f := one("price"); f["mean"] != 20.0 || f["min"] != 10.0 || f["max"] != 30.0 {
+		t.Errorf("unexpected trend: %v", f)
+	}
+
+	// It starts over once turned into points
+	if points := r.points("script", 7, now.Add(10*time.Second), 10*time.Second); len(points) != 0 {
+		t.Errorf("want no points for an empty interval, got %d", len(points))
+	}
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	executor "github.com/lgpeterson/loadtests/executor/pb"
+)
+
+// protocolVersion is the newest version of the protocol the executor speaks
+const protocolVersion = executor.ProtocolVersion_V2
+
+// session is the conversation with the scheduler about an execution, in the
+// version of the protocol of the command that started it.
+type session struct {
+	server  executor.Commander_ExecuteCommandServer
+	version executor.ProtocolVersion
+	clock   clock.Clock
+
+	// the controllers of the scenarios share the session
+	lock    sync.Mutex
+	start   time.Time
+	summary executor.Summary
+}
+
+func newSession(server executor.Commander_ExecuteCommandServer, clock clock.Clock) *session {
+	return &session{server: server, version: executor.ProtocolVersion_V1, clock: clock}
+}
+
+// readRun reads the Run command that starts the execution, and from it the
+// version of the protocol.
+func (s *session) readRun(in *executor.CommandMessage) (*executor.ScriptParams, string, error) {
+	switch cmd := in.Kind.(type) {
+	case *executor.CommandMessage_Run:
+		s.version = executor.ProtocolVersion_V2
+		return cmd.Run.ScriptParams, cmd.Run.ScriptConfig, nil
+	case nil:
+		if in.Command != "Run" {
+			return nil, "", fmt.Errorf("the first command must be Run, not %q", in.Command)
+		}
+		return in.ScriptParams, in.ScriptConfig, nil
+	default:
+		s.version = executor.ProtocolVersion_V2
+		return nil, "", fmt.Errorf("the first command must be Run")
+	}
+}
+
+// isHalt is whether the command halts the execution, in either version.
+func isHalt(in *executor.CommandMessage) bool {
+	if in.Kind == nil {
+		return in.Command == "Halt"
+	}
+	return in.GetHalt() != nil
+}
+
+func (s *session) send(msg *executor.StatusMessage) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.server.Send(msg)
+}
+
+// accepted tells the scheduler the command will be carried out, V1 has no
+// such answer.
+func (s *session) accepted() error {
+	if s.version < executor.ProtocolVersion_V2 {
+		return nil
+	}
+	return s.send(&executor.StatusMessage{Event: &executor.StatusMessage_Accepted{
+		Accepted: &executor.Accepted{ProtocolVersion: s.version},
+	}})
+}
+
+// rejected tells the scheduler a command can't be carried out, in V1 it's
+// an "Invalid" status even though the execution goes on.
+func (s *session) rejected(code executor.ErrorCode, err error) error {
+	if s.version < executor.ProtocolVersion_V2 {
+		return s.send(&executor.StatusMessage{Status: "Invalid"})
+	}
+	return s.send(&executor.StatusMessage{Event: &executor.StatusMessage_Rejected{
+		Rejected: &executor.Rejected{Code: code, Message: err.Error()},
+	}})
+}
+
+// started tells the scheduler the load is being generated, only the first
+// call of the controllers of the scenarios does.
+func (s *session) started() error {
+	s.lock.Lock()
+	if !s.start.IsZero() {
+		s.lock.Unlock()
+		return nil
+	}
+	s.start = s.clock.Now()
+	s.lock.Unlock()
+	if s.version < executor.ProtocolVersion_V2 {
+		return nil
+	}
+	return s.send(&executor.StatusMessage{Event: &executor.StatusMessage_Started{
+		Started: &executor.Started{},
+	}})
+}
+
+// progress sends a snapshot, which is added to the summary.
+func (s *session) progress(snap *executor.Snapshot) error {
+	s.lock.Lock()
+	s.summary.Executions += snap.Executions
+	s.summary.Requests += snap.Requests
+	s.summary.Errors += snap.Errors
+	s.summary.FailedRequests += snap.FailedRequests
+	s.lock.Unlock()
+	if s.version < executor.ProtocolVersion_V2 {
+		return s.send(&executor.StatusMessage{Snapshot: snap})
+	}
+	return s.send(&executor.StatusMessage{Event: &executor.StatusMessage_Progress{
+		Progress: snap,
+	}})
+}
+
+// finished ends an execution that went to its end, or was halted.
+func (s *session) finished(halted bool) error {
+	if s.version < executor.ProtocolVersion_V2 {
+		status := "OK"
+		if halted {
+			status = "Halted"
+		}
+		return s.send(&executor.StatusMessage{Status: status})
+	}
+	s.lock.Lock()
+	summary := s.summary
+	if !s.start.IsZero() {
+		summary.Duration = s.clock.Now().Sub(s.start).Seconds()
+	}
+	s.lock.Unlock()
+	return s.send(&executor.StatusMessage{Event: &executor.StatusMessage_Finished{
+		Finished: &executor.Finished{Halted: halted, Summary: &summary},
+	}})
+}
+
+// failed ends an execution that couldn't run to its end.
+func (s *session) failed(err error) error {
+	if s.version < executor.ProtocolVersion_V2 {
+		return s.send(&executor.StatusMessage{Status: "Invalid: " + err.Error()})
+	}
+	return s.send(&executor.StatusMessage{Event: &executor.StatusMessage_Failed{
+		Failed: &executor.Failed{Code: errorCode(err), Message: err.Error()},
+	}})
+}
+
+// codedError is an error the scheduler is told the code of.
+type codedError struct {
+	code executor.ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func withCode(code executor.ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+func errorCode(err error) executor.ErrorCode {
+	if coded, ok := err.(*codedError); ok {
+		return coded.code
+	}
+	return executor.ErrorCode_INTERNAL
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/executor/stats"
+)
+
+// How often the scheduler is told how the execution is going
+var snapshotInterval = time.Second
+
+// liveStats aggregates what the workers did since the last snapshot. A nil
+// liveStats ignores everything.
+type liveStats struct {
+	lock           sync.Mutex
+	executions     int64
+	requests       int64
+	failedRequests int64
+	errors         int64
+	steps          map[string]*stepStats
+	checks         map[string]*checkCounts
+}
+
+// checkCounts are the outcomes of a check of the script
+type checkCounts struct {
+	passes int64
+	fails  int64
+}
+
+func (c *checkCounts) add(passed bool) {
+	if passed {
+		c.passes++
+	} else {
+		c.fails++
+	}
+}
+
+type stepStats struct {
+	errors  int64
+	latency stats.Histogram
+}
+
+func newLiveStats() *liveStats {
+	return &liveStats{
+		steps:  make(map[string]*stepStats),
+		checks: make(map[string]*checkCounts),
+	}
+}
+
+func (l *liveStats) step(name string) *stepStats {
+	step, ok := l.steps[name]
+	if !ok {
+		step = &stepStats{}
+		l.steps[name] = step
+	}
+	return step
+}
+
+func (l *liveStats) addExecution() {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.executions++
+}
+
+func (l *liveStats) addStep(name string, dur time.Duration) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.step(name).latency.Record(dur)
+}
+
+func (l *liveStats) addStepError(name string) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.step(name).errors++
+}
+
+// addRequest counts a request, that failed if it got no response or an error
+// status.
+func (l *liveStats) addRequest(failed bool) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.requests++
+	if failed {
+		l.failedRequests++
+		l.errors++
+	}
+}
+
+// addError counts an execution of the script that failed.
+func (l *liveStats) addError() {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.errors++
+}
+
+func (l *liveStats) addCheck(name string, passed bool) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	check, ok := l.checks[name]
+	if !ok {
+		check = &checkCounts{}
+		l.checks[name] = check
+	}
+	check.add(passed)
+}
+
+// snapshot returns what was aggregated so far, covering `interval`, and
+// starts over.
+func (l *liveStats) snapshot(interval time.Duration) *executorGRPC.Snapshot {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	snap := &executorGRPC.Snapshot{
+		Interval:       interval.Seconds(),
+		Executions:     l.executions,
+		Requests:       l.requests,
+		FailedRequests: l.failedRequests,
+		Errors:         l.errors,
+	}
+	names := make([]string, 0, len(l.steps))
+	for name := range l.steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		step := l.steps[name]
+		snap.Steps = append(snap.Steps, &executorGRPC.StepSnapshot{
+			Name:    name,
+			Errors:  step.errors,
+			Latency: step.latency.Proto(),
+		})
+	}
+	names = names[:0]
+	for name := range l.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check := l.checks[name]
+		snap.Checks = append(snap.Checks, &executorGRPC.CheckSnapshot{
+			Name:   name,
+			Passes: check.passes,
+			Fails:  check.fails,
+		})
+	}
+
+	l.executions, l.requests, l.failedRequests, l.errors = 0, 0, 0, 0
+	l.steps = make(map[string]*stepStats)
+	l.checks = make(map[string]*checkCounts)
+	return snap
+}
@@ -1,36 +1,81 @@
 package controller
 
 import (
-	"encoding/json"
 	"log"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/lgpeterson/loadtests/executor/engine"
 	"github.com/lgpeterson/loadtests/executor/pb"
 	"golang.org/x/net/context"
 )
 
+// job is one execution of the script
+type job struct {
+	// scheduled is when the execution was meant to start, latency is
+	// measured from there rather than from when a worker got to it. So are
+	// the requests of the open model
+	scheduled time.Time
+	// deadline is the time past which the job is dropped instead of being
+	// started late, zero if it must always run
+	deadline time.Time
+}
+
 type worker struct {
 	WorkerId   int32
-	Config     string
+	Config     map[string]interface{}
+	DataFeed   engine.DataFeed
 	Command    *executorGRPC.ScriptParams
 	Metrics    *MetricsGatherer
+	Clock      clock.Clock
 	Wait       *sync.WaitGroup
-	JobChannel <-chan struct{}
+	JobChannel <-chan job
 	Done       <-chan struct{}
+	// Limit tells if the worker takes jobs
+	Limit *workerLimit
 }
 
 func (w *worker) execute() {
 	w.Wait.Add(1)
 	defer log.Printf("Worker: %d closed", w.WorkerId)
 	defer w.Wait.Done()
+
+	// The script is compiled once and every job reuses it
+	prog, err := w.newProgram()
+	if err != nil {
+		// This should not be because the script did not compile, if it
+		// did not compile it would be reported to the user before this
+		log.Printf("Worker %d, Error creating lua script: %v", w.WorkerId, err)
+		return
+	}
+	if err := prog.Init(); err != nil {
+		log.Printf("Worker %d, Error initializing: %v", w.WorkerId, err)
+		w.Metrics.AddLuaError(err)
+		return
+	}
+	defer func() {
+		if err := prog.Finish(); err != nil {
+			log.Printf("Worker %d, Error finishing: %v", w.WorkerId, err)
+			w.Metrics.AddLuaError(err)
+		}
+	}()
+
 	testNum := 0
 	for {
+		if ok, changed := w.Limit.allows(w.WorkerId); !ok {
+			select {
+			case <-w.Done:
+				return
+			case <-changed:
+				continue
+			}
+		}
 		select {
 		case <-w.Done:
 			return
-		case _, ok := <-w.JobChannel:
+		case j, ok := <-w.JobChannel:
 			// Make sure that the channels are not closed
 			if !ok {
 				return
@@ -40,31 +85,30 @@ func (w *worker) execute() {
 				return
 			default:
 			}
-			w.Metrics.TestId = testNum
-			testNum++
-
-			scriptReader := strings.NewReader(w.Command.Script)
-			prog, err := engine.Lua(scriptReader, engine.SetMetricReporter(w.Metrics))
-			if err != nil {
-				// This should not be because the script did not compile, if it
-				// did not compile it would be reported to the user before this
-				log.Printf("Worker %d, Error creating lua script: %v", w.WorkerId, err)
-				return
+			now := w.Clock.Now()
+			if !j.deadline.IsZero() && now.After(j.deadline) {
+				// Every worker was busy while this job should have started
+				w.Metrics.AddDroppedIterations(1)
+				continue
 			}
-			// Add a config if it exists
-			if w.Config != "" {
-				cfg := make(map[string]interface{})
-				if err = json.Unmarshal([]byte(w.Config), &cfg); err != nil {
-					// This should not occur, it would have been checked in controller
-					log.Printf("Worker %d, Error unmarshalling: %v", w.WorkerId, err)
-					return
-				}
-				if err = prog.AddConfig(cfg); err != nil {
-					log.Printf("Worker %d, Error addding Config to lua script: %v", w.WorkerId, err)
+			// Jobs of the open model are handed out ahead of time
+			if wait := j.scheduled.Sub(now); wait > 0 {
+				select {
+				case <-w.Done:
 					return
+				case <-w.Clock.After(wait):
 				}
 			}
+			w.Metrics.TestId = testNum
+			testNum++
+
+			start := w.Clock.Now()
+			if w.Command.OpenModel {
+				w.Metrics.StartDelay = start.Sub(j.scheduled)
+			}
 			err = prog.Execute(context.Background())
+			end := w.Clock.Now()
+			w.Metrics.AddIterationLatency(start.Sub(j.scheduled), end.Sub(j.scheduled))
 
 			if err != nil {
 				// I assume I can keep going if the lua script encoutered an error
@@ -73,3 +117,29 @@ func (w *worker) execute() {
 		}
 	}
 }
+
+func (w *worker) newProgram() (*engine.LuaProgram, error) {
+	scriptReader := strings.NewReader(w.Command.Script)
+	prog, err := engine.Lua(scriptReader,
+		engine.SetMetricReporter(w.Metrics),
+		engine.KeepCookies(w.Command.KeepCookies),
+		engine.SetDataFeed(w.DataFeed),
+		engine.AllowHosts(w.Command.AllowedHosts...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Add a config if it exists
+	if w.Config != nil {
+		if err = prog.AddConfig(w.Config); err != nil {
+			return nil, err
+		}
+	}
+	// What the setup of the script returned, it was run by the scheduler
+	if w.Command.SetupData != "" {
+		if err = prog.SetSetupData([]byte(w.Command.SetupData)); err != nil {
+			return nil, err
+		}
+	}
+	return prog, nil
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Shopify/go-lua"
+)
+
+// check runs named conditions against a value, and counts which passed:
+//
+//	check(resp, {["status is 200"] = function(r) return r.code == 200 end})
+//
+// A condition passes if it returns a true value, and fails if it returns
+// false or nil, or raises an error. A failed check doesn't stop the step,
+// check returns whether all of them passed so the script can decide.
+func (prgm *LuaProgram) check(l *lua.State) int {
+	lua.CheckAny(l, 1)
+	lua.CheckType(l, 2, lua.TypeTable)
+
+	// tables have no order, the conditions run in the order of their names
+	var names []string
+	l.PushNil()
+	for l.Next(2) {
+		// converting a number key in place would confuse Next
+		if l.TypeOf(-2) != lua.TypeString {
+			lua.Errorf(l, "check: names must be strings, got a %s", lua.TypeNameOf(l, -2))
+			return 0
+		}
+		name, _ := l.ToString(-2)
+		if !l.IsFunction(-1) {
+			lua.Errorf(l, "%s", fmt.Sprintf("check %q: must be a function, got a %s", name, lua.TypeNameOf(l, -1)))
+			return 0
+		}
+		names = append(names, name)
+		l.Pop(1)
+	}
+	sort.Strings(names)
+
+	all := true
+	for _, name := range names {
+		l.Field(2, name)
+		l.PushValue(1)
+		passed := l.ProtectedCall(1, 1, 0) == nil && l.ToBoolean(-1)
+		l.Pop(1)
+		prgm.metrics.IncrCheck(name, passed)
+		all = all && passed
+	}
+	l.PushBoolean(all)
+	return 1
+}
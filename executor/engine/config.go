@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Shopify/go-lua"
+)
+
+// ParseConfig reads the JSON config of a script, which must be an object.
+// Every member becomes a global of the script.
+func ParseConfig(config string) (map[string]interface{}, error) {
+	var cfg map[string]interface{}
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		return nil, fmt.Errorf("script config must be a JSON object: %v", err)
+	}
+	if err := VerifyConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// VerifyConfig checks that every value of the config can be given to a
+// script, the error tells where the first one that can't is.
+func VerifyConfig(json map[string]interface{}) error {
+	for key, val := range json {
+		if err := verifyValue(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyValue(path string, val interface{}) error {
+	switch val := val.(type) {
+	case string, int, int64, float64, bool, nil:
+	case []interface{}:
+		for i, elem := range val {
+			if err := verifyValue(path+"["+strconv.Itoa(i)+"]", elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for key, elem := range val {
+			if err := verifyValue(path+"."+key, elem); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid type for lua config file: %T, at: %s", val, path)
+	}
+	return nil
+}
+
+// pushValue pushes a config value: arrays become sequences, and objects
+// tables keyed by their members.
+func pushValue(l *lua.State, path string, val interface{}) error {
+	if !l.CheckStack(3) {
+		return fmt.Errorf("lua config file is too deep, at: %s", path)
+	}
+	switch val := val.(type) {
+	case string:
+		l.PushString(val)
+	case int:
+		l.PushInteger(val)
+	case int64:
+		l.PushNumber(float64(val))
+	case float64:
+		l.PushNumber(val)
+	case bool:
+		l.PushBoolean(val)
+	case nil:
+		l.PushNil()
+	case []interface{}:
+		l.CreateTable(len(val), 0)
+		for i, elem := range val {
+			if err := pushValue(l, path+"["+strconv.Itoa(i)+"]", elem); err != nil {
+				return err
+			}
+			l.RawSetInt(-2, i+1)
+		}
+	case map[string]interface{}:
+		l.CreateTable(0, len(val))
+		for key, elem := range val {
+			if err := pushValue(l, path+"."+key, elem); err != nil {
+				return err
+			}
+			l.SetField(-2, key)
+		}
+	default:
+		return fmt.Errorf("invalid type for lua config file: %T, at: %s", val, path)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	"github.com/Shopify/go-lua"
+)
+
+// cookieFunctions are the members of the `cookies` table
+func (h *httpBind) cookieFunctions() []lua.RegistryFunction {
+	return []lua.RegistryFunction{
+		{Name: "get", Function: h.getCookies},
+		{Name: "set", Function: h.setCookie},
+		{Name: "clear", Function: h.clearCookies},
+	}
+}
+
+// resetJar forgets every cookie received so far.
+func (h *httpBind) resetJar() {
+	// a jar can't be emptied, replace it instead
+	jar, _ := cookiejar.New(nil)
+	h.client.Jar = jar
+}
+
+// getCookies returns a table of the cookies that would be sent to a URL,
+// indexed by name.
+func (h *httpBind) getCookies(l *lua.State) int {
+	u := checkURL(l, 1)
+
+	l.NewTable()
+	for _, cookie := range h.client.Jar.Cookies(u) {
+		l.PushString(cookie.Value)
+		l.SetField(-2, cookie.Name)
+	}
+	return 1
+}
+
+// setCookie stores a cookie as if it had been received from a URL.
+func (h *httpBind) setCookie(l *lua.State) int {
+	u := checkURL(l, 1)
+	name := lua.CheckString(l, 2)
+	value := lua.CheckString(l, 3)
+
+	h.client.Jar.SetCookies(u, []*http.Cookie{{Name: name, Value: value, Path: "/"}})
+	return 0
+}
+
+func (h *httpBind) clearCookies(l *lua.State) int {
+	h.resetJar()
+	return 0
+}
+
+func checkURL(l *lua.State, index int) *url.URL {
+	u, err := url.Parse(lua.CheckString(l, index))
+	if err != nil {
+		lua.ArgumentError(l, index, err.Error())
+	}
+	return u
+}
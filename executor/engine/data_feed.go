@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/Shopify/go-lua"
+)
+
+// DataFeed hands records out to a script, every call to `data.next()`, or
+// `feed.next()`, gets one. Feeds can be shared by the programs of many workers.
+type DataFeed interface {
+	// Next returns the next record, false once there are none left.
+	Next() (map[string]interface{}, bool)
+}
+
+// SetDataFeed gives the program the feed `data.next()` reads from.
+func SetDataFeed(feed DataFeed) LuaOption {
+	return func(prgm *LuaProgram) {
+		prgm.feed = feed
+	}
+}
+
+type sequentialFeed struct {
+	lock    sync.Mutex
+	records []map[string]interface{}
+	next    int
+	wrap    bool
+}
+
+// NewSequentialFeed hands the records out in order, starting over once they
+// were all handed out.
+func NewSequentialFeed(records []map[string]interface{}) DataFeed {
+	return &sequentialFeed{records: records, wrap: true}
+}
+
+// NewUniqueFeed hands the records out in order, each of them only once.
+func NewUniqueFeed(records []map[string]interface{}) DataFeed {
+	return &sequentialFeed{records: records}
+}
+
+func (f *sequentialFeed) Next() (map[string]interface{}, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.next >= len(f.records) {
+		if !f.wrap || len(f.records) == 0 {
+			return nil, false
+		}
+		f.next = 0
+	}
+	record := f.records[f.next]
+	f.next++
+	return record, true
+}
+
+type randomFeed struct {
+	records []map[string]interface{}
+}
+
+// NewRandomFeed hands out records picked at random.
+func NewRandomFeed(records []map[string]interface{}) DataFeed {
+	return &randomFeed{records: records}
+}
+
+func (f *randomFeed) Next() (map[string]interface{}, bool) {
+	if len(f.records) == 0 {
+		return nil, false
+	}
+	return f.records[rand.Intn(len(f.records))], true
+}
+
+// dataFunctions are the members of the `data` table
+func (prgm *LuaProgram) dataFunctions() []lua.RegistryFunction {
+	return []lua.RegistryFunction{
+		{Name: "next", Function: prgm.dataNext},
+	}
+}
+
+// dataNext returns the next record of the feed as a table, or nil when there
+// are none left.
+func (prgm *LuaProgram) dataNext(l *lua.State) int {
+	if prgm.feed == nil {
+		lua.Errorf(l, "lua-data: no data feed was given to the load test")
+		return 0
+	}
+	record, ok := prgm.feed.Next()
+	if !ok {
+		l.PushNil()
+		return 1
+	}
+	if err := pushValue(l, "record", record); err != nil {
+		lua.Errorf(l, "lua-data: %s", err.Error())
+		return 0
+	}
+	return 1
+}
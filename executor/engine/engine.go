@@ -24,9 +24,14 @@ type MetricReporter interface {
 	IncrStepExecution(string, time.Duration)
 	IncrStepError(string)
 
-	IncrHTTPGet(string, int, time.Duration)
-	IncrHTTPPost(string, int, time.Duration)
-	IncrHTTPError(string)
+	IncrHTTPRequest(method, url string, code int, dur time.Duration)
+	IncrHTTPTimings(method, url string, timings HTTPTimings)
+	IncrHTTPError(url string, err *HTTPError)
+
+	IncrCheck(name string, passed bool)
+	// IncrCustomMetric updates a metric declared by the script, `kind` is
+	// one of the Metric constants and a rate's value is 1 or 0.
+	IncrCustomMetric(name, kind string, value float64, tags map[string]string)
 
 	IncrLogInfo(interface{})
 	IncrLogFatal(interface{})
@@ -34,11 +39,13 @@ type MetricReporter interface {
 
 type nullMetric struct{}
 
-func (_ nullMetric) IncrScriptExecution()                    {}
-func (_ nullMetric) IncrStepExecution(string, time.Duration) {}
-func (_ nullMetric) IncrStepError(string)                    {}
-func (_ nullMetric) IncrHTTPGet(string, int, time.Duration)  {}
-func (_ nullMetric) IncrHTTPPost(string, int, time.Duration) {}
-func (_ nullMetric) IncrHTTPError(string)                    {}
-func (_ nullMetric) IncrLogInfo(interface{})                 {}
-func (_ nullMetric) IncrLogFatal(interface{})                {}
+func (_ nullMetric) IncrScriptExecution()                                        {}
+func (_ nullMetric) IncrStepExecution(string, time.Duration)                     {}
+func (_ nullMetric) IncrStepError(string)                                        {}
+func (_ nullMetric) IncrHTTPRequest(string, string, int, time.Duration)          {}
+func (_ nullMetric) IncrHTTPTimings(string, string, HTTPTimings)                 {}
+func (_ nullMetric) IncrHTTPError(string, *HTTPError)                            {}
+func (_ nullMetric) IncrCheck(string, bool)                                      {}
+func (_ nullMetric) IncrCustomMetric(string, string, float64, map[string]string) {}
+func (_ nullMetric) IncrLogInfo(interface{})                                     {}
+func (_ nullMetric) IncrLogFatal(interface{})                                    {}
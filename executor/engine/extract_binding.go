@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"regexp"
+
+	"github.com/Shopify/go-lua"
+	"github.com/lgpeterson/loadtests/executor/extract"
+)
+
+// How many compiled expressions a program keeps, scripts that build their
+// expressions on the fly would otherwise keep them all
+const maxCompiled = 256
+
+// extractBind compiles the expressions of a program. Scripts use the same few
+// expressions over and over, they are only compiled the first time.
+type extractBind struct {
+	compiled map[string]interface{}
+}
+
+func newExtractBinding() *extractBind {
+	return &extractBind{compiled: make(map[string]interface{})}
+}
+
+func (e *extractBind) compile(kind, expr string, fn func(string) (interface{}, error)) (interface{}, error) {
+	key := kind + "\x00" + expr
+	if c, ok := e.compiled[key]; ok {
+		return c, nil
+	}
+	c, err := fn(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.compiled) >= maxCompiled {
+		e.compiled = make(map[string]interface{})
+	}
+	e.compiled[key] = c
+	return c, nil
+}
+
+// functions are the members of the `extract` table. They all return the
+// first match, or nil when there is none.
+func (e *extractBind) functions() []lua.RegistryFunction {
+	return []lua.RegistryFunction{
+		{Name: "json", Function: e.json},
+		{Name: "regex", Function: e.regex},
+		{Name: "css", Function: e.css},
+		{Name: "xpath", Function: e.xpath},
+	}
+}
+
+// json finds a value with a JSONPath: extract.json(body, "$.items[0].id")
+func (e *extractBind) json(l *lua.State) int {
+	body := lua.CheckString(l, 1)
+	c, err := e.compile("json", lua.CheckString(l, 2), func(expr string) (interface{}, error) {
+		return extract.CompileJSONPath(expr)
+	})
+	if err != nil {
+		lua.ArgumentError(l, 2, err.Error())
+		return 0
+	}
+	values, err := c.(*extract.JSONPath).Evaluate([]byte(body))
+	if err != nil {
+		lua.Errorf(l, "lua-extract: body isn't JSON: %s", err.Error())
+		return 0
+	}
+	if len(values) == 0 {
+		l.PushNil()
+		return 1
+	}
+	if err := pushValue(l, "extract", values[0]); err != nil {
+		lua.Errorf(l, "lua-extract: %s", err.Error())
+		return 0
+	}
+	return 1
+}
+
+// regex finds the first capture group of a regular expression, or the
+// whole match if it has none: extract.regex(body, `name="csrf" value="(\w+)"`)
+func (e *extractBind) regex(l *lua.State) int {
+	body := lua.CheckString(l, 1)
+	c, err := e.compile("regex", lua.CheckString(l, 2), func(expr string) (interface{}, error) {
+		return regexp.Compile(expr)
+	})
+	if err != nil {
+		lua.ArgumentError(l, 2, err.Error())
+		return 0
+	}
+	match := c.(*regexp.Regexp).FindStringSubmatch(body)
+	switch {
+	case match == nil:
+		l.PushNil()
+	case len(match) > 1:
+		l.PushString(match[1])
+	default:
+		l.PushString(match[0])
+	}
+	return 1
+}
+
+// css finds an attribute of an element, or its text when no attribute
+// is given: extract.css(html, "input[name=csrf]", "value")
+func (e *extractBind) css(l *lua.State) int {
+	doc := lua.CheckString(l, 1)
+	c, err := e.compile("css", lua.CheckString(l, 2), func(expr string) (interface{}, error) {
+		return extract.CompileCSS(expr)
+	})
+	if err != nil {
+		lua.ArgumentError(l, 2, err.Error())
+		return 0
+	}
+	attr := lua.OptString(l, 3, "")
+	for _, n := range c.(*extract.Selector).MatchAll(extract.ParseHTML([]byte(doc))) {
+		if attr == "" {
+			l.PushString(n.TextContent())
+			return 1
+		}
+		if value, ok := n.Attr(attr); ok {
+			l.PushString(value)
+			return 1
+		}
+	}
+	l.PushNil()
+	return 1
+}
+
+// xpath finds the text of an element, an attribute or some text:
+// extract.xpath(html, "//a[@rel='next']/@href")
+func (e *extractBind) xpath(l *lua.State) int {
+	doc := lua.CheckString(l, 1)
+	c, err := e.compile("xpath", lua.CheckString(l, 2), func(expr string) (interface{}, error) {
+		return extract.CompileXPath(expr)
+	})
+	if err != nil {
+		lua.ArgumentError(l, 2, err.Error())
+		return 0
+	}
+	values := c.(*extract.XPath).Evaluate(extract.ParseHTML([]byte(doc)))
+	if len(values) == 0 {
+		l.PushNil()
+		return 1
+	}
+	l.PushString(values[0])
+	return 1
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shopify/go-lua"
+	"golang.org/x/net/context"
+)
+
+const setupDataRegistryKey = "loadtests.setup_data"
+
+// How many instructions a hook runs between two looks at whether its context
+// is done
+const hookCheckInterval = 1000
+
+// The hooks a script can define, as global functions, around its steps:
+//
+//	setup()          once per load test, before any step runs; what it
+//	                 returns is given to everything below
+//	init(data)       once per worker, before its first execution
+//	step.*(prev, data)
+//	                 the data feed is then read with feed.next()
+//	finish(data)     once per worker, after its last execution
+//	teardown(data)   once per load test, after every worker finished
+//
+// Like executions, hooks start without the globals assigned by earlier ones.
+const (
+	hookSetup    = "setup"
+	hookInit     = "init"
+	hookFinish   = "finish"
+	hookTeardown = "teardown"
+)
+
+// Setup runs the `setup` hook of the script, if it has one, and returns what
+// it returned encoded as JSON, so it can be handed to other programs with
+// SetSetupData. The JSON is "null" if there is no hook, or it returned
+// nothing. The hook is stopped once `ctx` is done.
+func (prgm *LuaProgram) Setup(ctx context.Context) ([]byte, error) {
+	called, err := prgm.callHook(ctx, hookSetup, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if !called {
+		return []byte("null"), nil
+	}
+	l := prgm.vm
+	defer l.Pop(1)
+	data, err := goValue(l, -1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: can't return its value: %v", hookSetup, err)
+	}
+	return json.Marshal(data)
+}
+
+// SetSetupData gives the program what the `setup` hook returned, as encoded
+// by Setup. Every step and the other hooks receive it.
+func (prgm *LuaProgram) SetSetupData(data []byte) error {
+	var val interface{}
+	if err := json.Unmarshal(data, &val); err != nil {
+		return fmt.Errorf("setup data must be JSON: %v", err)
+	}
+	l := prgm.vm
+	top := l.Top()
+	if err := pushValue(l, hookSetup, val); err != nil {
+		l.SetTop(top)
+		return err
+	}
+	l.SetField(lua.RegistryIndex, setupDataRegistryKey)
+	return nil
+}
+
+// Init runs the `init` hook of the script, if it has one.
+func (prgm *LuaProgram) Init() error {
+	_, err := prgm.callHook(context.Background(), hookInit, 1, 0)
+	return err
+}
+
+// Finish runs the `finish` hook of the script, if it has one.
+func (prgm *LuaProgram) Finish() error {
+	_, err := prgm.callHook(context.Background(), hookFinish, 1, 0)
+	return err
+}
+
+// Teardown runs the `teardown` hook of the script, if it has one. The hook is
+// stopped once `ctx` is done.
+func (prgm *LuaProgram) Teardown(ctx context.Context) error {
+	_, err := prgm.callHook(ctx, hookTeardown, 1, 0)
+	return err
+}
+
+// callHook calls the global function `name`, with the setup data if `args`
+// is 1, leaving `results` values on the stack. It returns false if the
+// script doesn't define it. The hook, and its requests, are stopped once
+// `ctx` is done.
+func (prgm *LuaProgram) callHook(ctx context.Context, name string, args, results int) (bool, error) {
+	l := prgm.vm
+	l.Global(name)
+	if !l.IsFunction(-1) {
+		l.Pop(1)
+		return false, nil
+	}
+	if args > 0 {
+		l.Field(lua.RegistryIndex, setupDataRegistryKey)
+	}
+
+	if ctx.Done() != nil {
+		lua.SetDebugHook(l, func(l *lua.State, _ lua.Debug) {
+			select {
+			case <-ctx.Done():
+				lua.Errorf(l, "%s", ctx.Err().Error())
+			default:
+			}
+		}, lua.MaskCount, hookCheckInterval)
+		prgm.http.ctx = ctx
+		defer func() {
+			lua.SetDebugHook(l, nil, 0, 0)
+			prgm.http.ctx = nil
+		}()
+	}
+
+	current := name
+	prgm.logIn(&current)
+	prgm.resetEnv()
+	if err := l.ProtectedCall(args, results, 0); err != nil {
+		l.Pop(1) // the error
+		return true, fmt.Errorf("%s: %v", name, err)
+	}
+	return true, nil
+}
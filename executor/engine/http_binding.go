@@ -1,50 +1,86 @@
 package engine
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/Shopify/go-lua"
+	"golang.org/x/net/context"
 )
 
 type httpBind struct {
 	metrics MetricReporter
 	client  *http.Client
+	// nil if any host can be requested
+	allowed map[string]bool
+	// if set, requests are cancelled once it's done
+	ctx context.Context
 }
 
-func newHTTPBinding(met MetricReporter) *httpBind {
-	return &httpBind{
+func newHTTPBinding(met MetricReporter, allowed map[string]bool) *httpBind {
+	h := &httpBind{
 		metrics: met,
 		client:  &http.Client{},
+		allowed: allowed,
 	}
+	if allowed != nil {
+		h.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if !h.allows(req.URL) {
+				return fmt.Errorf("redirected to %q, which isn't an allowed host", HostPort(req.URL))
+			}
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return nil
+		}
+	}
+	h.resetJar()
+	return h
 }
 
-func (h *httpBind) get(l *lua.State) int {
+// allows tells if requests can be sent to the host and port of `u`.
+func (h *httpBind) allows(u *url.URL) bool {
+	return h.allowed == nil || h.allowed[strings.ToLower(u.Hostname())] || h.allowed[HostPort(u)]
+}
 
-	u := lua.CheckString(l, -1)
+// HostPort is where the requests to `u` are sent, as host:port. The port is
+// the default one of the scheme when `u` has none.
+func HostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
 
-	start := time.Now()
-	resp, err := h.client.Get(u)
-	if err != nil {
-		h.metrics.IncrHTTPError(u)
-		lua.Errorf(l, "lua-http: can't GET: %s", err.Error())
-		return 0
+// functions are the members of the `http` table
+func (h *httpBind) functions() []lua.RegistryFunction {
+	return []lua.RegistryFunction{
+		{Name: "request", Function: h.request},
+		{Name: "get", Function: h.get},
+		{Name: "post", Function: h.post},
 	}
-	defer resp.Body.Close()
-	defer func() {
-		h.metrics.IncrHTTPGet(u, resp.StatusCode, time.Since(start))
-	}()
+}
+
+func (h *httpBind) get(l *lua.State) int {
 
-	args, err := pushResponse(l, resp)
+	u := lua.CheckString(l, -1)
+
+	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		h.metrics.IncrHTTPError(u)
-		lua.Errorf(l, "lua-http: can't read body from GET: %s", err.Error())
-		return args
+		return h.fail(l, "GET", u, ErrorProtocol, err, nil)
 	}
-
-	return args
+	return h.do(l, req, requestOptions{})
 }
 
 func (h *httpBind) post(l *lua.State) int {
@@ -53,33 +89,159 @@ func (h *httpBind) post(l *lua.State) int {
 	contentType := lua.CheckString(l, -2)
 	body := lua.CheckString(l, -1)
 
-	start := time.Now()
-	resp, err := h.client.Post(u, contentType, strings.NewReader(body))
+	req, err := http.NewRequest("POST", u, strings.NewReader(body))
 	if err != nil {
-		h.metrics.IncrHTTPError(u)
-		lua.Errorf(l, "lua-http: can't POST: %s", err.Error())
+		return h.fail(l, "POST", u, ErrorProtocol, err, nil)
+	}
+	req.Header.Set("Content-Type", contentType)
+	return h.do(l, req, requestOptions{})
+}
+
+// request sends the request described by a table:
+//
+//	http.request{
+//	  method  = "PUT",                    -- defaults to GET
+//	  url     = "http://example.com/",    -- required
+//	  headers = {Authorization = "..."},
+//	  query   = {page = 2},               -- added to the query of `url`
+//	  body    = "...",
+//	  timeout = 1.5,                      -- in seconds
+//	  expect_errors = {"timeout"},        -- see below
+//	}
+//
+// A request that gets no response raises an error, unless its kind is one of
+// `expect_errors`. The request then returns nil and the error as a table:
+// {kind = "timeout", message = "..."}.
+func (h *httpBind) request(l *lua.State) int {
+	lua.CheckType(l, 1, lua.TypeTable)
+
+	method := strings.ToUpper(stringField(l, 1, "method", "GET"))
+	u := stringField(l, 1, "url", "")
+	if u == "" {
+		lua.ArgumentError(l, 1, "field 'url' is required")
 		return 0
 	}
-	defer resp.Body.Close()
-	defer func() {
-		h.metrics.IncrHTTPPost(u, resp.StatusCode, time.Since(start))
-	}()
+	body := stringField(l, 1, "body", "")
+	opts := requestOptions{
+		timeout: time.Duration(numberField(l, 1, "timeout", 0) * float64(time.Second)),
+	}
+	l.Field(1, "expect_errors")
+	if l.IsTable(-1) {
+		opts.expectErrors = make(map[string]bool)
+		forEachPair(l, -1, "expect_errors", func(_, kind string) {
+			if !validErrorKind(kind) {
+				lua.ArgumentError(l, 1, "unknown error kind '"+kind+"' in 'expect_errors'")
+			}
+			opts.expectErrors[kind] = true
+		})
+	}
+	l.Pop(1)
+
+	target, err := url.Parse(u)
+	if err != nil {
+		return h.fail(l, method, u, ErrorProtocol, err, opts.expectErrors)
+	}
+	l.Field(1, "query")
+	if l.IsTable(-1) {
+		query := target.Query()
+		forEachPair(l, -1, "query", query.Add)
+		target.RawQuery = query.Encode()
+	}
+	l.Pop(1)
 
-	args, err := pushResponse(l, resp)
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, target.String(), rd)
 	if err != nil {
-		h.metrics.IncrHTTPError(u)
-		lua.Errorf(l, "lua-http: can't read body from POST: %s", err.Error())
-		return args
+		return h.fail(l, method, u, ErrorProtocol, err, opts.expectErrors)
+	}
+
+	l.Field(1, "headers")
+	if l.IsTable(-1) {
+		forEachPair(l, -1, "headers", func(key, value string) {
+			if http.CanonicalHeaderKey(key) == "Host" {
+				req.Host = value
+				return
+			}
+			req.Header.Add(key, value)
+		})
 	}
+	l.Pop(1)
+
+	return h.do(l, req, opts)
+}
 
-	return args
+type requestOptions struct {
+	timeout time.Duration
+	// the kinds of errors that are returned to the script, not raised
+	expectErrors map[string]bool
 }
 
-func pushResponse(l *lua.State, resp *http.Response) (int, error) {
+func (h *httpBind) do(l *lua.State, req *http.Request, opts requestOptions) int {
+	client := h.client
+	if opts.timeout > 0 {
+		withTimeout := *h.client
+		withTimeout.Timeout = opts.timeout
+		client = &withTimeout
+	}
+	u := req.URL.String()
+	if !h.allows(req.URL) {
+		lua.Errorf(l, "%s", fmt.Sprintf("lua-http: can't %s %s: %q isn't an allowed host", req.Method, u, HostPort(req.URL)))
+		return 0
+	}
+
+	if h.ctx != nil {
+		req = req.WithContext(h.ctx)
+	}
+	trace := newTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+	resp, err := client.Do(req)
+	if err != nil {
+		return h.fail(l, req.Method, u, classify(err), err, opts.expectErrors)
+	}
+	defer resp.Body.Close()
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return h.fail(l, req.Method, u, ErrorBodyRead, err, opts.expectErrors)
+	}
+	timings := trace.done()
+	h.metrics.IncrHTTPRequest(req.Method, u, resp.StatusCode, timings.Total)
+	h.metrics.IncrHTTPTimings(req.Method, u, timings)
+
+	return pushResponse(l, resp, body, timings)
+}
+
+// fail records a request that failed and raises the error, unless the script
+// expects errors of that kind: `nil, err` is then returned to it.
+func (h *httpBind) fail(l *lua.State, method, u, kind string, err error, expectErrors map[string]bool) int {
+	httpErr := &HTTPError{Kind: kind, Message: err.Error(), Expected: expectErrors[kind]}
+	h.metrics.IncrHTTPError(u, httpErr)
+	if !httpErr.Expected {
+		lua.Errorf(l, "lua-http: can't %s (%s): %s", method, kind, err.Error())
+		return 0
+	}
+	l.PushNil()
+	l.NewTable()
+	l.PushString(httpErr.Kind)
+	l.SetField(-2, "kind")
+	l.PushString(httpErr.Message)
+	l.SetField(-2, "message")
+	return 2
+}
+
+func validErrorKind(kind string) bool {
+	for _, k := range ErrorKinds {
+		if k == kind {
+			return true
+		}
 	}
+	return false
+}
+
+func pushResponse(l *lua.State, resp *http.Response, body []byte, timings HTTPTimings) int {
 	l.NewTable()
 	setstring := func(key, value string) {
 		l.PushString(value)
@@ -104,5 +266,61 @@ func pushResponse(l *lua.State, resp *http.Response) (int, error) {
 	}
 	l.SetField(-2, "header")
 
-	return 1, nil
+	// push the timings, in seconds
+	l.NewTable()
+	setfloat("dns", timings.DNS.Seconds())
+	setfloat("connect", timings.Connect.Seconds())
+	setfloat("tls", timings.TLS.Seconds())
+	setfloat("ttfb", timings.TTFB.Seconds())
+	setfloat("transfer", timings.Transfer.Seconds())
+	setfloat("total", timings.Total.Seconds())
+	l.SetField(-2, "timings")
+
+	return 1
+}
+
+// stringField reads `table[key]` from the table at `index`, numbers are
+// converted to strings.
+func stringField(l *lua.State, index int, key, def string) string {
+	l.Field(index, key)
+	defer l.Pop(1)
+	if l.IsNoneOrNil(-1) {
+		return def
+	}
+	s, ok := l.ToString(-1)
+	if !ok {
+		lua.Errorf(l, "field '%s' must be a string, got %s", key, lua.TypeNameOf(l, -1))
+	}
+	return s
+}
+
+func numberField(l *lua.State, index int, key string, def float64) float64 {
+	l.Field(index, key)
+	defer l.Pop(1)
+	if l.IsNoneOrNil(-1) {
+		return def
+	}
+	n, ok := l.ToNumber(-1)
+	if !ok {
+		lua.Errorf(l, "field '%s' must be a number, got %s", key, lua.TypeNameOf(l, -1))
+	}
+	return n
+}
+
+// forEachPair calls fn with every key/value of the table at `index`, both
+// converted to strings.
+func forEachPair(l *lua.State, index int, name string, fn func(key, value string)) {
+	index = l.AbsIndex(index)
+	l.PushNil()
+	for l.Next(index) {
+		// convert copies, converting the key in place would confuse `Next`
+		l.PushValue(-2)
+		key, keyOk := l.ToString(-1)
+		value, valueOk := l.ToString(-2)
+		l.Pop(2)
+		if !keyOk || !valueOk {
+			lua.Errorf(l, "'%s' must only contain strings and numbers", name)
+		}
+		fn(key, value)
+	}
 }
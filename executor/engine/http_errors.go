@@ -0,0 +1,90 @@
+package engine
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// The kinds of HTTP errors, why a request got no response
+const (
+	ErrorDNS      = "dns"
+	ErrorConnect  = "connect"
+	ErrorTLS      = "tls"
+	ErrorTimeout  = "timeout"
+	ErrorReset    = "reset"
+	ErrorProtocol = "protocol"
+	ErrorBodyRead = "body_read"
+)
+
+// ErrorKinds are all the kinds an HTTPError can be of
+var ErrorKinds = []string{
+	ErrorDNS, ErrorConnect, ErrorTLS, ErrorTimeout, ErrorReset, ErrorProtocol, ErrorBodyRead,
+}
+
+// HTTPError is a request that failed
+type HTTPError struct {
+	Kind    string
+	Message string
+	// Expected is set when the script said it can live with errors of
+	// this kind
+	Expected bool
+}
+
+// classify finds the kind of error a request failed with. Errors reading the
+// body are classified by the caller, as they look like any other.
+func classify(err error) string {
+	dial := false
+	// The error is unwrapped from those of net/http and net, which tell
+	// whether it timed out and what was being done
+	for {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return ErrorTimeout
+		}
+		switch e := err.(type) {
+		case *url.Error:
+			err = e.Err
+			continue
+		case *net.OpError:
+			if e.Op == "remote error" {
+				// an alert sent by the server during the TLS handshake
+				return ErrorTLS
+			}
+			dial = dial || e.Op == "dial"
+			err = e.Err
+			continue
+		case *os.SyscallError:
+			err = e.Err
+			continue
+		case *net.DNSError:
+			return ErrorDNS
+		}
+		break
+	}
+	if isTLSError(err) {
+		return ErrorTLS
+	}
+	switch err {
+	case syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE, io.EOF, io.ErrUnexpectedEOF:
+		return ErrorReset
+	}
+	if dial {
+		return ErrorConnect
+	}
+	return ErrorProtocol
+}
+
+func isTLSError(err error) bool {
+	switch err.(type) {
+	case tls.RecordHeaderError, x509.UnknownAuthorityError, x509.HostnameError, x509.CertificateInvalidError:
+		return true
+	}
+	// the other errors of the handshake, like failing to verify the
+	// certificate, are only told apart by their message
+	return strings.HasPrefix(err.Error(), "tls: ")
+}
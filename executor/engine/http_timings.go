@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// HTTPTimings breaks down where the time of a request went. When a request
+// is redirected, the phases of every hop are added up.
+type HTTPTimings struct {
+	// resolving the host name
+	DNS time.Duration
+	// establishing the TCP connection
+	Connect time.Duration
+	// the TLS handshake
+	TLS time.Duration
+	// from the request being written to the first byte of the response
+	TTFB time.Duration
+	// from the first byte of the response to the end of its body
+	Transfer time.Duration
+	// the whole request
+	Total time.Duration
+}
+
+// tracer measures the phases of a request as httptrace reports them. The
+// callbacks can be called concurrently, when dialing several addresses.
+type tracer struct {
+	lock sync.Mutex
+	start,
+	dnsStart,
+	connectStart,
+	tlsStart,
+	wroteRequest,
+	firstByte time.Time
+	timings HTTPTimings
+}
+
+func newTracer() *tracer {
+	return &tracer{start: time.Now()}
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.since(&t.dnsStart, &t.timings.DNS)
+		},
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.since(&t.connectStart, &t.timings.Connect)
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.since(&t.tlsStart, &t.timings.TLS)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mark(&t.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+			t.since(&t.wroteRequest, &t.timings.TTFB)
+		},
+	}
+}
+
+func (t *tracer) mark(at *time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	*at = time.Now()
+}
+
+func (t *tracer) since(start *time.Time, phase *time.Duration) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !start.IsZero() {
+		*phase += time.Since(*start)
+	}
+}
+
+// done is called once the body of the response was read.
+func (t *tracer) done() HTTPTimings {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	if !t.firstByte.IsZero() {
+		t.timings.Transfer = now.Sub(t.firstByte)
+	}
+	t.timings.Total = now.Sub(t.start)
+	return t.timings
+}
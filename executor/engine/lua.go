@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/Shopify/go-lua"
 	"golang.org/x/net/context"
 )
 
+const chunkRegistryKey = "loadtests.chunk"
+
 type LuaOption func(*LuaProgram)
 
 func SetMetricReporter(met MetricReporter) LuaOption {
@@ -24,8 +27,34 @@ func SetLogger(out io.Writer) LuaOption {
 	}
 }
 
+// KeepCookies makes the cookies received during an execution available to
+// the following ones. By default, every execution starts without cookies.
+func KeepCookies(keep bool) LuaOption {
+	return func(prgm *LuaProgram) {
+		prgm.keepCookies = keep
+	}
+}
+
+// AllowHosts restricts the requests of the script to the given hosts,
+// redirects included. A host given with a port, like example.com:8080, is
+// only allowed on that port, see HostPort; without, on any port. By default,
+// any host can be requested.
+func AllowHosts(hosts ...string) LuaOption {
+	return func(prgm *LuaProgram) {
+		if len(hosts) == 0 {
+			return
+		}
+		prgm.allowedHosts = make(map[string]bool, len(hosts))
+		for _, host := range hosts {
+			prgm.allowedHosts[strings.ToLower(host)] = true
+		}
+	}
+}
+
 var _ Program = &LuaProgram{}
 
+// LuaProgram is a compiled script. It can be executed any number of times,
+// but not concurrently: each worker should own its program.
 type LuaProgram struct {
 	vm *lua.State
 
@@ -37,6 +66,14 @@ type LuaProgram struct {
 	info    func(*lua.State) int
 	fatal   func(*lua.State) int
 
+	http        *httpBind
+	keepCookies bool
+	feed        DataFeed
+	// the kinds of the custom metrics declared by the script
+	metricKinds map[string]string
+	// nil if any host can be requested
+	allowedHosts map[string]bool
+
 	out io.Writer
 }
 
@@ -44,16 +81,15 @@ func Lua(source io.Reader, opts ...LuaOption) (*LuaProgram, error) {
 	l := lua.NewState()
 
 	prgm := &LuaProgram{
-		vm:      l,
-		out:     ioutil.Discard,
-		metrics: nullMetric{},
+		vm:          l,
+		out:         ioutil.Discard,
+		metrics:     nullMetric{},
+		metricKinds: make(map[string]string),
 		info: func(l *lua.State) int {
 			panic(fmt.Errorf("'info' is not defined outside of steps"))
-			return 0
 		},
 		fatal: func(l *lua.State) int {
 			panic(fmt.Errorf("'fatal' is not defined outside of steps"))
-			return 0
 		},
 	}
 
@@ -72,15 +108,34 @@ func Lua(source io.Reader, opts ...LuaOption) (*LuaProgram, error) {
 		prgm.metrics.IncrLogFatal(l.ToValue(1))
 		return prgm.fatal(l)
 	})
+	l.Register("check", prgm.check)
 
-	httpBind := newHTTPBinding(prgm.metrics)
+	httpBind := newHTTPBinding(prgm.metrics, prgm.allowedHosts)
 	l.Register("get", httpBind.get)
 	l.Register("post", httpBind.post)
+	lua.NewLibrary(l, httpBind.functions())
+	l.SetGlobal("http")
+	lua.NewLibrary(l, httpBind.cookieFunctions())
+	l.SetGlobal("cookies")
+	prgm.http = httpBind
+	// `feed` is the same table, for the steps whose `data` is the setup data
+	lua.NewLibrary(l, prgm.dataFunctions())
+	l.PushValue(-1)
+	l.SetGlobal("feed")
+	l.SetGlobal("data")
+	lua.NewLibrary(l, prgm.metricsFunctions())
+	l.SetGlobal("metrics")
+	lua.NewLibrary(l, newExtractBinding().functions())
+	l.SetGlobal("extract")
 
 	// load the source
 	if err := l.Load(source, "", ""); err != nil {
 		return prgm, fmt.Errorf("compiling program: %v", err)
 	}
+	// keep the chunk around, its environment is swapped before every
+	// execution
+	l.PushValue(-1)
+	l.SetField(lua.RegistryIndex, chunkRegistryKey)
 	// invoke the program to prepare the steps
 	if err := l.ProtectedCall(0, 0, 0); err != nil {
 		return prgm, fmt.Errorf("preparing program: %v", err)
@@ -91,63 +146,32 @@ func Lua(source io.Reader, opts ...LuaOption) (*LuaProgram, error) {
 	return prgm, nil
 }
 
-func VerifyConfig(json map[string]interface{}) error {
-	for key, val := range json {
-		switch valType := val.(type) {
-		case string:
-		case int:
-		case float64:
-		case bool:
-		case nil:
-			break
-		default:
-			return fmt.Errorf("invalid type for lua config file: %v, key was: %v", valType, key)
-		}
-	}
-	return nil
-}
-
+// AddConfig makes every member of the config a global of the script. Numbers,
+// booleans, arrays and objects keep their types, to any depth. The tables are
+// shared by all the executions, so scripts shouldn't modify them.
 func (prgm *LuaProgram) AddConfig(json map[string]interface{}) error {
 	vm := prgm.vm
+	top := vm.Top()
 	for key, val := range json {
-		switch valType := val.(type) {
-		case string:
-			vm.PushString(fmt.Sprintf("%v", val))
-			vm.SetGlobal(key)
-		case int:
-			vm.PushString(fmt.Sprintf("%v", val))
-			vm.SetGlobal(key)
-		case float64:
-			vm.PushString(fmt.Sprintf("%v", val))
-			vm.SetGlobal(key)
-		case bool:
-			vm.PushString(fmt.Sprintf("%v", val))
-			vm.SetGlobal(key)
-		case nil:
-			vm.PushNil()
-			vm.SetGlobal(key)
-		default:
-			return fmt.Errorf("invalid type for lua config file: %v, key was: %v", valType, key)
+		if err := pushValue(vm, key, val); err != nil {
+			vm.SetTop(top)
+			return err
 		}
+		vm.SetGlobal(key)
 	}
 	return nil
 }
 
+// Execute runs all the steps of the program once. Globals assigned by a
+// previous execution are not visible, only the ones that existed once the
+// program was prepared and configured are. The cookies are kept across
+// steps, and across executions when the program was created with
+// KeepCookies.
 func (prgm *LuaProgram) Execute(ctx context.Context) error {
 
 	runNext := true
 	currentStep := "<not a step>"
-
-	prgm.info = func(l *lua.State) int {
-		msg := l.ToValue(1)
-		fmt.Fprintf(prgm.out, `{"lvl":"info","step":%q,"msg":"%v"}`+"\n", currentStep, msg)
-		return 0
-	}
-	prgm.fatal = func(l *lua.State) int {
-		msg := l.ToValue(1)
-		fmt.Fprintf(prgm.out, `{"lvl":"fatal","step":%q,"msg":"%v"}`+"\n", currentStep, msg)
-		return 0
-	}
+	prgm.logIn(&currentStep)
 
 	reporter := func(stepName string) bool {
 		currentStep = stepName
@@ -159,9 +183,40 @@ func (prgm *LuaProgram) Execute(ctx context.Context) error {
 		}
 	}
 
+	prgm.resetEnv()
+	if !prgm.keepCookies {
+		prgm.http.resetJar()
+	}
 	return prgm.runSteps(reporter)
 }
 
+// logIn makes `info` and `fatal` log as coming from the step `current`
+// points to.
+func (prgm *LuaProgram) logIn(current *string) {
+	prgm.info = func(l *lua.State) int {
+		msg := l.ToValue(1)
+		fmt.Fprintf(prgm.out, `{"lvl":"info","step":%q,"msg":"%v"}`+"\n", *current, msg)
+		return 0
+	}
+	prgm.fatal = func(l *lua.State) int {
+		msg := l.ToValue(1)
+		fmt.Fprintf(prgm.out, `{"lvl":"fatal","step":%q,"msg":"%v"}`+"\n", *current, msg)
+		return 0
+	}
+}
+
+// resetEnv gives the chunk, and every function it defined, a new empty
+// environment that falls back on the global table for reads.
+func (prgm *LuaProgram) resetEnv() {
+	l := prgm.vm
+	l.Field(lua.RegistryIndex, chunkRegistryKey)
+	l.NewTable()
+	lua.SetMetaTableNamed(l, "envMetaTable")
+	// _ENV is the only upvalue of a chunk
+	lua.SetUpValue(l, -2, 1)
+	l.Pop(1)
+}
+
 func (prgm *LuaProgram) runSteps(reporter func(step string) bool) error {
 	l := prgm.vm
 
@@ -187,14 +242,16 @@ func (prgm *LuaProgram) runSteps(reporter func(step string) bool) error {
 		l.PushValue(-i)
 		// remove the old copy of the result
 		l.Remove(-(i + 1))
+		// the data returned by setup() is the second argument
+		l.Field(lua.RegistryIndex, setupDataRegistryKey)
 		// now that we have the:
-		//   - argument
+		//   - arguments
 		//   - function
 		//   - step-table
-		// we can invoke the function with the argument
+		// we can invoke the function with the arguments
 
 		start := time.Now()
-		err := l.ProtectedCall(1, 1, 0) // 1 argument, with 1 return value
+		err := l.ProtectedCall(2, 1, 0) // 2 arguments, with 1 return value
 		if err != nil {
 			prgm.metrics.IncrStepError(stepName)
 			return &StepError{Step: stepName, Err: err}
@@ -221,7 +278,7 @@ func (prgm *LuaProgram) registerStep(l *lua.State) int {
 func configureLua(prgm *LuaProgram, l *lua.State) {
 	lua.NewMetaTable(l, "stepMetaTable")
 	lua.SetFunctions(l, []lua.RegistryFunction{{
-		"__newindex", prgm.registerStep,
+		Name: "__newindex", Function: prgm.registerStep,
 	}}, 0)
 
 	// create the `step` table, make it global, give it the
@@ -232,4 +289,13 @@ func configureLua(prgm *LuaProgram, l *lua.State) {
 	l.SetGlobal("step")
 	lua.SetMetaTableNamed(l, "stepMetaTable")
 	l.Pop(2)
+
+	// json, url, base64, crypto, uuid() and now()
+	registerStdLibrary(l)
+
+	// the environment of an execution reads through to the globals
+	lua.NewMetaTable(l, "envMetaTable")
+	l.PushGlobalTable()
+	l.SetField(-2, "__index")
+	l.Pop(1)
 }
@@ -2,12 +2,17 @@ package engine_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/lgpeterson/loadtests/executor/engine"
 	"golang.org/x/net/context"
@@ -103,3 +108,1145 @@ end
 		t.Fatalf("different output")
 	}
 }
+
+func TestLuaProgramReuse(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	script := strings.NewReader(`
+counter = 0
+
+step.first_step = function()
+    counter = counter + 1
+    visits = (visits or 0) + 1
+    info(greeting .. " " .. counter .. " " .. visits)
+end
+`)
+	prgm, err := engine.Lua(script, engine.SetLogger(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.AddConfig(map[string]interface{}{"greeting": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var want string
+	for i := 0; i < 3; i++ {
+		if err := prgm.Execute(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		want += `{"lvl":"info","step":"first_step","msg":"hello 1 1"}` + "\n"
+	}
+	got := buf.String()
+	if want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("state leaked between executions")
+	}
+}
+
+func TestLuaTypedConfig(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+
+	script := strings.NewReader(`
+step.first_step = function()
+    info(retries + 1)
+    if verbose then
+        info("verbose")
+    end
+    for i = 1, #endpoints do
+        info(endpoints[i].method .. " " .. endpoints[i].path .. " " .. endpoints[i].weight * 2)
+    end
+    info(limits.latency.p99)
+    if missing == nil then
+        info("nil")
+    end
+end
+`)
+	prgm, err := engine.Lua(script, engine.SetLogger(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := engine.ParseConfig(`{
+		"retries": 2,
+		"verbose": true,
+		"endpoints": [
+			{"method": "GET", "path": "/items", "weight": 3},
+			{"method": "POST", "path": "/items", "weight": 0.5}
+		],
+		"limits": {"latency": {"p99": "300ms"}},
+		"missing": null
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.AddConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"lvl":"info","step":"first_step","msg":"3"}
+{"lvl":"info","step":"first_step","msg":"verbose"}
+{"lvl":"info","step":"first_step","msg":"GET /items 6"}
+{"lvl":"info","step":"first_step","msg":"POST /items 1"}
+{"lvl":"info","step":"first_step","msg":"300ms"}
+{"lvl":"info","step":"first_step","msg":"nil"}
+`
+	if got := buf.String(); want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+}
+
+func TestLuaInvalidConfig(t *testing.T) {
+	for _, tt := range []struct {
+		config string
+		want   string
+	}{
+		{`[1, 2]`, "must be a JSON object"},
+		{`{"a": `, "must be a JSON object"},
+	} {
+		if _, err := engine.ParseConfig(tt.config); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("config %s: want an error containing %q, got %v", tt.config, tt.want, err)
+		}
+	}
+
+	// JSON only has valid types, but configs can also be made in Go
+	err := engine.VerifyConfig(map[string]interface{}{
+		"endpoints": []interface{}{
+			map[string]interface{}{"path": "/"},
+			map[string]interface{}{"timeout": time.Second},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "at: endpoints[1].timeout") {
+		t.Errorf("want an error at endpoints[1].timeout, got %v", err)
+	}
+}
+
+func TestLuaDataFeed(t *testing.T) {
+	records := []map[string]interface{}{
+		{"user": "ann", "pass": "a"},
+		{"user": "bob", "pass": "b"},
+	}
+	script := `
+step.first_step = function()
+    local record = data.next()
+    if not record then
+        info("none left")
+    else
+        info(record.user .. ":" .. record.pass)
+    end
+end
+`
+	for _, tt := range []struct {
+		name string
+		feed engine.DataFeed
+		want []string
+	}{
+		{"sequential", engine.NewSequentialFeed(records), []string{"ann:a", "bob:b", "ann:a"}},
+		{"unique", engine.NewUniqueFeed(records), []string{"ann:a", "bob:b", "none left"}},
+	} {
+		buf := bytes.NewBuffer(nil)
+		prgm, err := engine.Lua(strings.NewReader(script), engine.SetLogger(buf), engine.SetDataFeed(tt.feed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want string
+		for _, msg := range tt.want {
+			if err := prgm.Execute(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			want += `{"lvl":"info","step":"first_step","msg":"` + msg + `"}` + "\n"
+		}
+		if got := buf.String(); want != got {
+			t.Logf("want=%q", want)
+			t.Logf(" got=%q", got)
+			t.Errorf("%s: different output", tt.name)
+		}
+	}
+
+	// A step given the setup data reads the feed as `feed`
+	buf := bytes.NewBuffer(nil)
+	prgm, err := engine.Lua(strings.NewReader(`
+step.first_step = function(prev, data)
+    info(feed.next().user)
+end
+`), engine.SetLogger(buf), engine.SetDataFeed(engine.NewSequentialFeed(records)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"lvl":"info","step":"first_step","msg":"ann"}` + "\n"; buf.String() != want {
+		t.Errorf("want %q, got %q", want, buf.String())
+	}
+
+	// A script needing data can't run without
+	prgm, err = engine.Lua(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err == nil || !strings.Contains(err.Error(), "no data feed") {
+		t.Fatalf("want an error about the missing data feed, got %v", err)
+	}
+}
+
+const benchScript = `
+step.first_step = function()
+    local words = {}
+    for i = 1, 10 do
+        words[i] = greeting .. i
+    end
+    return words
+end
+
+step.second_step = function(last)
+    local n = 0
+    for i = 1, #last do
+        n = n + #last[i]
+    end
+    return n
+end
+`
+
+var benchConfig = map[string]interface{}{"greeting": "hello", "verbose": false}
+
+func BenchmarkLuaCompileEachExecution(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		prgm, err := engine.Lua(strings.NewReader(benchScript))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := prgm.AddConfig(benchConfig); err != nil {
+			b.Fatal(err)
+		}
+		if err := prgm.Execute(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLuaReuseProgram(b *testing.B) {
+	ctx := context.Background()
+	prgm, err := engine.Lua(strings.NewReader(benchScript))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := prgm.AddConfig(benchConfig); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := prgm.Execute(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLuaHTTPRequest(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		got = append(got, fmt.Sprintf("%s %s auth=%q body=%q",
+			r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), body))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	script := strings.NewReader(fmt.Sprintf(`
+step.first_step = function()
+	local resp = http.request{
+		method = "put",
+		url = %q,
+		headers = {Authorization = "Bearer abc"},
+		query = {page = 2},
+		body = "hello",
+		timeout = 5,
+	}
+	info(resp.code)
+	http.request{method = "DELETE", url = %q}
+	http.request{method = "HEAD", url = %q}
+end
+`, srv.URL+"/items?sort=asc", srv.URL+"/items/1", srv.URL))
+
+	buf := bytes.NewBuffer(nil)
+	met := &recordMetrics{}
+	prgm, err := engine.Lua(script, engine.SetLogger(buf), engine.SetMetricReporter(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`PUT /items?page=2&sort=asc auth="Bearer abc" body="hello"`,
+		`DELETE /items/1 auth="" body=""`,
+		`HEAD / auth="" body=""`,
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different requests")
+	}
+
+	wantMethods := []string{"PUT", "DELETE", "HEAD"}
+	if fmt.Sprint(wantMethods) != fmt.Sprint(met.methods) {
+		t.Fatalf("want methods %v, got %v", wantMethods, met.methods)
+	}
+
+	wantLog := `{"lvl":"info","step":"first_step","msg":"202"}` + "\n"
+	if gotLog := buf.String(); wantLog != gotLog {
+		t.Logf("want=%q", wantLog)
+		t.Logf(" got=%q", gotLog)
+		t.Fatalf("different output")
+	}
+}
+
+type recordMetrics struct {
+	methods []string
+	timings []engine.HTTPTimings
+	errors  []engine.HTTPError
+	checks  []string
+	custom  []string
+}
+
+func (r *recordMetrics) IncrScriptExecution()                    {}
+func (r *recordMetrics) IncrStepExecution(string, time.Duration) {}
+func (r *recordMetrics) IncrStepError(string)                    {}
+func (r *recordMetrics) IncrHTTPRequest(method, url string, code int, dur time.Duration) {
+	r.methods = append(r.methods, method)
+}
+func (r *recordMetrics) IncrHTTPTimings(method, url string, timings engine.HTTPTimings) {
+	r.timings = append(r.timings, timings)
+}
+func (r *recordMetrics) IncrHTTPError(url string, err *engine.HTTPError) {
+	r.errors = append(r.errors, *err)
+}
+func (r *recordMetrics) IncrCheck(name string, passed bool) {
+	r.checks = append(r.checks, fmt.Sprintf("%s=%v", name, passed))
+}
+func (r *recordMetrics) IncrCustomMetric(name, kind string, value float64, tags map[string]string) {
+	r.custom = append(r.custom, fmt.Sprintf("%s %s %v %v", kind, name, value, tags))
+}
+func (r *recordMetrics) IncrLogInfo(interface{})  {}
+func (r *recordMetrics) IncrLogFatal(interface{}) {}
+
+func TestLuaHTTPTimings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("timed"))
+	}))
+	defer srv.Close()
+	// A host name, for the DNS phase
+	u := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	script := strings.NewReader(fmt.Sprintf(`
+step.first_step = function()
+	local timings = get(%q).timings
+	local phases = {"dns", "connect", "tls", "ttfb", "transfer", "total"}
+	for i = 1, #phases do
+		if timings[phases[i]] then
+			info(phases[i])
+		end
+	end
+end
+`, u))
+
+	buf := bytes.NewBuffer(nil)
+	met := &recordMetrics{}
+	prgm, err := engine.Lua(script, engine.SetLogger(buf), engine.SetMetricReporter(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var want string
+	for _, phase := range []string{"dns", "connect", "tls", "ttfb", "transfer", "total"} {
+		want += fmt.Sprintf(`{"lvl":"info","step":"first_step","msg":"%s"}`+"\n", phase)
+	}
+	if got := buf.String(); want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+	if len(met.timings) != 1 {
+		t.Fatalf("want the timings of 1 request, got %d", len(met.timings))
+	}
+	// Every phase of a plain HTTP request was measured, none is longer
+	// than the whole request
+	timings := met.timings[0]
+	for _, phase := range []struct {
+		name string
+		dur  time.Duration
+	}{
+		{"dns", timings.DNS},
+		{"connect", timings.Connect},
+		{"ttfb", timings.TTFB},
+	} {
+		if phase.dur <= 0 || phase.dur > timings.Total {
+			t.Errorf("want the %s phase measured within the total, got %+v", phase.name, timings)
+		}
+	}
+	if timings.TLS != 0 || timings.Transfer < 0 || timings.Transfer > timings.Total {
+		t.Errorf("want no TLS phase and the transfer within the total, got %+v", timings)
+	}
+}
+
+func TestLuaHTTPErrors(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+	// Nothing listens on the address of a closed server
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	// Its certificate isn't trusted
+	untrusted := httptest.NewTLSServer(http.NotFoundHandler())
+	defer untrusted.Close()
+	// It hangs up without answering
+	hangUp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer hangUp.Close()
+
+	script := fmt.Sprintf(`
+step.first_step = function()
+	local resp, err = http.request{url = %q, timeout = 0.01, expect_errors = {"timeout"}}
+	if resp == nil then
+		info(err.kind)
+	end
+	resp, err = http.request{url = %q, expect_errors = {"connect", "dns"}}
+	if resp == nil then
+		info(err.kind)
+	end
+	resp, err = http.request{url = %q, expect_errors = {"tls"}}
+	if resp == nil then
+		info(err.kind)
+	end
+	resp, err = http.request{url = %q, expect_errors = {"reset"}}
+	if resp == nil then
+		info(err.kind)
+	end
+end
+
+step.second_step = function()
+	get(%[2]q)
+end
+`, slow.URL, closed.URL, untrusted.URL, hangUp.URL)
+
+	buf := bytes.NewBuffer(nil)
+	met := &recordMetrics{}
+	prgm, err := engine.Lua(strings.NewReader(script), engine.SetLogger(buf), engine.SetMetricReporter(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = prgm.Execute(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "(connect)") {
+		t.Fatalf("want the unexpected error raised with its kind, got %v", err)
+	}
+
+	want := `{"lvl":"info","step":"first_step","msg":"timeout"}
+{"lvl":"info","step":"first_step","msg":"connect"}
+{"lvl":"info","step":"first_step","msg":"tls"}
+{"lvl":"info","step":"first_step","msg":"reset"}
+`
+	if got := buf.String(); want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+
+	var got []string
+	for _, e := range met.errors {
+		got = append(got, fmt.Sprintf("%s expected=%v", e.Kind, e.Expected))
+	}
+	wantErrors := []string{"timeout expected=true", "connect expected=true", "tls expected=true", "reset expected=true", "connect expected=false"}
+	if fmt.Sprint(wantErrors) != fmt.Sprint(got) {
+		t.Fatalf("want errors %v, got %v", wantErrors, got)
+	}
+
+	bad := strings.NewReader(`
+step.first_step = function()
+	http.request{url = "http://localhost/", expect_errors = {"gremlins"}}
+end
+`)
+	prgm, err = engine.Lua(bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err == nil || !strings.Contains(err.Error(), "gremlins") {
+		t.Fatalf("want an error about the unknown kind, got %v", err)
+	}
+}
+
+func TestLuaCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		case "/whoami":
+			c, err := r.Cookie("session")
+			if err != nil {
+				w.Write([]byte("nobody"))
+				return
+			}
+			w.Write([]byte(c.Value))
+		}
+	}))
+	defer srv.Close()
+
+	script := fmt.Sprintf(`
+step.before_login = function()
+	info(get(%[1]q .. "/whoami").body)
+end
+
+step.login = function()
+	get(%[1]q .. "/login")
+	info(get(%[1]q .. "/whoami").body)
+	info(cookies.get(%[1]q).session)
+end
+
+step.override = function()
+	cookies.set(%[1]q, "session", "xyz")
+	info(get(%[1]q .. "/whoami").body)
+	cookies.clear()
+	info(get(%[1]q .. "/whoami").body)
+	get(%[1]q .. "/login")
+end
+`, srv.URL)
+
+	tests := []struct {
+		keep bool
+		want []string
+	}{
+		{keep: false, want: []string{"nobody", "abc", "abc", "xyz", "nobody", "nobody", "abc", "abc", "xyz", "nobody"}},
+		{keep: true, want: []string{"nobody", "abc", "abc", "xyz", "nobody", "abc", "abc", "abc", "xyz", "nobody"}},
+	}
+	for _, tt := range tests {
+		buf := bytes.NewBuffer(nil)
+		prgm, err := engine.Lua(strings.NewReader(script), engine.SetLogger(buf), engine.KeepCookies(tt.keep))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := prgm.Execute(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var msg struct{ Msg string }
+			if err := json.Unmarshal([]byte(line), &msg); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, msg.Msg)
+		}
+		if fmt.Sprint(tt.want) != fmt.Sprint(got) {
+			t.Logf("want=%q", tt.want)
+			t.Logf(" got=%q", got)
+			t.Fatalf("different cookies when keep=%v", tt.keep)
+		}
+	}
+}
+
+func TestLuaAllowHosts(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/elsewhere" {
+			http.Redirect(w, r, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	other := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	port := srv.URL[strings.LastIndex(srv.URL, ":")+1:]
+	tests := []struct {
+		url     string
+		allowed string
+		want    string
+	}{
+		{url: srv.URL, allowed: "127.0.0.1", want: ""},
+		{url: other, allowed: "127.0.0.1", want: `"localhost:` + port + `" isn't an allowed host`},
+		{url: srv.URL + "/elsewhere", allowed: "127.0.0.1", want: `redirected to "localhost:` + port + `", which isn't an allowed host`},
+		// A host allowed with a port is only allowed on that one
+		{url: srv.URL, allowed: "127.0.0.1:" + port, want: ""},
+		{url: srv.URL, allowed: "127.0.0.1:1", want: `"127.0.0.1:` + port + `" isn't an allowed host`},
+		{url: srv.URL + "/elsewhere", allowed: "localhost:1", want: `"127.0.0.1:` + port + `" isn't an allowed host`},
+	}
+	for _, tt := range tests {
+		script := fmt.Sprintf(`
+step.only = function()
+	info(get(%q).body)
+end
+`, tt.url)
+		prgm, err := engine.Lua(strings.NewReader(script), engine.AllowHosts(tt.allowed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = prgm.Execute(context.Background())
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s allowing %s: %v", tt.url, tt.allowed, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s allowing %s: want an error with %q, got %v", tt.url, tt.allowed, tt.want, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		url, want string
+	}{
+		{"http://Example.com/path", "example.com:80"},
+		{"https://example.com", "example.com:443"},
+		{"http://example.com:8080", "example.com:8080"},
+		{"https://[::1]/", "[::1]:443"},
+	} {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := engine.HostPort(u); got != tt.want {
+			t.Errorf("HostPort(%s): want %s, got %s", tt.url, tt.want, got)
+		}
+	}
+}
+
+// runLogs executes a script once and returns what it logged.
+func runLogs(t *testing.T, script string) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	prgm, err := engine.Lua(strings.NewReader(script), engine.SetLogger(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = prgm.Execute(context.Background())
+	return buf.String(), err
+}
+
+func TestLuaJSON(t *testing.T) {
+	got, err := runLogs(t, `
+step.first_step = function()
+    local user = json.decode('{"name":"ann","tags":["a","b"],"age":30,"admin":false,"manager":null}')
+    info(user.name .. " " .. user.tags[2] .. " " .. user.age + 1)
+    if user.admin == false and user.manager == nil then
+        info("not admin")
+    end
+    info(json.encode({name = "bob", scores = {1, 2.5, 3}, nested = {ok = true}}))
+    info(json.encode({}))
+    info(json.encode({[1] = "a", [3] = "c"}))
+    info(json.encode("quote\""))
+end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"lvl":"info","step":"first_step","msg":"ann b 31"}
+{"lvl":"info","step":"first_step","msg":"not admin"}
+{"lvl":"info","step":"first_step","msg":"{"name":"bob","nested":{"ok":true},"scores":[1,2.5,3]}"}
+{"lvl":"info","step":"first_step","msg":"{}"}
+{"lvl":"info","step":"first_step","msg":"{"1":"a","3":"c"}"}
+{"lvl":"info","step":"first_step","msg":""quote\"""}
+`
+	if want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+
+	for script, wantErr := range map[string]string{
+		`step.s = function() json.decode("{") end`:                        "lua-json: can't decode",
+		`step.s = function() local t = {} t.t = t json.encode(t) end`:     "nested deeper",
+		`step.s = function() json.encode({f = function() end}) end`:       "function has no JSON representation",
+		`step.s = function() json.encode({[true] = "yes"}) end`:           "boolean keys",
+		`step.s = function() json.encode({inf = 1/0}) end`:                "+Inf has no JSON representation",
+		`step.s = function() json.encode({n = 1, [2.5] = "half"}) end`:    "",
+		`step.s = function() json.encode({{}, {a = {b = {}}}}) end`:       "",
+		`step.s = function() json.encode(json.decode("[1,[2,[3]]]")) end`: "",
+	} {
+		_, err := runLogs(t, script)
+		if wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error: %v", script, err)
+		} else if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("%s: want an error containing %q, got %v", script, wantErr, err)
+		}
+	}
+}
+
+func TestLuaURL(t *testing.T) {
+	got, err := runLogs(t, `
+step.first_step = function()
+    info(url.encode({q = "a b", page = 2}))
+    info(url.encode("a&b c"))
+    local u = url.parse("https://example.com:8443/items?sort=asc&page=2#top")
+    info(u.scheme .. " " .. u.hostname .. " " .. u.port .. " " .. u.path .. " " .. u.query.sort .. " " .. u.fragment)
+end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"lvl":"info","step":"first_step","msg":"page=2&q=a+b"}
+{"lvl":"info","step":"first_step","msg":"a%26b+c"}
+{"lvl":"info","step":"first_step","msg":"https example.com 8443 /items asc top"}
+`
+	if want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+}
+
+func TestLuaBase64(t *testing.T) {
+	got, err := runLogs(t, `
+step.first_step = function()
+    local encoded = base64.encode("user:pass")
+    info(encoded)
+    info(base64.decode(encoded))
+end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"lvl":"info","step":"first_step","msg":"dXNlcjpwYXNz"}
+{"lvl":"info","step":"first_step","msg":"user:pass"}
+`
+	if want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+
+	_, err = runLogs(t, `step.s = function() base64.decode("!!!") end`)
+	if err == nil || !strings.Contains(err.Error(), "lua-base64: can't decode") {
+		t.Fatalf("want an error decoding invalid base64, got %v", err)
+	}
+}
+
+func TestLuaCrypto(t *testing.T) {
+	got, err := runLogs(t, `
+step.first_step = function()
+    info(crypto.sha256("abc"))
+    info(crypto.hmac("key", "The quick brown fox jumps over the lazy dog"))
+end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"lvl":"info","step":"first_step","msg":"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"}
+{"lvl":"info","step":"first_step","msg":"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"}
+`
+	if want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+}
+
+func TestLuaUUIDAndNow(t *testing.T) {
+	before := float64(time.Now().UnixNano()) / float64(time.Second)
+	got, err := runLogs(t, `
+step.first_step = function()
+    info(uuid())
+    info(uuid())
+    info(now())
+end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := float64(time.Now().UnixNano()) / float64(time.Second)
+
+	var lines []struct{ Msg string }
+	for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+		var l struct{ Msg string }
+		if err := json.Unmarshal([]byte(line), &l); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, l)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("want 3 lines, got %q", got)
+	}
+	uuidRE := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, l := range lines[:2] {
+		if !uuidRE.MatchString(l.Msg) {
+			t.Errorf("want a version 4 UUID, got %q", l.Msg)
+		}
+	}
+	if lines[0].Msg == lines[1].Msg {
+		t.Errorf("want different UUIDs, got %q twice", lines[0].Msg)
+	}
+	ts, err := strconv.ParseFloat(lines[2].Msg, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts < before || ts > after {
+		t.Errorf("want now() within [%f, %f], got %f", before, after, ts)
+	}
+}
+
+func TestLuaExtract(t *testing.T) {
+	got, err := runLogs(t, `
+local page = [[
+<html><body>
+  <form><input type="hidden" name="csrf" value="tok123"></form>
+  <ul><li><a href="/1">One</a><li><a href="/2" rel="next">Two</a></ul>
+</body></html>
+]]
+local body = '{"items":[{"id":41,"tags":["a"]},{"id":42}],"total":2}'
+
+step.first_step = function()
+    info(extract.json(body, "$.items[1].id"))
+    info(extract.json(body, "$.items[0].tags")[1])
+    info(extract.regex(page, [[name="csrf" value="(\w+)"]]))
+    info(extract.regex(page, [[/\d]]))
+    info(extract.css(page, "input[name=csrf]", "value"))
+    info(extract.css(page, "ul li a"))
+    info(extract.xpath(page, "//a[@rel='next']/@href"))
+    if not extract.json(body, "$.missing") and not extract.css(page, "table") and not extract.xpath(page, "//a/@title") then
+        info("no match")
+    end
+end
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"lvl":"info","step":"first_step","msg":"42"}
+{"lvl":"info","step":"first_step","msg":"a"}
+{"lvl":"info","step":"first_step","msg":"tok123"}
+{"lvl":"info","step":"first_step","msg":"/1"}
+{"lvl":"info","step":"first_step","msg":"tok123"}
+{"lvl":"info","step":"first_step","msg":"One"}
+{"lvl":"info","step":"first_step","msg":"/2"}
+{"lvl":"info","step":"first_step","msg":"no match"}
+`
+	if want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+
+	for _, script := range []string{
+		`step.s = function() extract.json("{}", "items") end`,
+		`step.s = function() extract.json("<html>", "$.items") end`,
+		`step.s = function() extract.regex("", "(") end`,
+		`step.s = function() extract.css("", "a[") end`,
+		`step.s = function() extract.xpath("", "//a[") end`,
+	} {
+		if _, err := runLogs(t, script); err == nil {
+			t.Errorf("%s: want an error", script)
+		}
+	}
+}
+
+func TestLuaCheck(t *testing.T) {
+	script := strings.NewReader(`
+step.first_step = function()
+    local resp = {code = 200, body = "hello world"}
+    local ok = check(resp, {
+        ["status is 200"] = function(r) return r.code == 200 end,
+        ["body has bye"] = function(r) return r.body:find("bye") end,
+        ["raises"] = function(r) return r.missing.field end,
+    })
+    if not ok then
+        info("some checks failed")
+    end
+    if check(resp.code, {["is a number"] = function(c) return c > 0 end}) then
+        info("all checks passed")
+    end
+end
+step.second_step = function()
+    info("still running")
+end
+`)
+
+	buf := bytes.NewBuffer(nil)
+	met := &recordMetrics{}
+	prgm, err := engine.Lua(script, engine.SetLogger(buf), engine.SetMetricReporter(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	wantChecks := []string{"body has bye=false", "raises=false", "status is 200=true", "is a number=true"}
+	if fmt.Sprint(wantChecks) != fmt.Sprint(met.checks) {
+		t.Fatalf("want checks %q, got %q", wantChecks, met.checks)
+	}
+	want := `{"lvl":"info","step":"first_step","msg":"some checks failed"}
+{"lvl":"info","step":"first_step","msg":"all checks passed"}
+{"lvl":"info","step":"second_step","msg":"still running"}
+`
+	if got := buf.String(); want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+
+	for _, script := range []string{
+		`step.s = function() check(1) end`,
+		`step.s = function() check(1, {"not named"}) end`,
+		`step.s = function() check(1, {named = true}) end`,
+	} {
+		if _, err := runLogs(t, script); err == nil {
+			t.Errorf("%s: want an error", script)
+		}
+	}
+}
+
+func TestLuaCustomMetrics(t *testing.T) {
+	script := strings.NewReader(`
+local cart = metrics.counter("cart_items")
+local depth = metrics.gauge("queue_depth")
+local ok = metrics.rate("login_ok")
+local wait = metrics.trend("queue_wait_ms")
+
+step.first_step = function()
+    cart:add(3, {shop = "eu", page = 2})
+    cart:add(1)
+    depth:set(12.5)
+    ok:add(true)
+    ok:add(nil)
+    wait:add(250, {queue = "checkout"})
+    if metrics.counter("cart_items") then
+        metrics.counter("cart_items"):add(2)
+    end
+end
+`)
+
+	met := &recordMetrics{}
+	prgm, err := engine.Lua(script, engine.SetMetricReporter(met))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"counter cart_items 3 map[page:2 shop:eu]",
+		"counter cart_items 1 map[]",
+		"gauge queue_depth 12.5 map[]",
+		"rate login_ok 1 map[]",
+		"rate login_ok 0 map[]",
+		"trend queue_wait_ms 250 map[queue:checkout]",
+		"counter cart_items 2 map[]",
+	}
+	if fmt.Sprint(want) != fmt.Sprint(met.custom) {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", met.custom)
+		t.Fatalf("different metrics")
+	}
+
+	for _, script := range []string{
+		`metrics.counter("cart items")`,
+		`metrics.counter("RequestTable")`,
+		`metrics.counter("x") metrics.gauge("x")`,
+		`step.s = function() metrics.gauge("x"):add(1) end`,
+		`step.s = function() metrics.counter("x"):set(1) end`,
+		`step.s = function() metrics.trend("x"):add("slow") end`,
+		`step.s = function() metrics.counter("x"):add(1, {[1] = "a"}) end`,
+		`step.s = function() metrics.counter("x"):add(1, {a = {}}) end`,
+	} {
+		prgm, err := engine.Lua(strings.NewReader(script))
+		if err == nil {
+			err = prgm.Execute(context.Background())
+		}
+		if err == nil {
+			t.Errorf("%s: want an error", script)
+		}
+	}
+
+	for _, script := range []string{
+		`step.s = function() metrics.counter("x"):add(0/0) end`,
+		`step.s = function() metrics.trend("x"):add(-1/0) end`,
+		`step.s = function() metrics.gauge("x"):set(1/0) end`,
+	} {
+		prgm, err := engine.Lua(strings.NewReader(script))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := prgm.Execute(context.Background()); err == nil || !strings.Contains(err.Error(), "must be a finite number") {
+			t.Errorf("%s: want an error about the value, got %v", script, err)
+		}
+	}
+}
+
+func TestLuaHooks(t *testing.T) {
+	script := `
+local runs = 0
+
+function setup()
+    info("setting up")
+    return {token = "abc", ids = {1, 2}}
+end
+
+function init(data)
+    info("worker has " .. data.token)
+end
+
+step.first_step = function(prev, data)
+    runs = runs + 1
+    info(data.token .. " " .. data.ids[2] .. " run " .. runs)
+end
+
+function finish(data)
+    info("worker ran " .. runs .. " times")
+end
+
+function teardown(data)
+    info("tearing down " .. data.token)
+end
+`
+	buf := bytes.NewBuffer(nil)
+	setup, err := engine.Lua(strings.NewReader(script), engine.SetLogger(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := setup.Setup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"ids":[1,2],"token":"abc"}`; string(data) != want {
+		t.Fatalf("want setup data %s, got %s", want, data)
+	}
+
+	// the steps usually run in other programs, like on the executors
+	prgm, err := engine.Lua(strings.NewReader(script), engine.SetLogger(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.SetSetupData(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := prgm.Execute(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := prgm.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if err := setup.SetSetupData(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := setup.Teardown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"lvl":"info","step":"setup","msg":"setting up"}
+{"lvl":"info","step":"init","msg":"worker has abc"}
+{"lvl":"info","step":"first_step","msg":"abc 2 run 1"}
+{"lvl":"info","step":"first_step","msg":"abc 2 run 2"}
+{"lvl":"info","step":"finish","msg":"worker ran 2 times"}
+{"lvl":"info","step":"teardown","msg":"tearing down abc"}
+`
+	if got := buf.String(); want != got {
+		t.Logf("want=%q", want)
+		t.Logf(" got=%q", got)
+		t.Fatalf("different output")
+	}
+}
+
+func TestLuaNoHooks(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	prgm, err := engine.Lua(strings.NewReader(`
+step.first_step = function(prev, data)
+    if not data then
+        info("no data")
+    end
+end
+`), engine.SetLogger(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := prgm.Setup(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "null" {
+		t.Fatalf("want null setup data, got %s", data)
+	}
+	if err := prgm.SetSetupData(data); err != nil {
+		t.Fatal(err)
+	}
+	for _, hook := range []func() error{prgm.Init, prgm.Finish} {
+		if err := hook(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := prgm.Teardown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := prgm.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := `{"lvl":"info","step":"first_step","msg":"no data"}`+"\n", buf.String(); want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+
+	for _, script := range []string{
+		`function setup() local fixtures; return fixtures.id end`,
+		`function setup() return {f = function() end} end`,
+	} {
+		prgm, err := engine.Lua(strings.NewReader(script))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := prgm.Setup(context.Background()); err == nil {
+			t.Errorf("%s: want an error", script)
+		}
+	}
+	if err := prgm.SetSetupData([]byte("{")); err == nil {
+		t.Error("want an error for setup data that isn't JSON")
+	}
+}
+
+func TestLuaHooksTimeout(t *testing.T) {
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer srv.Close()
+	defer close(hang)
+
+	// Hooks are stopped whether they loop or wait for a request
+	for _, script := range []string{
+		`function setup() while true do end end`,
+		fmt.Sprintf(`function setup() get(%q) end`, srv.URL),
+		`function teardown() local n = 0; while true do n = n + 1 end end`,
+	} {
+		prgm, err := engine.Lua(strings.NewReader(script))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err = prgm.Setup(ctx)
+		if err == nil {
+			err = prgm.Teardown(ctx)
+		}
+		cancel()
+		if err == nil || !strings.Contains(err.Error(), "deadline exceeded") && !strings.Contains(err.Error(), "(timeout)") {
+			t.Errorf("%s: want the hook stopped, got %v", script, err)
+		}
+		// The steps aren't stopped by the context of the hooks
+		if err := prgm.Execute(context.Background()); err != nil {
+			t.Errorf("%s: %v", script, err)
+		}
+	}
+}
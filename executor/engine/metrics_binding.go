@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/Shopify/go-lua"
+)
+
+// The kinds of custom metrics a script can declare
+const (
+	// MetricCounter adds up its values
+	MetricCounter = "counter"
+	// MetricGauge keeps the last value it was set to
+	MetricGauge = "gauge"
+	// MetricRate counts how often its values were true
+	MetricRate = "rate"
+	// MetricTrend summarizes the distribution of its values
+	MetricTrend = "trend"
+)
+
+const customMetricMetaTable = "customMetric"
+
+// Custom metrics are persisted as their own measurements, their names can't
+// be those of the built-in ones which all end with "Table"
+var metricName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type customMetric struct {
+	name string
+	kind string
+}
+
+// metricsFunctions are the members of the `metrics` table, which declare
+// custom metrics:
+//
+//	local cart = metrics.counter("cart_items")
+//	cart:add(3, {shop = "eu"})
+//
+// Counters, rates and trends are updated with `add`, gauges with `set`. The
+// tags are optional.
+func (prgm *LuaProgram) metricsFunctions() []lua.RegistryFunction {
+	declare := func(kind string) lua.Function {
+		return func(l *lua.State) int {
+			return prgm.declareMetric(l, kind)
+		}
+	}
+	return []lua.RegistryFunction{
+		{Name: MetricCounter, Function: declare(MetricCounter)},
+		{Name: MetricGauge, Function: declare(MetricGauge)},
+		{Name: MetricRate, Function: declare(MetricRate)},
+		{Name: MetricTrend, Function: declare(MetricTrend)},
+	}
+}
+
+// declareMetric returns a metric. Declaring the same name twice returns
+// the same metric, but it can't change kind.
+func (prgm *LuaProgram) declareMetric(l *lua.State, kind string) int {
+	name := lua.CheckString(l, 1)
+	if !metricName.MatchString(name) || strings.HasSuffix(name, "Table") {
+		lua.ArgumentError(l, 1, "metric names are letters, digits and underscores, and don't end with Table")
+		return 0
+	}
+	if declared, ok := prgm.metricKinds[name]; ok && declared != kind {
+		lua.Errorf(l, "%s", fmt.Sprintf("metric %q is already a %s", name, declared))
+		return 0
+	}
+	prgm.metricKinds[name] = kind
+
+	l.PushUserData(&customMetric{name: name, kind: kind})
+	if lua.NewMetaTable(l, customMetricMetaTable) {
+		l.PushValue(-1)
+		l.SetField(-2, "__index")
+		lua.SetFunctions(l, []lua.RegistryFunction{
+			{Name: "add", Function: prgm.metricAdd},
+			{Name: "set", Function: prgm.metricSet},
+		}, 0)
+	}
+	l.SetMetaTable(-2)
+	return 1
+}
+
+// metricAdd updates a counter or a trend with a number, or a rate with a
+// boolean.
+func (prgm *LuaProgram) metricAdd(l *lua.State) int {
+	m := lua.CheckUserData(l, 1, customMetricMetaTable).(*customMetric)
+	var value float64
+	switch m.kind {
+	case MetricGauge:
+		lua.Errorf(l, "%s", fmt.Sprintf("metric %q is a gauge, use set", m.name))
+		return 0
+	case MetricRate:
+		lua.CheckAny(l, 2)
+		if l.ToBoolean(2) {
+			value = 1
+		}
+	default:
+		value = checkFinite(l, 2)
+	}
+	prgm.metrics.IncrCustomMetric(m.name, m.kind, value, checkTags(l, 3))
+	return 0
+}
+
+// metricSet sets a gauge to a number.
+func (prgm *LuaProgram) metricSet(l *lua.State) int {
+	m := lua.CheckUserData(l, 1, customMetricMetaTable).(*customMetric)
+	if m.kind != MetricGauge {
+		lua.Errorf(l, "%s", fmt.Sprintf("metric %q is a %s, use add", m.name, m.kind))
+		return 0
+	}
+	value := checkFinite(l, 2)
+	prgm.metrics.IncrCustomMetric(m.name, m.kind, value, checkTags(l, 3))
+	return 0
+}
+
+// checkFinite reads the number at `index`, NaN and infinities can't be
+// stored nor aggregated.
+func checkFinite(l *lua.State, index int) float64 {
+	value := lua.CheckNumber(l, index)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		lua.ArgumentError(l, index, "must be a finite number")
+	}
+	return value
+}
+
+// checkTags reads the optional table of tags at `index`, its values can be
+// strings or numbers.
+func checkTags(l *lua.State, index int) map[string]string {
+	if l.IsNoneOrNil(index) {
+		return nil
+	}
+	lua.CheckType(l, index, lua.TypeTable)
+	tags := make(map[string]string)
+	l.PushNil()
+	for l.Next(index) {
+		// converting a number key in place would confuse Next
+		if l.TypeOf(-2) != lua.TypeString {
+			lua.ArgumentError(l, index, "tag names must be strings")
+			return nil
+		}
+		name, _ := l.ToString(-2)
+		value, ok := l.ToString(-1)
+		if !ok {
+			lua.ArgumentError(l, index, "tag "+name+" must be a string or a number")
+			return nil
+		}
+		tags[name] = value
+		l.Pop(1)
+	}
+	return tags
+}
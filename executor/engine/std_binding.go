@@ -0,0 +1,258 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/Shopify/go-lua"
+)
+
+// How deep tables can be nested when encoding them, deeper ones are likely
+// to refer to themselves
+const maxEncodeDepth = 64
+
+// stdLibrary are the helpers every script can use, by the name of their table
+var stdLibrary = map[string][]lua.RegistryFunction{
+	"json": {
+		{Name: "encode", Function: jsonEncode},
+		{Name: "decode", Function: jsonDecode},
+	},
+	"url": {
+		{Name: "encode", Function: urlEncode},
+		{Name: "parse", Function: urlParse},
+	},
+	"base64": {
+		{Name: "encode", Function: base64Encode},
+		{Name: "decode", Function: base64Decode},
+	},
+	"crypto": {
+		{Name: "sha256", Function: cryptoSHA256},
+		{Name: "hmac", Function: cryptoHMAC},
+	},
+}
+
+func registerStdLibrary(l *lua.State) {
+	for name, functions := range stdLibrary {
+		lua.NewLibrary(l, functions)
+		l.SetGlobal(name)
+	}
+	l.Register("uuid", newUUID)
+	l.Register("now", timeNow)
+}
+
+// jsonEncode returns the JSON of a value. Tables that are sequences become
+// arrays, the others objects.
+func jsonEncode(l *lua.State) int {
+	lua.CheckAny(l, 1)
+	val, err := goValue(l, 1, 0)
+	if err != nil {
+		lua.Errorf(l, "lua-json: can't encode: %s", err.Error())
+		return 0
+	}
+	encoded, err := json.Marshal(val)
+	if err != nil {
+		lua.Errorf(l, "lua-json: can't encode: %s", err.Error())
+		return 0
+	}
+	l.PushString(string(encoded))
+	return 1
+}
+
+// jsonDecode returns the value of a JSON document, null becomes nil.
+func jsonDecode(l *lua.State) int {
+	data := lua.CheckString(l, 1)
+	var val interface{}
+	if err := json.Unmarshal([]byte(data), &val); err != nil {
+		lua.Errorf(l, "lua-json: can't decode: %s", err.Error())
+		return 0
+	}
+	if err := pushValue(l, "json", val); err != nil {
+		lua.Errorf(l, "lua-json: can't decode: %s", err.Error())
+		return 0
+	}
+	return 1
+}
+
+// goValue converts the value at `index` to what encoding/json expects.
+func goValue(l *lua.State, index, depth int) (interface{}, error) {
+	index = l.AbsIndex(index)
+	switch l.TypeOf(index) {
+	case lua.TypeNil:
+		return nil, nil
+	case lua.TypeBoolean:
+		return l.ToBoolean(index), nil
+	case lua.TypeNumber:
+		n, _ := l.ToNumber(index)
+		if math.IsInf(n, 0) || math.IsNaN(n) {
+			return nil, fmt.Errorf("%v has no JSON representation", n)
+		}
+		return n, nil
+	case lua.TypeString:
+		s, _ := l.ToString(index)
+		return s, nil
+	case lua.TypeTable:
+		if depth >= maxEncodeDepth {
+			return nil, fmt.Errorf("tables nested deeper than %d, is one of them in itself?", maxEncodeDepth)
+		}
+		return goTable(l, index, depth+1)
+	default:
+		return nil, fmt.Errorf("%s has no JSON representation", lua.TypeNameOf(l, index))
+	}
+}
+
+func goTable(l *lua.State, index, depth int) (interface{}, error) {
+	object := make(map[string]interface{})
+	array := make(map[int]interface{})
+	isArray := true
+	if !l.CheckStack(3) {
+		return nil, fmt.Errorf("tables nested too deep")
+	}
+	l.PushNil()
+	for l.Next(index) {
+		val, err := goValue(l, -1, depth)
+		if err != nil {
+			l.Pop(2)
+			return nil, err
+		}
+		l.Pop(1)
+		if l.TypeOf(-1) == lua.TypeNumber {
+			n, _ := l.ToNumber(-1)
+			if i := int(n); float64(i) == n && i > 0 {
+				array[i] = val
+				object[fmt.Sprint(i)] = val
+				continue
+			}
+		}
+		// convert a copy, converting the key in place would confuse `Next`
+		l.PushValue(-1)
+		key, ok := l.ToString(-1)
+		l.Pop(1)
+		if !ok {
+			err := fmt.Errorf("%s keys have no JSON representation", lua.TypeNameOf(l, -1))
+			l.Pop(1)
+			return nil, err
+		}
+		isArray = false
+		object[key] = val
+	}
+	// An empty table is taken for an object, sequences must have no holes
+	if !isArray || len(array) == 0 {
+		return object, nil
+	}
+	seq := make([]interface{}, len(array))
+	for i, val := range array {
+		if i > len(array) {
+			return object, nil
+		}
+		seq[i-1] = val
+	}
+	return seq, nil
+}
+
+// urlEncode escapes a string for a query, or encodes a table of parameters
+// into a query string, sorted by key.
+func urlEncode(l *lua.State) int {
+	if l.IsTable(1) {
+		query := make(url.Values)
+		forEachPair(l, 1, "parameters", query.Add)
+		l.PushString(query.Encode())
+		return 1
+	}
+	l.PushString(url.QueryEscape(lua.CheckString(l, 1)))
+	return 1
+}
+
+// urlParse splits an URL in a table of its parts, `query` has the first
+// value of every parameter.
+func urlParse(l *lua.State) int {
+	u, err := url.Parse(lua.CheckString(l, 1))
+	if err != nil {
+		lua.Errorf(l, "lua-url: can't parse: %s", err.Error())
+		return 0
+	}
+	l.NewTable()
+	for _, field := range []struct{ key, value string }{
+		{"scheme", u.Scheme},
+		{"host", u.Host},
+		{"hostname", u.Hostname()},
+		{"port", u.Port()},
+		{"path", u.Path},
+		{"raw_query", u.RawQuery},
+		{"fragment", u.Fragment},
+	} {
+		l.PushString(field.value)
+		l.SetField(-2, field.key)
+	}
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	l.NewTable()
+	for _, key := range keys {
+		l.PushString(query.Get(key))
+		l.SetField(-2, key)
+	}
+	l.SetField(-2, "query")
+	return 1
+}
+
+func base64Encode(l *lua.State) int {
+	l.PushString(base64.StdEncoding.EncodeToString([]byte(lua.CheckString(l, 1))))
+	return 1
+}
+
+func base64Decode(l *lua.State) int {
+	decoded, err := base64.StdEncoding.DecodeString(lua.CheckString(l, 1))
+	if err != nil {
+		lua.Errorf(l, "lua-base64: can't decode: %s", err.Error())
+		return 0
+	}
+	l.PushString(string(decoded))
+	return 1
+}
+
+// cryptoSHA256 returns the hex encoded SHA-256 of a string.
+func cryptoSHA256(l *lua.State) int {
+	sum := sha256.Sum256([]byte(lua.CheckString(l, 1)))
+	l.PushString(hex.EncodeToString(sum[:]))
+	return 1
+}
+
+// cryptoHMAC returns the hex encoded HMAC-SHA256 of a message:
+// crypto.hmac(key, message).
+func cryptoHMAC(l *lua.State) int {
+	mac := hmac.New(sha256.New, []byte(lua.CheckString(l, 1)))
+	mac.Write([]byte(lua.CheckString(l, 2)))
+	l.PushString(hex.EncodeToString(mac.Sum(nil)))
+	return 1
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID(l *lua.State) int {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		lua.Errorf(l, "lua-uuid: %s", err.Error())
+		return 0
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	l.PushString(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+	return 1
+}
+
+// timeNow returns the seconds since the epoch, with a fractional part.
+func timeNow(l *lua.State) int {
+	l.PushNumber(float64(time.Now().UnixNano()) / float64(time.Second))
+	return 1
+}
@@ -72,7 +72,7 @@ func TestValidLoggingCode(t *testing.T) {
 		time.Sleep(time.Millisecond * 1)
 	}
 
-	status, err := r.Recv()
+	status, err := recvStatus(r)
 	if err != nil {
 		t.Fatalf("Received error when executing: %v", err)
 	}
@@ -138,7 +138,7 @@ func TestValidGetCode(t *testing.T) {
 		timeMock.Add(time.Millisecond * 100)
 		time.Sleep(time.Millisecond * 1)
 	}
-	status, err := r.Recv()
+	status, snapshots, err := recvSnapshots(r)
 	if err != nil {
 		t.Fatalf("Received error when executing: %v", err)
 	}
@@ -159,11 +159,347 @@ func TestValidGetCode(t *testing.T) {
 		verifyResults(scriptId, t, gp.GetRequestContent)
 		// Make sure it got good responses
 		verifyResults(fmt.Sprintf("%s %d", srv.URL, 200), t, gp.GetRequestContent)
+		// The scheduler was told about them as they happened
+		var requests, steps int64
+		for _, snap := range snapshots {
+			requests += snap.Requests
+			for _, step := range snap.Steps {
+				if step.Name == "first_step" {
+					for _, count := range step.Latency.Counts {
+						steps += count
+					}
+				}
+			}
+		}
+		if requests == 0 || steps == 0 {
+			t.Errorf("Expected snapshots of the requests and steps, got %d requests and %d steps from %d snapshots", requests, steps, len(snapshots))
+		}
+		// Requests are persisted one by one unless rollups are asked for
+		if gp.PointCounts["GetRequestTable"] == 0 || gp.PointCounts["RequestRollupTable"] != 0 {
+			t.Errorf("Expected only raw requests, got %v", gp.PointCounts)
+		}
 	} else {
 		t.Fatalf("Received error when executing: %s", status.Status)
 	}
 }
 
+func TestOpenModelArrivalRate(t *testing.T) {
+	//TODO remove race condition for test cases
+	const rate, runTime = 50, 4
+
+	// run executes the open model against the handler, and returns how many
+	// executions started and how many were dropped
+	run := func(handler http.HandlerFunc, release func()) (int, int) {
+		gp := persister.TestPersister{}
+		timeMock := clock.NewMock()
+		sch, wg2 := startScheduler(t)
+		s, wg := startServer(t, &gp, timeMock, defaultPort)
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+
+		scriptId := fmt.Sprintf("%d", rand.Int63())
+		r, conn, err := sendMesage(&exgrpc.ScriptParams{
+			ScriptId:                  scriptId,
+			Url:                       srv.URL,
+			Script:                    fmt.Sprintf(goodGetScript, srv.URL),
+			RunTime:                   runTime,
+			MaxWorkers:                2,
+			GrowthFactor:              1,
+			TimeBetweenGrowth:         1,
+			StartingRequestsPerSecond: rate,
+			MaxRequestsPerSecond:      rate,
+			OpenModel:                 true,
+		}, defaultPort)
+		if err != nil {
+			t.Fatalf("Error from grpc: %v", err)
+		}
+		// The workers are let go before the end, or they couldn't be
+		// stopped
+		releaseTime := timeMock.Now().Add((runTime - 1) * time.Second)
+		doneTime := timeMock.Now().Add((runTime * time.Second) + time.Second)
+
+		// Make sure it doesn't deadlock
+		long := time.AfterFunc(time.Second*10, func() { panic("too long") })
+
+		// Mock time passage
+		for timeMock.Now().Before(doneTime) {
+			if release != nil && !timeMock.Now().Before(releaseTime) {
+				release()
+				release = nil
+			}
+			timeMock.Add(time.Millisecond * 100)
+			time.Sleep(time.Millisecond * 1)
+		}
+		status, err := recvStatus(r)
+		if err != nil {
+			t.Fatalf("Received error when executing: %v", err)
+		}
+		conn.Close()
+		sch.Stop()
+		s.Stop()
+		wg.Wait()
+		wg2.Wait()
+		long.Stop()
+
+		if status.Status != "OK" {
+			t.Fatalf("Received error when executing: %s", status.Status)
+		}
+		return gp.PointCounts["IterationTable"], gp.DroppedIterations
+	}
+
+	fast := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test"))
+	}
+	// The slow target holds every request until it's released, so the
+	// workers can't keep up
+	hold := make(chan struct{})
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		<-hold
+		w.Write([]byte("test"))
+	}
+
+	// The jobs still queued when the run ends are neither started nor
+	// dropped, and the last tick can race the end of the run
+	want := rate * runTime
+	margin := 3 * rate / 10
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		release func()
+	}{
+		{"fast target", fast, nil},
+		{"slow target", slow, func() { close(hold) }},
+	} {
+		started, dropped := run(tt.handler, tt.release)
+		if arrivals := started + dropped; arrivals < want-margin || arrivals > want+margin/3 {
+			t.Errorf("%s: want about %d executions started or dropped, got %d (%d started, %d dropped)", tt.name, want, arrivals, started, dropped)
+		}
+		if tt.release != nil && dropped == 0 {
+			t.Errorf("%s: expected executions to be dropped", tt.name)
+		}
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	//TODO remove race condition for test cases
+	gp := persister.TestPersister{}
+
+	timeMock := clock.NewMock()
+	sch, wg2 := startScheduler(t)
+	s, wg := startServer(t, &gp, timeMock, defaultPort)
+	var mu sync.Mutex
+	numReq := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		numReq++
+		mu.Unlock()
+		w.Write([]byte("test"))
+	}))
+	defer srv.Close()
+
+	script := fmt.Sprintf(goodGetScript, srv.URL)
+
+	scriptId := fmt.Sprintf("%d", rand.Int63())
+	r, conn, err := sendMesage(&exgrpc.ScriptParams{
+		ScriptId:             scriptId,
+		Url:                  srv.URL,
+		Script:               script,
+		MaxWorkers:           3,
+		MaxRequestsPerSecond: 100,
+		Stages: []*exgrpc.Stage{
+			{Duration: 2, TargetRequestsPerSecond: 0, Interpolation: exgrpc.Stage_STEP},
+			{Duration: 1, TargetRequestsPerSecond: 100},
+			{Duration: 2, TargetRequestsPerSecond: 100, Interpolation: exgrpc.Stage_STEP},
+		},
+	}, defaultPort)
+	if err != nil {
+		t.Fatalf("Error from grpc: %v", err)
+	}
+
+	// Nothing is due during the first stage
+	for i := 0; i < 15; i++ {
+		timeMock.Add(time.Millisecond * 100)
+		time.Sleep(time.Millisecond * 1)
+	}
+	time.Sleep(time.Millisecond * 50)
+	mu.Lock()
+	if numReq != 0 {
+		t.Errorf("Expected no requests during the first stage, got %d", numReq)
+	}
+	mu.Unlock()
+
+	// The stages last 5 seconds rather than a run time
+	doneTime := timeMock.Now().Add((4 * time.Second) + time.Second)
+
+	// Make sure it doesn't deadlock
+	long := time.AfterFunc(time.Second*10, func() { panic("too long") })
+
+	// Mock time passage
+	for timeMock.Now().Before(doneTime) {
+		timeMock.Add(time.Millisecond * 100)
+		time.Sleep(time.Millisecond * 1)
+	}
+	status, err := recvStatus(r)
+	if err != nil {
+		t.Fatalf("Received error when executing: %v", err)
+	}
+	conn.Close()
+	// Stop the server and wait for the executor stop finish
+	sch.Stop()
+	s.Stop()
+	wg.Wait()
+	wg2.Wait()
+	long.Stop()
+
+	if status.Status != "OK" {
+		t.Fatalf("Received error when executing: %s", status.Status)
+	}
+	if numReq == 0 {
+		t.Fatal("Received no get requests from script")
+	}
+	verifyResults(fmt.Sprintf("%s %d", srv.URL, 200), t, gp.GetRequestContent)
+	// Every request was persisted as is
+	if gp.PointCounts["GetRequestTable"] == 0 || gp.PointCounts["RequestRollupTable"] != 0 {
+		t.Errorf("Expected only raw requests, got %v", gp.PointCounts)
+	}
+}
+
+func TestLoadProfileLowRate(t *testing.T) {
+	//TODO remove race condition for test cases
+	gp := persister.TestPersister{}
+
+	timeMock := clock.NewMock()
+	sch, wg2 := startScheduler(t)
+	s, wg := startServer(t, &gp, timeMock, defaultPort)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test"))
+	}))
+	defer srv.Close()
+
+	// A ramp to 15 rps, then 15 rps: under a job per tick all along
+	r, conn, err := sendMesage(&exgrpc.ScriptParams{
+		ScriptId:             fmt.Sprintf("%d", rand.Int63()),
+		Url:                  srv.URL,
+		Script:               fmt.Sprintf(goodGetScript, srv.URL),
+		MaxWorkers:           3,
+		MaxRequestsPerSecond: 15,
+		Stages: []*exgrpc.Stage{
+			{Duration: 4, TargetRequestsPerSecond: 15},
+			{Duration: 2, TargetRequestsPerSecond: 15, Interpolation: exgrpc.Stage_STEP},
+		},
+	}, defaultPort)
+	if err != nil {
+		t.Fatalf("Error from grpc: %v", err)
+	}
+
+	doneTime := timeMock.Now().Add(7 * time.Second)
+	long := time.AfterFunc(time.Second*10, func() { panic("too long") })
+	for timeMock.Now().Before(doneTime) {
+		timeMock.Add(time.Millisecond * 100)
+		time.Sleep(time.Millisecond * 1)
+	}
+	status, err := recvStatus(r)
+	if err != nil {
+		t.Fatalf("Received error when executing: %v", err)
+	}
+	conn.Close()
+	sch.Stop()
+	s.Stop()
+	wg.Wait()
+	wg2.Wait()
+	long.Stop()
+
+	if status.Status != "OK" {
+		t.Fatalf("Received error when executing: %s", status.Status)
+	}
+	// 30 executions during the ramp, 30 after it. The last tick can race
+	// the end of the run
+	if executions := gp.PointCounts["IterationTable"]; executions < 58 || executions > 61 {
+		t.Errorf("want about 60 executions, got %d", executions)
+	}
+}
+
+func TestScenarios(t *testing.T) {
+	gp := persister.TestPersister{}
+
+	timeMock := clock.NewMock()
+	sch, wg2 := startScheduler(t)
+	s, wg := startServer(t, &gp, timeMock, defaultPort)
+	var mu sync.Mutex
+	numReq := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		numReq[r.URL.Path]++
+		mu.Unlock()
+		w.Write([]byte("test"))
+	}))
+	defer srv.Close()
+
+	scenario := func(name string, rate, workers int32) *exgrpc.Scenario {
+		return &exgrpc.Scenario{
+			Name: name,
+			Params: &exgrpc.ScriptParams{
+				ScriptId:                  name,
+				Url:                       srv.URL,
+				Script:                    fmt.Sprintf(goodGetScript, srv.URL+"/"+name),
+				RunTime:                   3,
+				MaxWorkers:                workers,
+				GrowthFactor:              1,
+				TimeBetweenGrowth:         1,
+				StartingRequestsPerSecond: rate,
+				MaxRequestsPerSecond:      rate,
+			},
+		}
+	}
+	r, conn, err := sendMesage(&exgrpc.ScriptParams{
+		ScriptId: "scenarios",
+		Url:      srv.URL,
+		RunTime:  3,
+		// A weighted scenario with most of the rate, and one with a fixed
+		// rate of its own
+		Scenarios: []*exgrpc.Scenario{
+			scenario("browse", 60, 3),
+			scenario("checkout", 20, 2),
+		},
+	}, defaultPort)
+	if err != nil {
+		t.Fatalf("Error from grpc: %v", err)
+	}
+
+	doneTime := timeMock.Now().Add(4 * time.Second)
+
+	// Make sure it doesn't deadlock
+	long := time.AfterFunc(time.Second*10, func() { panic("too long") })
+
+	// Mock time passage
+	for timeMock.Now().Before(doneTime) {
+		timeMock.Add(time.Millisecond * 100)
+		time.Sleep(time.Millisecond * 1)
+	}
+	status, err := recvStatus(r)
+	if err != nil {
+		t.Fatalf("Received error when executing: %v", err)
+	}
+	conn.Close()
+	sch.Stop()
+	s.Stop()
+	wg.Wait()
+	wg2.Wait()
+	long.Stop()
+
+	if status.Status != "OK" {
+		t.Fatalf("Received error when executing: %s", status.Status)
+	}
+	// Every scenario runs side by side, at its own rate
+	browse, checkout := numReq["/browse"], numReq["/checkout"]
+	if checkout == 0 || browse < 2*checkout {
+		t.Errorf("want about three times more requests browsing than checking out, got %d and %d", browse, checkout)
+	}
+	if browse > 60*3 || checkout > 20*3 {
+		t.Errorf("want at most the rate of the scenarios, got %d and %d requests", browse, checkout)
+	}
+}
+
 func TestHalt(t *testing.T) {
 	//TODO remove race condition for test cases
 	gp := persister.TestPersister{}
@@ -218,7 +554,9 @@ func TestHalt(t *testing.T) {
 	// Make sure it had time to fully halt before contining
 	time.Sleep(time.Millisecond * 50)
 	// Get the current number of requests after the halt
+	gp.Lock()
 	numRequests := len(gp.GetRequestContent)
+	gp.Unlock()
 
 	// Continue Mock time passage
 	for timeMock.Now().Before(doneTime) {
@@ -226,7 +564,7 @@ func TestHalt(t *testing.T) {
 		time.Sleep(time.Millisecond * 1)
 	}
 
-	status, err := r.Recv()
+	status, err := recvStatus(r)
 	if err != nil {
 		t.Fatalf("Received error when executing: %v", err)
 	}
@@ -310,7 +648,9 @@ func TestDisconnect(t *testing.T) {
 	time.Sleep(time.Millisecond * 50)
 	log.Println("Connection closed")
 	// Get the current number of requests after the halt
+	gp.Lock()
 	numRequests := len(gp.GetRequestContent)
+	gp.Unlock()
 
 	// Continue Mock time passage
 	for timeMock.Now().Before(doneTime) {
@@ -318,7 +658,7 @@ func TestDisconnect(t *testing.T) {
 		time.Sleep(time.Millisecond * 1)
 	}
 
-	_, err = r.Recv()
+	_, err = recvStatus(r)
 	if err == nil {
 
 		t.Fatalf("Received no error when asking for response")
@@ -381,6 +721,248 @@ func TestInvalidCode(t *testing.T) {
 	wg2.Wait()
 }
 
+func TestProtocolV2(t *testing.T) {
+	gp := persister.TestPersister{}
+
+	timeMock := clock.NewMock()
+	sch, wg2 := startScheduler(t)
+	s, wg := startServer(t, &gp, timeMock, defaultPort)
+	r, conn, err := sendRun(&exgrpc.ScriptParams{
+		ScriptId:                  "v2",
+		Url:                       "http://localhost",
+		Script:                    goodLogScript,
+		RunTime:                   3,
+		MaxWorkers:                3,
+		GrowthFactor:              1.5,
+		TimeBetweenGrowth:         1,
+		StartingRequestsPerSecond: 15,
+		MaxRequestsPerSecond:      1000,
+	}, defaultPort)
+	if err != nil {
+		t.Fatalf("Error from grpc: %v", err)
+	}
+	long := time.AfterFunc(time.Second*10, func() { panic("too long") })
+
+	accepted, err := r.Recv()
+	if err != nil {
+		t.Fatalf("Received error when executing: %v", err)
+	}
+	if a := accepted.GetAccepted(); a == nil || a.ProtocolVersion != exgrpc.ProtocolVersion_V2 {
+		t.Fatalf("The run wasn't accepted in V2: %v", accepted)
+	}
+	started, err := r.Recv()
+	if err != nil {
+		t.Fatalf("Received error when executing: %v", err)
+	}
+	if started.GetStarted() == nil {
+		t.Fatalf("The run didn't start: %v", started)
+	}
+	// The execution is already running
+	r.Send(&exgrpc.CommandMessage{Kind: &exgrpc.CommandMessage_Run{Run: &exgrpc.Run{}}})
+
+	doneTime := timeMock.Now().Add((3 * time.Second) + time.Second)
+	for timeMock.Now().Before(doneTime) {
+		timeMock.Add(time.Millisecond * 100)
+		time.Sleep(time.Millisecond * 1)
+	}
+
+	var rejected *exgrpc.Rejected
+	var finished *exgrpc.Finished
+	var executions int64
+	for finished == nil {
+		status, err := r.Recv()
+		if err != nil {
+			t.Fatalf("Received error when executing: %v", err)
+		}
+		switch event := status.Event.(type) {
+		case *exgrpc.StatusMessage_Rejected:
+			rejected = event.Rejected
+		case *exgrpc.StatusMessage_Progress:
+			executions += event.Progress.Executions
+		case *exgrpc.StatusMessage_Finished:
+			finished = event.Finished
+		default:
+			t.Fatalf("Unexpected status: %v", status)
+		}
+	}
+	conn.Close()
+	sch.Stop()
+	s.Stop()
+	wg.Wait()
+	wg2.Wait()
+	long.Stop()
+
+	if rejected == nil || rejected.Code != exgrpc.ErrorCode_INVALID_COMMAND {
+		t.Errorf("The second run wasn't rejected: %v", rejected)
+	}
+	if finished.Halted {
+		t.Errorf("The execution was halted")
+	}
+	if finished.Summary == nil || finished.Summary.Executions == 0 {
+		t.Fatalf("The summary has no executions: %v", finished.Summary)
+	}
+	if finished.Summary.Executions != executions {
+		t.Errorf("The summary has %d executions, the snapshots %d", finished.Summary.Executions, executions)
+	}
+}
+
+func TestControl(t *testing.T) {
+	gp := persister.TestPersister{}
+
+	timeMock := clock.NewMock()
+	sch, wg2 := startScheduler(t)
+	s, wg := startServer(t, &gp, timeMock, defaultPort)
+	r, conn, err := sendRun(&exgrpc.ScriptParams{
+		ScriptId:                  "control",
+		Url:                       "http://localhost",
+		Script:                    goodLogScript,
+		RunTime:                   6,
+		MaxWorkers:                3,
+		GrowthFactor:              1,
+		TimeBetweenGrowth:         1,
+		StartingRequestsPerSecond: 50,
+		MaxRequestsPerSecond:      50,
+	}, defaultPort)
+	if err != nil {
+		t.Fatalf("Error from grpc: %v", err)
+	}
+	long := time.AfterFunc(time.Second*10, func() { panic("too long") })
+
+	// recv skips the progress, counting the executions
+	var executions int64
+	recv := func() *exgrpc.StatusMessage {
+		for {
+			status, err := r.Recv()
+			if err != nil {
+				t.Fatalf("Received error when executing: %v", err)
+			}
+			if progress := status.GetProgress(); progress != nil {
+				executions += progress.Executions
+				continue
+			}
+			return status
+		}
+	}
+	advance := func(d time.Duration) {
+		end := timeMock.Now().Add(d)
+		for timeMock.Now().Before(end) {
+			timeMock.Add(time.Millisecond * 100)
+			time.Sleep(time.Millisecond * 1)
+		}
+	}
+	if status := recv(); status.GetAccepted() == nil {
+		t.Fatalf("The run wasn't accepted: %v", status)
+	}
+	if status := recv(); status.GetStarted() == nil {
+		t.Fatalf("The run didn't start: %v", status)
+	}
+
+	r.Send(&exgrpc.CommandMessage{Kind: &exgrpc.CommandMessage_SetRate{SetRate: &exgrpc.SetRate{Workers: 4}}})
+	if status := recv(); status.GetRejected() == nil || status.GetRejected().Code != exgrpc.ErrorCode_INVALID_COMMAND {
+		t.Fatalf("More workers than the execution has weren't rejected: %v", status)
+	}
+	r.Send(&exgrpc.CommandMessage{Kind: &exgrpc.CommandMessage_Pause{Pause: &exgrpc.Pause{}}})
+	// The controls accepted are applied at the next tick
+	if status := recv(); status.GetAccepted() == nil {
+		t.Fatalf("The pause wasn't accepted: %v", status)
+	}
+	advance(2 * time.Second)
+	// The progress of the pause is read before the answer to the next command
+	r.Send(&exgrpc.CommandMessage{Kind: &exgrpc.CommandMessage_SetRate{SetRate: &exgrpc.SetRate{RequestsPerSecond: 20, Workers: 1}}})
+	if status := recv(); status.GetAccepted() == nil {
+		t.Fatalf("The rate wasn't accepted: %v", status)
+	}
+	if executions != 0 {
+		t.Errorf("%d executions while paused", executions)
+	}
+	r.Send(&exgrpc.CommandMessage{Kind: &exgrpc.CommandMessage_Resume{Resume: &exgrpc.Resume{}}})
+	if status := recv(); status.GetAccepted() == nil {
+		t.Fatalf("The resume wasn't accepted: %v", status)
+	}
+	advance(5 * time.Second)
+
+	finished := recv().GetFinished()
+	conn.Close()
+	sch.Stop()
+	s.Stop()
+	wg.Wait()
+	wg2.Wait()
+	long.Stop()
+
+	if finished == nil {
+		t.Fatalf("The execution didn't finish")
+	}
+	if executions == 0 {
+		t.Errorf("No execution once resumed")
+	}
+	// The rejected control isn't applied
+	if annotations := gp.PointCounts["AnnotationTable"]; annotations != 3 {
+		t.Errorf("%d annotations for 3 controls", annotations)
+	}
+}
+
+func TestProtocolV2InvalidCode(t *testing.T) {
+	gp := persister.TestPersister{}
+
+	timeMock := clock.NewMock()
+	sch, wg2 := startScheduler(t)
+	s, wg := startServer(t, &gp, timeMock, defaultPort)
+	r, conn, err := sendRun(&exgrpc.ScriptParams{
+		ScriptId:                  "v2",
+		Url:                       "http://localhost",
+		Script:                    badScript,
+		RunTime:                   2,
+		MaxWorkers:                3,
+		GrowthFactor:              1.5,
+		TimeBetweenGrowth:         1,
+		StartingRequestsPerSecond: 15,
+		MaxRequestsPerSecond:      1000,
+	}, defaultPort)
+	if err != nil {
+		t.Fatalf("Error from grpc: %v", err)
+	}
+
+	// The command is valid, the script isn't
+	accepted, err := r.Recv()
+	if err != nil || accepted.GetAccepted() == nil {
+		t.Fatalf("The run wasn't accepted: %v, %v", accepted, err)
+	}
+	status, err := r.Recv()
+	if err != nil {
+		t.Fatalf("Received error when executing: %v", err)
+	}
+	failed := status.GetFailed()
+	if failed == nil || failed.Code != exgrpc.ErrorCode_INVALID_SCRIPT ||
+		failed.Message != "compiling program: syntax error" {
+		t.Errorf("The execution didn't fail because of the script: %v", status)
+	}
+
+	conn.Close()
+	sch.Stop()
+	s.Stop()
+	wg.Wait()
+	wg2.Wait()
+}
+
+// recvStatus skips the snapshots sent during the execution.
+func recvStatus(r exgrpc.Commander_ExecuteCommandClient) (*exgrpc.StatusMessage, error) {
+	status, _, err := recvSnapshots(r)
+	return status, err
+}
+
+// recvSnapshots collects the snapshots sent during the execution until the
+// status is received.
+func recvSnapshots(r exgrpc.Commander_ExecuteCommandClient) (*exgrpc.StatusMessage, []*exgrpc.Snapshot, error) {
+	var snapshots []*exgrpc.Snapshot
+	for {
+		status, err := r.Recv()
+		if err != nil || status.Snapshot == nil {
+			return status, snapshots, err
+		}
+		snapshots = append(snapshots, status.Snapshot)
+	}
+}
+
 func verifyResults(server string, t *testing.T, content []string) {
 	if len(content) < 1 {
 		// attempt to wait for it, it might be slow
@@ -400,7 +982,7 @@ func verifyResults(server string, t *testing.T, content []string) {
 func startServer(t *testing.T, gp controller.Persister, timeMock clock.Clock, port int) (*grpc.Server, *sync.WaitGroup) {
 	// Loop forever, because I will wait for commands from the grpc server
 	wg := sync.WaitGroup{}
-	s, err := controller.NewGRPCExecutorStarter(gp, schedulerIP, port, dropletId, timeMock)
+	s, err := controller.NewGRPCExecutorStarter(gp, schedulerIP, "", "", port, dropletId, timeMock)
 	if err != nil {
 		t.Errorf("err starting grpc server %v", err)
 	}
@@ -415,7 +997,7 @@ func startServer(t *testing.T, gp controller.Persister, timeMock clock.Clock, po
 		defer wg.Done()
 		err := s.Serve(lis)
 		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
-			t.Fatalf("Grpc server had an error: %v", err)
+			t.Errorf("Grpc server had an error: %v", err)
 		}
 	}()
 	return s, &wg
@@ -435,7 +1017,7 @@ func startScheduler(t *testing.T) (*grpc.Server, *sync.WaitGroup) {
 		defer wg.Done()
 		err := s.Serve(lis)
 		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
-			t.Fatalf("Grpc server had an error: %v", err)
+			t.Errorf("Grpc server had an error: %v", err)
 		}
 	}()
 	return s, &wg
@@ -459,6 +1041,22 @@ func sendMesage(message *exgrpc.ScriptParams, port int) (exgrpc.Commander_Execut
 	return client, conn, err
 }
 
+// sendRun starts an execution with a V2 command.
+func sendRun(message *exgrpc.ScriptParams, port int) (exgrpc.Commander_ExecuteCommandClient, *grpc.ClientConn, error) {
+	conn, err := grpc.Dial(fmt.Sprintf("localhost:%d", port), grpc.WithTimeout(15*time.Second), grpc.WithInsecure())
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := exgrpc.NewCommanderClient(conn).ExecuteCommand(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	err = client.Send(&exgrpc.CommandMessage{Kind: &exgrpc.CommandMessage_Run{
+		Run: &exgrpc.Run{ScriptParams: message},
+	}})
+	return client, conn, err
+}
+
 type mockScheduler struct{}
 
 func (f *mockScheduler) RegisterExecutor(context.Context, *scheduler.RegisterExecutorReq) (*scheduler.RegisterExecutorResp, error) {
@@ -474,3 +1072,19 @@ func (f *mockScheduler) RegisterExecutor(context.Context, *scheduler.RegisterExe
 func (f *mockScheduler) LoadTest(in *scheduler.LoadTestReq, s scheduler.Scheduler_LoadTestServer) error {
 	return nil
 }
+
+func (f *mockScheduler) ControlLoadTest(context.Context, *scheduler.ControlLoadTestReq) (*scheduler.ControlLoadTestResp, error) {
+	return &scheduler.ControlLoadTestResp{}, nil
+}
+
+func (f *mockScheduler) CancelLoadTest(context.Context, *scheduler.CancelLoadTestReq) (*scheduler.CancelLoadTestResp, error) {
+	return &scheduler.CancelLoadTestResp{}, nil
+}
+
+func (f *mockScheduler) ListLoadTests(context.Context, *scheduler.ListLoadTestsReq) (*scheduler.ListLoadTestsResp, error) {
+	return &scheduler.ListLoadTestsResp{}, nil
+}
+
+func (f *mockScheduler) GetLoadTest(context.Context, *scheduler.GetLoadTestReq) (*scheduler.GetLoadTestResp, error) {
+	return &scheduler.GetLoadTestResp{}, nil
+}
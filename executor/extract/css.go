@@ -0,0 +1,280 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selector is a compiled CSS selector. The supported syntax is:
+//
+//	div, *                        elements by name, or any
+//	#login, .item                 by id and class
+//	[name], [name=csrf]           by attribute, also ^=, $=, *= and ~=
+//	form input, ul > li           descendants and children
+//	a.next, h1, h2                compounds and groups
+type Selector struct {
+	groups [][]cssStep
+}
+
+// cssStep is a compound selector, and how it relates to the one before it.
+type cssStep struct {
+	// child is set for `>`, otherwise any ancestor matches
+	child   bool
+	name    string
+	id      string
+	classes []string
+	attrs   []cssAttr
+}
+
+type cssAttr struct {
+	name, op, value string
+}
+
+// CompileCSS parses a selector.
+func CompileCSS(selector string) (*Selector, error) {
+	p := &cssParser{in: selector}
+	sel := &Selector{}
+	for {
+		group, err := p.group()
+		if err != nil {
+			return nil, fmt.Errorf("css selector %q: %v", selector, err)
+		}
+		sel.groups = append(sel.groups, group)
+		if p.eof() {
+			return sel, nil
+		}
+		p.pos++ // the comma
+	}
+}
+
+// MatchAll returns the elements under `root` matching the selector, in
+// document order.
+func (s *Selector) MatchAll(root *Node) []*Node {
+	var matches []*Node
+	root.descendants(func(n *Node) {
+		for _, group := range s.groups {
+			if matchSteps(n, group) {
+				matches = append(matches, n)
+				return
+			}
+		}
+	})
+	return matches
+}
+
+// matchSteps matches the last step against `n`, then the others against its
+// ancestors.
+func matchSteps(n *Node, steps []cssStep) bool {
+	last := steps[len(steps)-1]
+	if !last.matches(n) {
+		return false
+	}
+	if len(steps) == 1 {
+		return true
+	}
+	for parent := n.Parent; parent != nil && parent.IsElement(); parent = parent.Parent {
+		if matchSteps(parent, steps[:len(steps)-1]) {
+			return true
+		}
+		if last.child {
+			return false
+		}
+	}
+	return false
+}
+
+func (s cssStep) matches(n *Node) bool {
+	if s.name != "" && s.name != "*" && s.name != n.Name {
+		return false
+	}
+	if s.id != "" {
+		if id, _ := n.Attr("id"); id != s.id {
+			return false
+		}
+	}
+	classes, _ := n.Attr("class")
+	for _, class := range s.classes {
+		if !containsWord(classes, class) {
+			return false
+		}
+	}
+	for _, attr := range s.attrs {
+		value, ok := n.Attr(attr.name)
+		if !ok {
+			return false
+		}
+		switch attr.op {
+		case "=":
+			ok = value == attr.value
+		case "^=":
+			ok = strings.HasPrefix(value, attr.value)
+		case "$=":
+			ok = strings.HasSuffix(value, attr.value)
+		case "*=":
+			ok = strings.Contains(value, attr.value)
+		case "~=":
+			ok = containsWord(value, attr.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func containsWord(list, word string) bool {
+	for _, w := range strings.Fields(list) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+type cssParser struct {
+	in  string
+	pos int
+}
+
+func (p *cssParser) eof() bool { return p.pos >= len(p.in) }
+
+func (p *cssParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.in[p.pos]
+}
+
+func (p *cssParser) skipSpaces() bool {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\n", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// group parses the steps up to a comma or the end.
+func (p *cssParser) group() ([]cssStep, error) {
+	var steps []cssStep
+	child := false
+	p.skipSpaces()
+	for {
+		step, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		step.child = child
+		steps = append(steps, step)
+
+		spaced := p.skipSpaces()
+		switch {
+		case p.eof() || p.peek() == ',':
+			return steps, nil
+		case p.peek() == '>':
+			p.pos++
+			p.skipSpaces()
+			child = true
+		case spaced:
+			child = false
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+		}
+	}
+}
+
+func (p *cssParser) compound() (cssStep, error) {
+	var step cssStep
+	start := p.pos
+	if p.peek() == '*' {
+		p.pos++
+		step.name = "*"
+	} else {
+		step.name = strings.ToLower(p.ident())
+	}
+	for !p.eof() {
+		switch p.peek() {
+		case '#':
+			p.pos++
+			if step.id = p.ident(); step.id == "" {
+				return step, fmt.Errorf("missing id at %d", p.pos)
+			}
+		case '.':
+			p.pos++
+			class := p.ident()
+			if class == "" {
+				return step, fmt.Errorf("missing class at %d", p.pos)
+			}
+			step.classes = append(step.classes, class)
+		case '[':
+			p.pos++
+			attr, err := p.attr()
+			if err != nil {
+				return step, err
+			}
+			step.attrs = append(step.attrs, attr)
+		default:
+			if p.pos == start {
+				return step, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+			}
+			return step, nil
+		}
+	}
+	if p.pos == start {
+		return step, fmt.Errorf("missing selector at %d", p.pos)
+	}
+	return step, nil
+}
+
+// attr parses what follows `[`, up to and including `]`.
+func (p *cssParser) attr() (cssAttr, error) {
+	p.skipSpaces()
+	attr := cssAttr{name: strings.ToLower(p.ident())}
+	if attr.name == "" {
+		return attr, fmt.Errorf("missing attribute name at %d", p.pos)
+	}
+	p.skipSpaces()
+	if p.peek() == ']' {
+		p.pos++
+		return attr, nil
+	}
+	for _, op := range []string{"=", "^=", "$=", "*=", "~="} {
+		if strings.HasPrefix(p.in[p.pos:], op) {
+			attr.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if attr.op == "" {
+		return attr, fmt.Errorf("unknown attribute operator at %d", p.pos)
+	}
+	p.skipSpaces()
+	if q := p.peek(); q == '"' || q == '\'' {
+		end := strings.IndexByte(p.in[p.pos+1:], q)
+		if end < 0 {
+			return attr, fmt.Errorf("unterminated string at %d", p.pos)
+		}
+		attr.value = p.in[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	} else {
+		attr.value = p.ident()
+	}
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return attr, fmt.Errorf("missing ] at %d", p.pos)
+	}
+	p.pos++
+	return attr, nil
+}
+
+func (p *cssParser) ident() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.in[start:p.pos]
+}
@@ -0,0 +1,159 @@
+// Package extract finds values in response bodies: with JSONPath in JSON,
+// and with CSS selectors or XPath in HTML and XML.
+package extract
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Node is an element, or a piece of text, of a parsed document.
+type Node struct {
+	// Name is the lower case name of an element, "#text" for text and
+	// "#document" for the root of a document
+	Name     string
+	Attrs    []xml.Attr
+	Text     string
+	Parent   *Node
+	Children []*Node
+}
+
+// The contents of these elements aren't markup, and likely to confuse the
+// parser
+var (
+	scripts = regexp.MustCompile(`(?is)<script\b[^>]*>.*?</script\s*>`)
+	styles  = regexp.MustCompile(`(?is)<style\b[^>]*>.*?</style\s*>`)
+)
+
+// impliedEnd lists, for the elements HTML lets authors leave open, the open
+// elements a new one closes
+var impliedEnd = map[string][]string{
+	"li":     {"li"},
+	"p":      {"p"},
+	"option": {"option"},
+	"dt":     {"dt", "dd"},
+	"dd":     {"dt", "dd"},
+	"tr":     {"tr", "td", "th"},
+	"td":     {"td", "th"},
+	"th":     {"td", "th"},
+}
+
+// ParseHTML parses an HTML or XML document leniently: elements that aren't
+// closed are closed by their parent, and whatever comes after markup that
+// can't be parsed at all is ignored. It never fails, at worst the document
+// is empty.
+func ParseHTML(data []byte) *Node {
+	data = scripts.ReplaceAll(data, nil)
+	data = styles.ReplaceAll(data, nil)
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	root := &Node{Name: "#document"}
+	current := root
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return root
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(tok.Name.Local)
+			for closes(impliedEnd[name], current.Name) {
+				current = current.Parent
+			}
+			n := &Node{Name: name, Parent: current}
+			for _, attr := range tok.Attr {
+				attr.Name.Local = strings.ToLower(attr.Name.Local)
+				n.Attrs = append(n.Attrs, attr)
+			}
+			current.Children = append(current.Children, n)
+			current = n
+		case xml.EndElement:
+			// the decoder closes what was left open itself, but doesn't
+			// know about the elements closed above: end tags without an
+			// open element are ignored
+			name := strings.ToLower(tok.Name.Local)
+			for n := current; n.Parent != nil; n = n.Parent {
+				if n.Name == name {
+					current = n.Parent
+					break
+				}
+			}
+		case xml.CharData:
+			current.Children = append(current.Children, &Node{
+				Name:   "#text",
+				Text:   string(tok),
+				Parent: current,
+			})
+		}
+	}
+}
+
+func closes(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsElement tells if the node is an element, rather than text or the root.
+func (n *Node) IsElement() bool {
+	return !strings.HasPrefix(n.Name, "#")
+}
+
+// Attr returns the value of an attribute of the node.
+func (n *Node) Attr(name string) (string, bool) {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// TextContent returns the text of the node and of all its descendants, with
+// the surrounding whitespace trimmed.
+func (n *Node) TextContent() string {
+	var buf bytes.Buffer
+	var walk func(*Node)
+	walk = func(n *Node) {
+		if n.Name == "#text" {
+			buf.WriteString(n.Text)
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(buf.String())
+}
+
+// elements returns the element children of the node.
+func (n *Node) elements() []*Node {
+	var elems []*Node
+	for _, child := range n.Children {
+		if child.IsElement() {
+			elems = append(elems, child)
+		}
+	}
+	return elems
+}
+
+// descendants calls fn with every element under the node, in document order.
+func (n *Node) descendants(fn func(*Node)) {
+	for _, child := range n.Children {
+		if child.IsElement() {
+			fn(child)
+			child.descendants(fn)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package extract_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lgpeterson/loadtests/executor/extract"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Items &amp; more</title>
+  <script>if (a < b && c > d) { document.write("<p>no</p>") }</script>
+</head>
+<body>
+  <form id="login" action="/login">
+    <input type="hidden" name=csrf value="tok123">
+    <input name="user" class="field wide" disabled>
+    <br>
+  </form>
+  <ul class="items">
+    <li><a href="/items/1">One</a>
+    <li class="current"><a href="/items/2" rel="next">Two</a>
+    <li><a href="/items/3">Three</a>
+  </ul>
+  <p>Contact: <b>us</b>&nbsp;today</p>
+</body>
+</html>`
+
+func TestCSS(t *testing.T) {
+	doc := extract.ParseHTML([]byte(page))
+	for _, tt := range []struct {
+		selector string
+		want     []string
+	}{
+		{"title", []string{"Items & more"}},
+		{"input[name=csrf]", []string{""}},
+		{"#login input", []string{"", ""}},
+		{"form > input.wide", []string{""}},
+		{"ul.items li", []string{"One", "Two", "Three"}},
+		{"li.current a[rel=next]", []string{"Two"}},
+		{"a[href^='/items/'][href$='3']", []string{"Three"}},
+		{"body > a", nil},
+		{"b, title", []string{"Items & more", "us"}},
+		{"p", []string{"Contact: us today"}},
+		{"*[class~=field]", []string{""}},
+	} {
+		sel, err := extract.CompileCSS(tt.selector)
+		if err != nil {
+			t.Errorf("%s: %v", tt.selector, err)
+			continue
+		}
+		var got []string
+		for _, n := range sel.MatchAll(doc) {
+			got = append(got, n.TextContent())
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.selector, tt.want, got)
+		}
+	}
+
+	for _, selector := range []string{"", "a,", "a[href", "a[href!=x]", "#", "a >"} {
+		if _, err := extract.CompileCSS(selector); err == nil {
+			t.Errorf("%q: want an error", selector)
+		}
+	}
+}
+
+func TestXPath(t *testing.T) {
+	doc := extract.ParseHTML([]byte(page))
+	for _, tt := range []struct {
+		expr string
+		want []string
+	}{
+		{"//input[@name='csrf']/@value", []string{"tok123"}},
+		{"/html/head/title", []string{"Items & more"}},
+		{"//li[2]/a/@href", []string{"/items/2"}},
+		{"//li[last()]", []string{"Three"}},
+		{"//a[@rel]", []string{"Two"}},
+		{"//input[contains(@class, 'wide')]/@name", []string{"user"}},
+		{"//ul/*[1]/a/text()", []string{"One"}},
+		{"//p/text()", []string{"Contact:", "today"}},
+		{"a", []string{"One", "Two", "Three"}},
+		{"//table", nil},
+	} {
+		x, err := extract.CompileXPath(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := x.Evaluate(doc); fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s: want %q, got %q", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{"//a[", "//a[0]", "//@href/b", "//a[@href=x]", "//a[position() > 1]"} {
+		if _, err := extract.CompileXPath(expr); err == nil {
+			t.Errorf("%q: want an error", expr)
+		}
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	doc := []byte(`{
+		"items": [
+			{"id": 1, "name": "one", "tags": ["a"]},
+			{"id": 2, "name": "two", "owner": {"id": 7}}
+		],
+		"total": 2
+	}`)
+	for _, tt := range []struct {
+		expr string
+		want string
+	}{
+		{"$.total", "[2]"},
+		{"$.items[0].id", "[1]"},
+		{"$['items'][-1].name", "[two]"},
+		{"$.items[*].name", "[one two]"},
+		{"$..id", "[1 2 7]"},
+		{"$.items[1].owner", "[map[id:7]]"},
+		{"$.items[5]", "[]"},
+		{"$.missing.id", "[]"},
+		{"$", "[map[items:[map[id:1 name:one tags:[a]] map[id:2 name:two owner:map[id:7]]] total:2]]"},
+	} {
+		p, err := extract.CompileJSONPath(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		got, err := p.Evaluate(doc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%s: want %s, got %v", tt.expr, tt.want, got)
+		}
+	}
+
+	for _, expr := range []string{"items", "$.", "$[", "$[1:2]"} {
+		if _, err := extract.CompileJSONPath(expr); err == nil {
+			t.Errorf("%q: want an error", expr)
+		}
+	}
+}
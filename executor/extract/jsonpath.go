@@ -0,0 +1,160 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a compiled JSONPath expression. The supported syntax is:
+//
+//	$                       the document
+//	.items, ['items']       a member
+//	[0], [-1]               an element, counting from the end if negative
+//	.*, [*]                 every member or element
+//	..id                    a member at any depth
+type JSONPath struct {
+	steps []jsonStep
+}
+
+type jsonStep struct {
+	recursive bool
+	// member is the name of a member, "*" for any member or element
+	member string
+	index  *int
+}
+
+// CompileJSONPath parses an expression.
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	rest := strings.TrimSpace(expr)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, fmt.Errorf("jsonpath %q: must start with $", expr)
+	}
+	rest = rest[1:]
+	p := &JSONPath{}
+	for rest != "" {
+		var step jsonStep
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(rest, "."):
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			step.member = rest[:end]
+			rest = rest[end:]
+			if step.member == "" {
+				return nil, fmt.Errorf("jsonpath %q: missing member name", expr)
+			}
+			p.steps = append(p.steps, step)
+			continue
+		case strings.HasPrefix(rest, "["):
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", expr, rest)
+		}
+
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("jsonpath %q: missing ]", expr)
+		}
+		inner := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+		if inner == "*" {
+			step.member = "*"
+		} else if name, err := unquote(inner); err == nil {
+			step.member = name
+		} else if i, err := strconv.Atoi(inner); err == nil {
+			step.index = &i
+		} else {
+			return nil, fmt.Errorf("jsonpath %q: unsupported subscript [%s]", expr, inner)
+		}
+		p.steps = append(p.steps, step)
+	}
+	return p, nil
+}
+
+// Evaluate returns the values the expression selects in a JSON document.
+func (p *JSONPath) Evaluate(data []byte) ([]interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	values := []interface{}{doc}
+	for _, step := range p.steps {
+		var next []interface{}
+		for _, v := range values {
+			if step.recursive {
+				walkJSON(v, func(v interface{}) { next = append(next, step.apply(v)...) })
+			} else {
+				next = append(next, step.apply(v)...)
+			}
+		}
+		values = next
+	}
+	return values, nil
+}
+
+func (s jsonStep) apply(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if s.member == "*" {
+			return members(v)
+		}
+		if member, ok := v[s.member]; ok && s.index == nil {
+			return []interface{}{member}
+		}
+	case []interface{}:
+		if s.member == "*" {
+			return v
+		}
+		if s.index != nil {
+			i := *s.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				return []interface{}{v[i]}
+			}
+		}
+	}
+	return nil
+}
+
+// members returns the values of an object, sorted by their names as JSON
+// objects have no order.
+func members(obj map[string]interface{}) []interface{} {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]interface{}, 0, len(obj))
+	for _, name := range names {
+		values = append(values, obj[name])
+	}
+	return values
+}
+
+// walkJSON calls fn with a value and everything it contains.
+func walkJSON(v interface{}, fn func(interface{})) {
+	fn(v)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, member := range members(v) {
+			walkJSON(member, fn)
+		}
+	case []interface{}:
+		for _, elem := range v {
+			walkJSON(elem, fn)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package extract
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XPath is a compiled XPath expression. The supported syntax is a location
+// path of steps:
+//
+//	/html/body, //a, //*          children and descendants by name, or any
+//	//a/@href, //h1/text()        an attribute, or the text, of the elements
+//	//li[2], //li[last()]         by position among the siblings
+//	//input[@name='csrf']         by attribute, or [@name] for its presence
+//	//div[contains(@class,'x')]   by part of an attribute
+//
+// Relative paths start from the root, as if they started with `//`.
+type XPath struct {
+	steps []xpathStep
+}
+
+type xpathStep struct {
+	// descendant is set for `//`, otherwise only children match
+	descendant bool
+	// name is an element name or "*", "@name" for an attribute, and
+	// "text()" for text
+	name       string
+	predicates []xpathPredicate
+}
+
+type xpathPredicate struct {
+	// position is set for [n], -1 for [last()]
+	position int
+	// attr is set when testing an attribute, with op being "" to test
+	// for its presence, "=" or "contains"
+	attr, op, value string
+}
+
+// CompileXPath parses an expression.
+func CompileXPath(expr string) (*XPath, error) {
+	x := &XPath{}
+	rest := strings.TrimSpace(expr)
+	if !strings.HasPrefix(rest, "/") {
+		rest = "//" + rest
+	}
+	for rest != "" {
+		var step xpathStep
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			step.descendant = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "/"):
+			rest = rest[1:]
+		default:
+			return nil, fmt.Errorf("xpath %q: expected / before %q", expr, rest)
+		}
+		end := strings.IndexAny(rest, "/[")
+		if end < 0 {
+			end = len(rest)
+		}
+		step.name = strings.TrimSpace(rest[:end])
+		rest = rest[end:]
+		if step.name == "" {
+			return nil, fmt.Errorf("xpath %q: missing step", expr)
+		}
+		if !strings.HasPrefix(step.name, "@") && step.name != "text()" {
+			step.name = strings.ToLower(step.name)
+		}
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("xpath %q: missing ]", expr)
+			}
+			pred, err := parsePredicate(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("xpath %q: %v", expr, err)
+			}
+			step.predicates = append(step.predicates, pred)
+			rest = rest[end+1:]
+		}
+		x.steps = append(x.steps, step)
+	}
+	for i, step := range x.steps {
+		last := i == len(x.steps)-1
+		if (strings.HasPrefix(step.name, "@") || step.name == "text()") && (!last || len(step.predicates) > 0) {
+			return nil, fmt.Errorf("xpath %q: %s can only be the last step, without predicates", expr, step.name)
+		}
+	}
+	return x, nil
+}
+
+func parsePredicate(pred string) (xpathPredicate, error) {
+	if pred == "last()" {
+		return xpathPredicate{position: -1}, nil
+	}
+	if n, err := strconv.Atoi(pred); err == nil {
+		if n < 1 {
+			return xpathPredicate{}, fmt.Errorf("positions start at 1, got %d", n)
+		}
+		return xpathPredicate{position: n}, nil
+	}
+	if strings.HasPrefix(pred, "contains(") && strings.HasSuffix(pred, ")") {
+		args := strings.SplitN(pred[len("contains("):len(pred)-1], ",", 2)
+		if len(args) != 2 || !strings.HasPrefix(strings.TrimSpace(args[0]), "@") {
+			return xpathPredicate{}, fmt.Errorf("unsupported predicate %q", pred)
+		}
+		value, err := unquote(strings.TrimSpace(args[1]))
+		if err != nil {
+			return xpathPredicate{}, err
+		}
+		return xpathPredicate{attr: strings.ToLower(strings.TrimSpace(args[0])[1:]), op: "contains", value: value}, nil
+	}
+	if strings.HasPrefix(pred, "@") {
+		parts := strings.SplitN(pred[1:], "=", 2)
+		p := xpathPredicate{attr: strings.ToLower(strings.TrimSpace(parts[0]))}
+		if len(parts) == 2 {
+			value, err := unquote(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return p, err
+			}
+			p.op, p.value = "=", value
+		}
+		return p, nil
+	}
+	return xpathPredicate{}, fmt.Errorf("unsupported predicate %q", pred)
+}
+
+func unquote(s string) (string, error) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], nil
+	}
+	return "", fmt.Errorf("expected a quoted string, got %q", s)
+}
+
+// Evaluate returns what the expression selects under `root`, in document
+// order: the text content of elements, the values of attributes, or text.
+func (x *XPath) Evaluate(root *Node) []string {
+	order := make(map[*Node]int)
+	root.descendants(func(n *Node) { order[n] = len(order) })
+
+	context := []*Node{root}
+	for _, step := range x.steps {
+		var parents []*Node
+		for _, n := range context {
+			parents = append(parents, n)
+			if step.descendant {
+				n.descendants(func(d *Node) { parents = append(parents, d) })
+			}
+		}
+		switch {
+		case strings.HasPrefix(step.name, "@"):
+			var values []string
+			for _, n := range parents {
+				if value, ok := n.Attr(strings.ToLower(step.name[1:])); ok && n.IsElement() {
+					values = append(values, value)
+				}
+			}
+			return values
+		case step.name == "text()":
+			var texts []string
+			for _, n := range parents {
+				for _, child := range n.Children {
+					if child.Name == "#text" && strings.TrimSpace(child.Text) != "" {
+						texts = append(texts, strings.TrimSpace(child.Text))
+					}
+				}
+			}
+			return texts
+		}
+		context = nil
+		seen := make(map[*Node]bool)
+		for _, parent := range parents {
+			for _, n := range step.filter(parent.elements()) {
+				// with `//`, a node can be reached through several
+				// of its ancestors
+				if !seen[n] {
+					seen[n] = true
+					context = append(context, n)
+				}
+			}
+		}
+		sort.Slice(context, func(i, j int) bool { return order[context[i]] < order[context[j]] })
+	}
+	values := make([]string, 0, len(context))
+	for _, n := range context {
+		values = append(values, n.TextContent())
+	}
+	return values
+}
+
+// filter keeps the siblings matching the name test and the predicates, in
+// turn, positions being counted among what the previous ones kept.
+func (s xpathStep) filter(siblings []*Node) []*Node {
+	var kept []*Node
+	for _, n := range siblings {
+		if s.name == "*" || s.name == n.Name {
+			kept = append(kept, n)
+		}
+	}
+	for _, pred := range s.predicates {
+		var next []*Node
+		for i, n := range kept {
+			if pred.matches(n, i+1, len(kept)) {
+				next = append(next, n)
+			}
+		}
+		kept = next
+	}
+	return kept
+}
+
+func (p xpathPredicate) matches(n *Node, position, size int) bool {
+	switch {
+	case p.position > 0:
+		return position == p.position
+	case p.position < 0:
+		return position == size
+	}
+	value, ok := n.Attr(p.attr)
+	switch p.op {
+	case "=":
+		return ok && value == p.value
+	case "contains":
+		return ok && strings.Contains(value, p.value)
+	default:
+		return ok
+	}
+}
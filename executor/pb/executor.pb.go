@@ -10,8 +10,26 @@ It is generated from these files:
 
 It has these top-level messages:
 	StatusMessage
+	Accepted
+	Rejected
+	Started
+	Finished
+	Summary
+	Failed
+	Snapshot
+	CheckSnapshot
+	StepSnapshot
+	Histogram
 	CommandMessage
+	Run
+	Halt
+	Pause
+	Resume
+	SetRate
 	ScriptParams
+	Scenario
+	DataFeed
+	Stage
 */
 package executorGRPC
 
@@ -29,8 +47,116 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+type ProtocolVersion int32
+
+const (
+	ProtocolVersion_UNSPECIFIED ProtocolVersion = 0
+	ProtocolVersion_V1          ProtocolVersion = 1
+	ProtocolVersion_V2          ProtocolVersion = 2
+)
+
+var ProtocolVersion_name = map[int32]string{
+	0: "UNSPECIFIED",
+	1: "V1",
+	2: "V2",
+}
+var ProtocolVersion_value = map[string]int32{
+	"UNSPECIFIED": 0,
+	"V1":          1,
+	"V2":          2,
+}
+
+func (x ProtocolVersion) String() string {
+	return proto.EnumName(ProtocolVersion_name, int32(x))
+}
+func (ProtocolVersion) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type ErrorCode int32
+
+const (
+	ErrorCode_INTERNAL            ErrorCode = 0
+	ErrorCode_INVALID_COMMAND     ErrorCode = 1
+	ErrorCode_INVALID_SCRIPT      ErrorCode = 2
+	ErrorCode_UNSUPPORTED_COMMAND ErrorCode = 3
+	ErrorCode_PERSISTENCE         ErrorCode = 4
+)
+
+var ErrorCode_name = map[int32]string{
+	0: "INTERNAL",
+	1: "INVALID_COMMAND",
+	2: "INVALID_SCRIPT",
+	3: "UNSUPPORTED_COMMAND",
+	4: "PERSISTENCE",
+}
+var ErrorCode_value = map[string]int32{
+	"INTERNAL":            0,
+	"INVALID_COMMAND":     1,
+	"INVALID_SCRIPT":      2,
+	"UNSUPPORTED_COMMAND": 3,
+	"PERSISTENCE":         4,
+}
+
+func (x ErrorCode) String() string {
+	return proto.EnumName(ErrorCode_name, int32(x))
+}
+func (ErrorCode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+type DataFeed_Mode int32
+
+const (
+	DataFeed_SEQUENTIAL DataFeed_Mode = 0
+	DataFeed_RANDOM     DataFeed_Mode = 1
+	DataFeed_UNIQUE     DataFeed_Mode = 2
+)
+
+var DataFeed_Mode_name = map[int32]string{
+	0: "SEQUENTIAL",
+	1: "RANDOM",
+	2: "UNIQUE",
+}
+var DataFeed_Mode_value = map[string]int32{
+	"SEQUENTIAL": 0,
+	"RANDOM":     1,
+	"UNIQUE":     2,
+}
+
+func (x DataFeed_Mode) String() string {
+	return proto.EnumName(DataFeed_Mode_name, int32(x))
+}
+func (DataFeed_Mode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{19, 0} }
+
+type Stage_Interpolation int32
+
+const (
+	Stage_LINEAR Stage_Interpolation = 0
+	Stage_STEP   Stage_Interpolation = 1
+)
+
+var Stage_Interpolation_name = map[int32]string{
+	0: "LINEAR",
+	1: "STEP",
+}
+var Stage_Interpolation_value = map[string]int32{
+	"LINEAR": 0,
+	"STEP":   1,
+}
+
+func (x Stage_Interpolation) String() string {
+	return proto.EnumName(Stage_Interpolation_name, int32(x))
+}
+func (Stage_Interpolation) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{20, 0} }
+
 type StatusMessage struct {
-	Status string `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+	Status   string    `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+	Snapshot *Snapshot `protobuf:"bytes,2,opt,name=snapshot" json:"snapshot,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//	*StatusMessage_Accepted
+	//	*StatusMessage_Rejected
+	//	*StatusMessage_Started
+	//	*StatusMessage_Progress
+	//	*StatusMessage_Finished
+	//	*StatusMessage_Failed
+	Event isStatusMessage_Event `protobuf_oneof:"event"`
 }
 
 func (m *StatusMessage) Reset()                    { *m = StatusMessage{} }
@@ -38,16 +164,388 @@ func (m *StatusMessage) String() string            { return proto.CompactTextStr
 func (*StatusMessage) ProtoMessage()               {}
 func (*StatusMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+type isStatusMessage_Event interface {
+	isStatusMessage_Event()
+}
+
+type StatusMessage_Accepted struct {
+	Accepted *Accepted `protobuf:"bytes,3,opt,name=accepted,oneof"`
+}
+type StatusMessage_Rejected struct {
+	Rejected *Rejected `protobuf:"bytes,4,opt,name=rejected,oneof"`
+}
+type StatusMessage_Started struct {
+	Started *Started `protobuf:"bytes,5,opt,name=started,oneof"`
+}
+type StatusMessage_Progress struct {
+	Progress *Snapshot `protobuf:"bytes,6,opt,name=progress,oneof"`
+}
+type StatusMessage_Finished struct {
+	Finished *Finished `protobuf:"bytes,7,opt,name=finished,oneof"`
+}
+type StatusMessage_Failed struct {
+	Failed *Failed `protobuf:"bytes,8,opt,name=failed,oneof"`
+}
+
+func (*StatusMessage_Accepted) isStatusMessage_Event() {}
+func (*StatusMessage_Rejected) isStatusMessage_Event() {}
+func (*StatusMessage_Started) isStatusMessage_Event()  {}
+func (*StatusMessage_Progress) isStatusMessage_Event() {}
+func (*StatusMessage_Finished) isStatusMessage_Event() {}
+func (*StatusMessage_Failed) isStatusMessage_Event()   {}
+
+func (m *StatusMessage) GetEvent() isStatusMessage_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetSnapshot() *Snapshot {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetAccepted() *Accepted {
+	if x, ok := m.GetEvent().(*StatusMessage_Accepted); ok {
+		return x.Accepted
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetRejected() *Rejected {
+	if x, ok := m.GetEvent().(*StatusMessage_Rejected); ok {
+		return x.Rejected
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetStarted() *Started {
+	if x, ok := m.GetEvent().(*StatusMessage_Started); ok {
+		return x.Started
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetProgress() *Snapshot {
+	if x, ok := m.GetEvent().(*StatusMessage_Progress); ok {
+		return x.Progress
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetFinished() *Finished {
+	if x, ok := m.GetEvent().(*StatusMessage_Finished); ok {
+		return x.Finished
+	}
+	return nil
+}
+
+func (m *StatusMessage) GetFailed() *Failed {
+	if x, ok := m.GetEvent().(*StatusMessage_Failed); ok {
+		return x.Failed
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*StatusMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), []interface{}) {
+	return _StatusMessage_OneofMarshaler, _StatusMessage_OneofUnmarshaler, []interface{}{
+		(*StatusMessage_Accepted)(nil),
+		(*StatusMessage_Rejected)(nil),
+		(*StatusMessage_Started)(nil),
+		(*StatusMessage_Progress)(nil),
+		(*StatusMessage_Finished)(nil),
+		(*StatusMessage_Failed)(nil),
+	}
+}
+
+func _StatusMessage_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*StatusMessage)
+	// event
+	switch x := m.Event.(type) {
+	case *StatusMessage_Accepted:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Accepted); err != nil {
+			return err
+		}
+	case *StatusMessage_Rejected:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Rejected); err != nil {
+			return err
+		}
+	case *StatusMessage_Started:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Started); err != nil {
+			return err
+		}
+	case *StatusMessage_Progress:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Progress); err != nil {
+			return err
+		}
+	case *StatusMessage_Finished:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Finished); err != nil {
+			return err
+		}
+	case *StatusMessage_Failed:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Failed); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("StatusMessage.Event has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _StatusMessage_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*StatusMessage)
+	switch tag {
+	case 3: // event.accepted
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Accepted)
+		err := b.DecodeMessage(msg)
+		m.Event = &StatusMessage_Accepted{msg}
+		return true, err
+	case 4: // event.rejected
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Rejected)
+		err := b.DecodeMessage(msg)
+		m.Event = &StatusMessage_Rejected{msg}
+		return true, err
+	case 5: // event.started
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Started)
+		err := b.DecodeMessage(msg)
+		m.Event = &StatusMessage_Started{msg}
+		return true, err
+	case 6: // event.progress
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Snapshot)
+		err := b.DecodeMessage(msg)
+		m.Event = &StatusMessage_Progress{msg}
+		return true, err
+	case 7: // event.finished
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Finished)
+		err := b.DecodeMessage(msg)
+		m.Event = &StatusMessage_Finished{msg}
+		return true, err
+	case 8: // event.failed
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Failed)
+		err := b.DecodeMessage(msg)
+		m.Event = &StatusMessage_Failed{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+type Accepted struct {
+	ProtocolVersion ProtocolVersion `protobuf:"varint,1,opt,name=protocol_version,enum=executorGRPC.ProtocolVersion" json:"protocol_version,omitempty"`
+}
+
+func (m *Accepted) Reset()                    { *m = Accepted{} }
+func (m *Accepted) String() string            { return proto.CompactTextString(m) }
+func (*Accepted) ProtoMessage()               {}
+func (*Accepted) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+type Rejected struct {
+	Code    ErrorCode `protobuf:"varint,1,opt,name=code,enum=executorGRPC.ErrorCode" json:"code,omitempty"`
+	Message string    `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *Rejected) Reset()                    { *m = Rejected{} }
+func (m *Rejected) String() string            { return proto.CompactTextString(m) }
+func (*Rejected) ProtoMessage()               {}
+func (*Rejected) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type Started struct {
+}
+
+func (m *Started) Reset()                    { *m = Started{} }
+func (m *Started) String() string            { return proto.CompactTextString(m) }
+func (*Started) ProtoMessage()               {}
+func (*Started) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+type Finished struct {
+	Halted  bool     `protobuf:"varint,1,opt,name=halted" json:"halted,omitempty"`
+	Summary *Summary `protobuf:"bytes,2,opt,name=summary" json:"summary,omitempty"`
+}
+
+func (m *Finished) Reset()                    { *m = Finished{} }
+func (m *Finished) String() string            { return proto.CompactTextString(m) }
+func (*Finished) ProtoMessage()               {}
+func (*Finished) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *Finished) GetSummary() *Summary {
+	if m != nil {
+		return m.Summary
+	}
+	return nil
+}
+
+type Summary struct {
+	Duration       float64 `protobuf:"fixed64,1,opt,name=duration" json:"duration,omitempty"`
+	Executions     int64   `protobuf:"varint,2,opt,name=executions" json:"executions,omitempty"`
+	Requests       int64   `protobuf:"varint,3,opt,name=requests" json:"requests,omitempty"`
+	Errors         int64   `protobuf:"varint,4,opt,name=errors" json:"errors,omitempty"`
+	FailedRequests int64   `protobuf:"varint,5,opt,name=failed_requests" json:"failed_requests,omitempty"`
+}
+
+func (m *Summary) Reset()                    { *m = Summary{} }
+func (m *Summary) String() string            { return proto.CompactTextString(m) }
+func (*Summary) ProtoMessage()               {}
+func (*Summary) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+type Failed struct {
+	Code    ErrorCode `protobuf:"varint,1,opt,name=code,enum=executorGRPC.ErrorCode" json:"code,omitempty"`
+	Message string    `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *Failed) Reset()                    { *m = Failed{} }
+func (m *Failed) String() string            { return proto.CompactTextString(m) }
+func (*Failed) ProtoMessage()               {}
+func (*Failed) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+type Snapshot struct {
+	Interval       float64          `protobuf:"fixed64,1,opt,name=interval" json:"interval,omitempty"`
+	Executions     int64            `protobuf:"varint,2,opt,name=executions" json:"executions,omitempty"`
+	Requests       int64            `protobuf:"varint,3,opt,name=requests" json:"requests,omitempty"`
+	Errors         int64            `protobuf:"varint,4,opt,name=errors" json:"errors,omitempty"`
+	Steps          []*StepSnapshot  `protobuf:"bytes,5,rep,name=steps" json:"steps,omitempty"`
+	FailedRequests int64            `protobuf:"varint,6,opt,name=failed_requests" json:"failed_requests,omitempty"`
+	Checks         []*CheckSnapshot `protobuf:"bytes,7,rep,name=checks" json:"checks,omitempty"`
+	Scenario       string           `protobuf:"bytes,8,opt,name=scenario" json:"scenario,omitempty"`
+}
+
+func (m *Snapshot) Reset()                    { *m = Snapshot{} }
+func (m *Snapshot) String() string            { return proto.CompactTextString(m) }
+func (*Snapshot) ProtoMessage()               {}
+func (*Snapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Snapshot) GetSteps() []*StepSnapshot {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
+func (m *Snapshot) GetChecks() []*CheckSnapshot {
+	if m != nil {
+		return m.Checks
+	}
+	return nil
+}
+
+type CheckSnapshot struct {
+	Name   string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Passes int64  `protobuf:"varint,2,opt,name=passes" json:"passes,omitempty"`
+	Fails  int64  `protobuf:"varint,3,opt,name=fails" json:"fails,omitempty"`
+}
+
+func (m *CheckSnapshot) Reset()                    { *m = CheckSnapshot{} }
+func (m *CheckSnapshot) String() string            { return proto.CompactTextString(m) }
+func (*CheckSnapshot) ProtoMessage()               {}
+func (*CheckSnapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+type StepSnapshot struct {
+	Name    string     `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Errors  int64      `protobuf:"varint,2,opt,name=errors" json:"errors,omitempty"`
+	Latency *Histogram `protobuf:"bytes,3,opt,name=latency" json:"latency,omitempty"`
+}
+
+func (m *StepSnapshot) Reset()                    { *m = StepSnapshot{} }
+func (m *StepSnapshot) String() string            { return proto.CompactTextString(m) }
+func (*StepSnapshot) ProtoMessage()               {}
+func (*StepSnapshot) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *StepSnapshot) GetLatency() *Histogram {
+	if m != nil {
+		return m.Latency
+	}
+	return nil
+}
+
+type Histogram struct {
+	Counts []int64 `protobuf:"varint,1,rep,packed,name=counts" json:"counts,omitempty"`
+	Sum    int64   `protobuf:"varint,2,opt,name=sum" json:"sum,omitempty"`
+	Max    int64   `protobuf:"varint,3,opt,name=max" json:"max,omitempty"`
+}
+
+func (m *Histogram) Reset()                    { *m = Histogram{} }
+func (m *Histogram) String() string            { return proto.CompactTextString(m) }
+func (*Histogram) ProtoMessage()               {}
+func (*Histogram) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
 type CommandMessage struct {
 	Command      string        `protobuf:"bytes,1,opt,name=command" json:"command,omitempty"`
 	ScriptParams *ScriptParams `protobuf:"bytes,2,opt,name=script_params" json:"script_params,omitempty"`
 	ScriptConfig string        `protobuf:"bytes,3,opt,name=script_config" json:"script_config,omitempty"`
+	// Types that are valid to be assigned to Kind:
+	//	*CommandMessage_Run
+	//	*CommandMessage_Halt
+	//	*CommandMessage_Pause
+	//	*CommandMessage_Resume
+	//	*CommandMessage_SetRate
+	Kind isCommandMessage_Kind `protobuf_oneof:"kind"`
 }
 
 func (m *CommandMessage) Reset()                    { *m = CommandMessage{} }
 func (m *CommandMessage) String() string            { return proto.CompactTextString(m) }
 func (*CommandMessage) ProtoMessage()               {}
-func (*CommandMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*CommandMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type isCommandMessage_Kind interface {
+	isCommandMessage_Kind()
+}
+
+type CommandMessage_Run struct {
+	Run *Run `protobuf:"bytes,4,opt,name=run,oneof"`
+}
+type CommandMessage_Halt struct {
+	Halt *Halt `protobuf:"bytes,5,opt,name=halt,oneof"`
+}
+type CommandMessage_Pause struct {
+	Pause *Pause `protobuf:"bytes,6,opt,name=pause,oneof"`
+}
+type CommandMessage_Resume struct {
+	Resume *Resume `protobuf:"bytes,7,opt,name=resume,oneof"`
+}
+type CommandMessage_SetRate struct {
+	SetRate *SetRate `protobuf:"bytes,8,opt,name=set_rate,oneof"`
+}
+
+func (*CommandMessage_Run) isCommandMessage_Kind()     {}
+func (*CommandMessage_Halt) isCommandMessage_Kind()    {}
+func (*CommandMessage_Pause) isCommandMessage_Kind()   {}
+func (*CommandMessage_Resume) isCommandMessage_Kind()  {}
+func (*CommandMessage_SetRate) isCommandMessage_Kind() {}
+
+func (m *CommandMessage) GetKind() isCommandMessage_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
 
 func (m *CommandMessage) GetScriptParams() *ScriptParams {
 	if m != nil {
@@ -56,37 +554,304 @@ func (m *CommandMessage) GetScriptParams() *ScriptParams {
 	return nil
 }
 
+func (m *CommandMessage) GetRun() *Run {
+	if x, ok := m.GetKind().(*CommandMessage_Run); ok {
+		return x.Run
+	}
+	return nil
+}
+
+func (m *CommandMessage) GetHalt() *Halt {
+	if x, ok := m.GetKind().(*CommandMessage_Halt); ok {
+		return x.Halt
+	}
+	return nil
+}
+
+func (m *CommandMessage) GetPause() *Pause {
+	if x, ok := m.GetKind().(*CommandMessage_Pause); ok {
+		return x.Pause
+	}
+	return nil
+}
+
+func (m *CommandMessage) GetResume() *Resume {
+	if x, ok := m.GetKind().(*CommandMessage_Resume); ok {
+		return x.Resume
+	}
+	return nil
+}
+
+func (m *CommandMessage) GetSetRate() *SetRate {
+	if x, ok := m.GetKind().(*CommandMessage_SetRate); ok {
+		return x.SetRate
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*CommandMessage) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), []interface{}) {
+	return _CommandMessage_OneofMarshaler, _CommandMessage_OneofUnmarshaler, []interface{}{
+		(*CommandMessage_Run)(nil),
+		(*CommandMessage_Halt)(nil),
+		(*CommandMessage_Pause)(nil),
+		(*CommandMessage_Resume)(nil),
+		(*CommandMessage_SetRate)(nil),
+	}
+}
+
+func _CommandMessage_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*CommandMessage)
+	// kind
+	switch x := m.Kind.(type) {
+	case *CommandMessage_Run:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Run); err != nil {
+			return err
+		}
+	case *CommandMessage_Halt:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Halt); err != nil {
+			return err
+		}
+	case *CommandMessage_Pause:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Pause); err != nil {
+			return err
+		}
+	case *CommandMessage_Resume:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Resume); err != nil {
+			return err
+		}
+	case *CommandMessage_SetRate:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.SetRate); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("CommandMessage.Kind has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _CommandMessage_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*CommandMessage)
+	switch tag {
+	case 4: // kind.run
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Run)
+		err := b.DecodeMessage(msg)
+		m.Kind = &CommandMessage_Run{msg}
+		return true, err
+	case 5: // kind.halt
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Halt)
+		err := b.DecodeMessage(msg)
+		m.Kind = &CommandMessage_Halt{msg}
+		return true, err
+	case 6: // kind.pause
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Pause)
+		err := b.DecodeMessage(msg)
+		m.Kind = &CommandMessage_Pause{msg}
+		return true, err
+	case 7: // kind.resume
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Resume)
+		err := b.DecodeMessage(msg)
+		m.Kind = &CommandMessage_Resume{msg}
+		return true, err
+	case 8: // kind.set_rate
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SetRate)
+		err := b.DecodeMessage(msg)
+		m.Kind = &CommandMessage_SetRate{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+type Run struct {
+	ScriptParams *ScriptParams `protobuf:"bytes,1,opt,name=script_params" json:"script_params,omitempty"`
+	ScriptConfig string        `protobuf:"bytes,2,opt,name=script_config" json:"script_config,omitempty"`
+}
+
+func (m *Run) Reset()                    { *m = Run{} }
+func (m *Run) String() string            { return proto.CompactTextString(m) }
+func (*Run) ProtoMessage()               {}
+func (*Run) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *Run) GetScriptParams() *ScriptParams {
+	if m != nil {
+		return m.ScriptParams
+	}
+	return nil
+}
+
+type Halt struct {
+}
+
+func (m *Halt) Reset()                    { *m = Halt{} }
+func (m *Halt) String() string            { return proto.CompactTextString(m) }
+func (*Halt) ProtoMessage()               {}
+func (*Halt) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+type Pause struct {
+}
+
+func (m *Pause) Reset()                    { *m = Pause{} }
+func (m *Pause) String() string            { return proto.CompactTextString(m) }
+func (*Pause) ProtoMessage()               {}
+func (*Pause) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+type Resume struct {
+}
+
+func (m *Resume) Reset()                    { *m = Resume{} }
+func (m *Resume) String() string            { return proto.CompactTextString(m) }
+func (*Resume) ProtoMessage()               {}
+func (*Resume) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type SetRate struct {
+	RequestsPerSecond int32 `protobuf:"varint,1,opt,name=requests_per_second" json:"requests_per_second,omitempty"`
+	Workers           int32 `protobuf:"varint,2,opt,name=workers" json:"workers,omitempty"`
+}
+
+func (m *SetRate) Reset()                    { *m = SetRate{} }
+func (m *SetRate) String() string            { return proto.CompactTextString(m) }
+func (*SetRate) ProtoMessage()               {}
+func (*SetRate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
 type ScriptParams struct {
-	Url                       string  `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
-	Script                    string  `protobuf:"bytes,2,opt,name=script" json:"script,omitempty"`
-	ScriptId                  string  `protobuf:"bytes,3,opt,name=script_id" json:"script_id,omitempty"`
-	RunTime                   int32   `protobuf:"varint,4,opt,name=run_time" json:"run_time,omitempty"`
-	MaxWorkers                int32   `protobuf:"varint,6,opt,name=max_workers" json:"max_workers,omitempty"`
-	GrowthFactor              float64 `protobuf:"fixed64,8,opt,name=growth_factor" json:"growth_factor,omitempty"`
-	TimeBetweenGrowth         float64 `protobuf:"fixed64,9,opt,name=time_between_growth" json:"time_between_growth,omitempty"`
-	StartingRequestsPerSecond int32   `protobuf:"varint,10,opt,name=starting_requests_per_second" json:"starting_requests_per_second,omitempty"`
-	MaxRequestsPerSecond      int32   `protobuf:"varint,11,opt,name=max_requests_per_second" json:"max_requests_per_second,omitempty"`
+	Url                       string      `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
+	Script                    string      `protobuf:"bytes,2,opt,name=script" json:"script,omitempty"`
+	ScriptId                  string      `protobuf:"bytes,3,opt,name=script_id" json:"script_id,omitempty"`
+	RunTime                   int32       `protobuf:"varint,4,opt,name=run_time" json:"run_time,omitempty"`
+	MaxWorkers                int32       `protobuf:"varint,6,opt,name=max_workers" json:"max_workers,omitempty"`
+	GrowthFactor              float64     `protobuf:"fixed64,8,opt,name=growth_factor" json:"growth_factor,omitempty"`
+	TimeBetweenGrowth         float64     `protobuf:"fixed64,9,opt,name=time_between_growth" json:"time_between_growth,omitempty"`
+	StartingRequestsPerSecond int32       `protobuf:"varint,10,opt,name=starting_requests_per_second" json:"starting_requests_per_second,omitempty"`
+	MaxRequestsPerSecond      int32       `protobuf:"varint,11,opt,name=max_requests_per_second" json:"max_requests_per_second,omitempty"`
+	KeepCookies               bool        `protobuf:"varint,12,opt,name=keep_cookies" json:"keep_cookies,omitempty"`
+	OpenModel                 bool        `protobuf:"varint,13,opt,name=open_model" json:"open_model,omitempty"`
+	Stages                    []*Stage    `protobuf:"bytes,14,rep,name=stages" json:"stages,omitempty"`
+	Rollups                   bool        `protobuf:"varint,15,opt,name=rollups" json:"rollups,omitempty"`
+	DataFeed                  *DataFeed   `protobuf:"bytes,16,opt,name=data_feed" json:"data_feed,omitempty"`
+	SetupData                 string      `protobuf:"bytes,17,opt,name=setup_data" json:"setup_data,omitempty"`
+	Scenarios                 []*Scenario `protobuf:"bytes,18,rep,name=scenarios" json:"scenarios,omitempty"`
+	AllowedHosts              []string    `protobuf:"bytes,19,rep,name=allowed_hosts" json:"allowed_hosts,omitempty"`
 }
 
 func (m *ScriptParams) Reset()                    { *m = ScriptParams{} }
 func (m *ScriptParams) String() string            { return proto.CompactTextString(m) }
 func (*ScriptParams) ProtoMessage()               {}
-func (*ScriptParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*ScriptParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ScriptParams) GetStages() []*Stage {
+	if m != nil {
+		return m.Stages
+	}
+	return nil
+}
+
+func (m *ScriptParams) GetDataFeed() *DataFeed {
+	if m != nil {
+		return m.DataFeed
+	}
+	return nil
+}
+
+func (m *ScriptParams) GetScenarios() []*Scenario {
+	if m != nil {
+		return m.Scenarios
+	}
+	return nil
+}
+
+type Scenario struct {
+	Name         string        `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Params       *ScriptParams `protobuf:"bytes,2,opt,name=params" json:"params,omitempty"`
+	ScriptConfig string        `protobuf:"bytes,3,opt,name=script_config" json:"script_config,omitempty"`
+}
+
+func (m *Scenario) Reset()                    { *m = Scenario{} }
+func (m *Scenario) String() string            { return proto.CompactTextString(m) }
+func (*Scenario) ProtoMessage()               {}
+func (*Scenario) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *Scenario) GetParams() *ScriptParams {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type DataFeed struct {
+	Records []string      `protobuf:"bytes,1,rep,name=records" json:"records,omitempty"`
+	Mode    DataFeed_Mode `protobuf:"varint,2,opt,name=mode,enum=executorGRPC.DataFeed_Mode" json:"mode,omitempty"`
+}
+
+func (m *DataFeed) Reset()                    { *m = DataFeed{} }
+func (m *DataFeed) String() string            { return proto.CompactTextString(m) }
+func (*DataFeed) ProtoMessage()               {}
+func (*DataFeed) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+type Stage struct {
+	Duration                float64             `protobuf:"fixed64,1,opt,name=duration" json:"duration,omitempty"`
+	TargetRequestsPerSecond int32               `protobuf:"varint,2,opt,name=target_requests_per_second" json:"target_requests_per_second,omitempty"`
+	Interpolation           Stage_Interpolation `protobuf:"varint,3,opt,name=interpolation,enum=executorGRPC.Stage_Interpolation" json:"interpolation,omitempty"`
+}
+
+func (m *Stage) Reset()                    { *m = Stage{} }
+func (m *Stage) String() string            { return proto.CompactTextString(m) }
+func (*Stage) ProtoMessage()               {}
+func (*Stage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func init() {
 	proto.RegisterType((*StatusMessage)(nil), "executorGRPC.StatusMessage")
+	proto.RegisterType((*Accepted)(nil), "executorGRPC.Accepted")
+	proto.RegisterType((*Rejected)(nil), "executorGRPC.Rejected")
+	proto.RegisterType((*Started)(nil), "executorGRPC.Started")
+	proto.RegisterType((*Finished)(nil), "executorGRPC.Finished")
+	proto.RegisterType((*Summary)(nil), "executorGRPC.Summary")
+	proto.RegisterType((*Failed)(nil), "executorGRPC.Failed")
+	proto.RegisterType((*Snapshot)(nil), "executorGRPC.Snapshot")
+	proto.RegisterType((*CheckSnapshot)(nil), "executorGRPC.CheckSnapshot")
+	proto.RegisterType((*StepSnapshot)(nil), "executorGRPC.StepSnapshot")
+	proto.RegisterType((*Histogram)(nil), "executorGRPC.Histogram")
 	proto.RegisterType((*CommandMessage)(nil), "executorGRPC.CommandMessage")
+	proto.RegisterType((*Run)(nil), "executorGRPC.Run")
+	proto.RegisterType((*Halt)(nil), "executorGRPC.Halt")
+	proto.RegisterType((*Pause)(nil), "executorGRPC.Pause")
+	proto.RegisterType((*Resume)(nil), "executorGRPC.Resume")
+	proto.RegisterType((*SetRate)(nil), "executorGRPC.SetRate")
 	proto.RegisterType((*ScriptParams)(nil), "executorGRPC.ScriptParams")
+	proto.RegisterType((*Scenario)(nil), "executorGRPC.Scenario")
+	proto.RegisterType((*DataFeed)(nil), "executorGRPC.DataFeed")
+	proto.RegisterType((*Stage)(nil), "executorGRPC.Stage")
+	proto.RegisterEnum("executorGRPC.ProtocolVersion", ProtocolVersion_name, ProtocolVersion_value)
+	proto.RegisterEnum("executorGRPC.ErrorCode", ErrorCode_name, ErrorCode_value)
+	proto.RegisterEnum("executorGRPC.DataFeed_Mode", DataFeed_Mode_name, DataFeed_Mode_value)
+	proto.RegisterEnum("executorGRPC.Stage_Interpolation", Stage_Interpolation_name, Stage_Interpolation_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ context.Context
 var _ grpc.ClientConn
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
 // Client API for Commander service
 
 type CommanderClient interface {
@@ -183,25 +948,83 @@ var _Commander_serviceDesc = grpc.ServiceDesc{
 }
 
 var fileDescriptor0 = []byte{
-	// 313 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x6c, 0x91, 0xc1, 0x4e, 0xf2, 0x40,
-	0x10, 0xc7, 0xbf, 0xfd, 0x50, 0xa4, 0x53, 0x40, 0x59, 0x62, 0xdc, 0x00, 0x09, 0xa6, 0xf1, 0xc0,
-	0x09, 0x15, 0x1f, 0x81, 0x18, 0x4f, 0x26, 0x28, 0x9e, 0xdd, 0x2c, 0x65, 0xc4, 0x46, 0xdb, 0xad,
-	0xb3, 0xdb, 0xc0, 0xe3, 0xfa, 0x28, 0x6e, 0x97, 0x92, 0x80, 0xf1, 0xd8, 0xdf, 0xfc, 0x3a, 0x33,
-	0xfb, 0x1f, 0xe8, 0xe4, 0x8b, 0x6b, 0xdc, 0x60, 0x5c, 0x58, 0x4d, 0xe3, 0x9c, 0xb4, 0xd5, 0xbc,
-	0xb9, 0xfb, 0x7e, 0x78, 0x9e, 0x4d, 0xa3, 0x21, 0xb4, 0xe6, 0x56, 0xd9, 0xc2, 0x3c, 0xa2, 0x31,
-	0x6a, 0x85, 0xbc, 0x0d, 0x75, 0xe3, 0x81, 0x60, 0x97, 0x6c, 0x14, 0x44, 0x2f, 0xd0, 0x9e, 0xea,
-	0x34, 0x55, 0xd9, 0x72, 0x67, 0x9c, 0xc2, 0x49, 0xbc, 0x25, 0x5b, 0x85, 0xdf, 0x42, 0xcb, 0xc4,
-	0x94, 0xe4, 0x56, 0xe6, 0x8a, 0x54, 0x6a, 0xc4, 0x7f, 0x87, 0xc3, 0x49, 0x6f, 0xbc, 0x3f, 0x69,
-	0x3c, 0xf7, 0xca, 0xcc, 0x1b, 0xd1, 0x37, 0x83, 0xe6, 0x3e, 0xe0, 0x21, 0xd4, 0x0a, 0xfa, 0xac,
-	0x1a, 0x96, 0x3b, 0xf8, 0xa2, 0xef, 0x14, 0xf0, 0x0e, 0x04, 0xd5, 0x80, 0x64, 0x29, 0x6a, 0x1e,
-	0x9d, 0x41, 0x83, 0x8a, 0x4c, 0xda, 0x24, 0x45, 0x71, 0xe4, 0xc8, 0x31, 0xef, 0x42, 0x98, 0xaa,
-	0x8d, 0x5c, 0x6b, 0xfa, 0x40, 0x32, 0xa2, 0xee, 0xe1, 0x39, 0xb4, 0x56, 0xa4, 0xd7, 0xf6, 0x5d,
-	0xbe, 0xa9, 0xd8, 0x6d, 0x22, 0x1a, 0x0e, 0x33, 0xde, 0x87, 0x6e, 0xf9, 0xa7, 0x5c, 0xa0, 0x5d,
-	0x23, 0x66, 0x72, 0xeb, 0x88, 0xc0, 0x17, 0xaf, 0x60, 0xe0, 0x12, 0x20, 0x9b, 0x64, 0x2b, 0x49,
-	0xf8, 0x55, 0xa0, 0xb1, 0x46, 0xe6, 0x48, 0xd2, 0x60, 0xac, 0xdd, 0xa3, 0xc1, 0x77, 0x1e, 0xc2,
-	0x45, 0x39, 0xee, 0x2f, 0x21, 0x2c, 0x85, 0xc9, 0x2b, 0x04, 0x55, 0x70, 0x48, 0xfc, 0x09, 0xda,
-	0xf7, 0x3e, 0x0c, 0xac, 0x18, 0x1f, 0x1c, 0xa6, 0x73, 0x98, 0x71, 0xaf, 0xff, 0x2b, 0xbb, 0xfd,
-	0x13, 0x45, 0xff, 0x46, 0xec, 0x86, 0x2d, 0xea, 0xfe, 0x9c, 0x77, 0x3f, 0x01, 0x00, 0x00, 0xff,
-	0xff, 0x0a, 0x26, 0x26, 0xa4, 0xe3, 0x01, 0x00, 0x00,
+	// 1243 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xa4, 0x56, 0xdd, 0x6e, 0xdb, 0xc6,
+	0x12, 0x96, 0x44, 0xfd, 0x50, 0x23, 0x4b, 0xa6, 0xd7, 0xc9, 0x31, 0x91, 0xe4, 0xe0, 0xf8, 0xb0,
+	0x49, 0x20, 0xbb, 0x80, 0xdb, 0xb8, 0x28, 0xd2, 0x8b, 0x5e, 0xd4, 0x91, 0x95, 0x4a, 0x40, 0x2c,
+	0x2b, 0x92, 0xed, 0x9b, 0x02, 0x25, 0x36, 0xe4, 0x58, 0x66, 0x4c, 0x72, 0xd9, 0xdd, 0xa5, 0x9d,
+	0xbc, 0x4c, 0x1f, 0xa2, 0x6f, 0xd0, 0x77, 0x68, 0xdf, 0xa7, 0xd8, 0xe5, 0x4a, 0x8e, 0x64, 0xa1,
+	0x40, 0x9b, 0x2b, 0x92, 0x3b, 0xdf, 0xcc, 0x7e, 0xf3, 0xcd, 0xec, 0x2c, 0x61, 0x2b, 0x7b, 0xf7,
+	0x15, 0x7e, 0xc0, 0x20, 0x97, 0x8c, 0x1f, 0x64, 0x9c, 0x49, 0x46, 0x36, 0xe6, 0xdf, 0x3f, 0x4e,
+	0xc6, 0x3d, 0xef, 0x8f, 0x0a, 0xb4, 0xa7, 0x92, 0xca, 0x5c, 0x9c, 0xa0, 0x10, 0x74, 0x86, 0xa4,
+	0x03, 0x75, 0xa1, 0x17, 0xdc, 0xf2, 0x6e, 0xb9, 0xdb, 0x24, 0x5d, 0xb0, 0x45, 0x4a, 0x33, 0x71,
+	0xc5, 0xa4, 0x5b, 0xd9, 0x2d, 0x77, 0x5b, 0x87, 0xff, 0x39, 0xf8, 0x34, 0xc4, 0xc1, 0xd4, 0x58,
+	0xc9, 0x3e, 0xd8, 0x34, 0x08, 0x30, 0x93, 0x18, 0xba, 0xd6, 0x3a, 0xe4, 0x91, 0xb1, 0x0e, 0x4a,
+	0x0a, 0xcb, 0xf1, 0x3d, 0x06, 0x0a, 0x5b, 0x5d, 0x87, 0x9d, 0x18, 0xeb, 0xa0, 0x44, 0xba, 0xd0,
+	0x10, 0x92, 0x72, 0x05, 0xad, 0x69, 0xe8, 0xc3, 0x15, 0x02, 0x85, 0xb1, 0x88, 0x9a, 0x71, 0x36,
+	0xe3, 0x28, 0x84, 0x5b, 0xff, 0x3b, 0xae, 0x05, 0xf6, 0x32, 0x4a, 0x23, 0x71, 0x85, 0xa1, 0xdb,
+	0x58, 0x87, 0x7d, 0x6d, 0xac, 0x83, 0x12, 0x79, 0x0e, 0xf5, 0x4b, 0x1a, 0xc5, 0x18, 0xba, 0xb6,
+	0x46, 0x3e, 0x58, 0x41, 0x6a, 0xdb, 0xa0, 0xf4, 0xaa, 0x01, 0x35, 0xbc, 0xc1, 0x54, 0x7a, 0x3d,
+	0xb0, 0xe7, 0xc9, 0x92, 0x97, 0xe0, 0x68, 0xe5, 0x03, 0x16, 0xfb, 0x37, 0xc8, 0x45, 0xc4, 0x52,
+	0x2d, 0x6d, 0xe7, 0xf0, 0xbf, 0xcb, 0x61, 0xc6, 0x06, 0x75, 0x51, 0x80, 0xbc, 0x57, 0x60, 0xcf,
+	0x55, 0x20, 0xcf, 0xa0, 0x1a, 0xb0, 0x10, 0x8d, 0xe3, 0xce, 0xb2, 0x63, 0x9f, 0x73, 0xc6, 0x7b,
+	0x2c, 0x44, 0xb2, 0x09, 0x8d, 0xa4, 0xa8, 0xa3, 0xae, 0x55, 0xd3, 0x6b, 0x42, 0xc3, 0xc8, 0xa3,
+	0xc2, 0xcd, 0x53, 0x52, 0x45, 0xbe, 0xa2, 0xb1, 0x52, 0x54, 0x05, 0xb4, 0xc9, 0x73, 0x68, 0x88,
+	0x3c, 0x49, 0x28, 0xff, 0xe8, 0x56, 0xd6, 0x4a, 0x5c, 0x18, 0xbd, 0xf7, 0xd0, 0x30, 0xaf, 0xc4,
+	0x01, 0x3b, 0xcc, 0x39, 0x95, 0xf3, 0x74, 0xca, 0x84, 0x00, 0x14, 0x4e, 0x11, 0x4b, 0x85, 0x8e,
+	0x63, 0x29, 0x14, 0xc7, 0x5f, 0x72, 0x14, 0x52, 0xe8, 0x9e, 0xb0, 0xd4, 0xd6, 0xa8, 0xf8, 0x0a,
+	0x5d, 0x77, 0x8b, 0xec, 0xc0, 0x66, 0xa1, 0xad, 0xbf, 0x00, 0xaa, 0x2a, 0x5b, 0xde, 0x0f, 0x50,
+	0x2f, 0x84, 0xfd, 0xd7, 0xc9, 0xff, 0x59, 0x06, 0x7b, 0xd1, 0x9d, 0x0e, 0xd8, 0x51, 0x2a, 0x91,
+	0xdf, 0xd0, 0xf8, 0xb3, 0xf8, 0xee, 0x41, 0x4d, 0x48, 0xcc, 0x14, 0x4b, 0xab, 0xdb, 0x3a, 0x7c,
+	0xb4, 0xda, 0x8b, 0x98, 0x2d, 0xb6, 0x5c, 0x93, 0x5a, 0x5d, 0xc7, 0xf8, 0x12, 0xea, 0xc1, 0x15,
+	0x06, 0xd7, 0xc2, 0x6d, 0xe8, 0x20, 0x8f, 0x97, 0x83, 0xf4, 0x94, 0xed, 0x53, 0xe2, 0x22, 0xc0,
+	0x94, 0xf2, 0x88, 0xe9, 0xf6, 0x6b, 0x7a, 0xdf, 0x43, 0x7b, 0x19, 0xb2, 0x01, 0xd5, 0x94, 0x26,
+	0x68, 0x4e, 0x6c, 0x07, 0xea, 0x19, 0x15, 0x02, 0xe7, 0x39, 0xb5, 0xa1, 0xa6, 0x68, 0x98, 0x84,
+	0xbc, 0x0b, 0xd8, 0x58, 0x62, 0x79, 0xcf, 0xd9, 0xa4, 0x5b, 0x38, 0x77, 0xa1, 0x11, 0x53, 0x89,
+	0x69, 0xf0, 0xd1, 0x9c, 0xe9, 0x15, 0xf9, 0x07, 0x91, 0x90, 0x6c, 0xc6, 0x69, 0xe2, 0x7d, 0x0b,
+	0xcd, 0xc5, 0x87, 0x0a, 0x13, 0xb0, 0x3c, 0x95, 0x6a, 0x8a, 0x58, 0x5d, 0x8b, 0xb4, 0xc0, 0x12,
+	0x79, 0x62, 0x62, 0xb6, 0xc0, 0x4a, 0xe8, 0x07, 0x43, 0xe7, 0xb7, 0x0a, 0x74, 0x7a, 0x2c, 0x49,
+	0x68, 0x1a, 0xce, 0x47, 0xd0, 0x26, 0x34, 0x82, 0x62, 0xc5, 0x90, 0x7a, 0x01, 0x6d, 0x11, 0xf0,
+	0x28, 0x93, 0x7e, 0x46, 0x39, 0x4d, 0x84, 0x69, 0xd2, 0x55, 0xed, 0x35, 0x64, 0xac, 0x11, 0xe4,
+	0xe1, 0xc2, 0x25, 0x60, 0xe9, 0x65, 0x34, 0xd3, 0xbb, 0x35, 0xc9, 0x2e, 0x58, 0x3c, 0x4f, 0xcd,
+	0xc8, 0xd9, 0x5a, 0x19, 0x39, 0x79, 0x3a, 0x28, 0x11, 0x0f, 0xaa, 0xea, 0x68, 0x98, 0x51, 0x43,
+	0x56, 0xb2, 0xa5, 0xb1, 0x9a, 0x1d, 0x4f, 0xa1, 0x96, 0xd1, 0x5c, 0xa0, 0x19, 0x32, 0xdb, 0x2b,
+	0xe7, 0x58, 0x99, 0x8a, 0xa9, 0xc1, 0x51, 0xe4, 0x09, 0xba, 0x8d, 0x75, 0x53, 0x63, 0xa2, 0x6d,
+	0x83, 0x12, 0xd9, 0x03, 0x5b, 0xa0, 0xf4, 0x39, 0x95, 0xe8, 0xda, 0x6b, 0x4f, 0x1f, 0xca, 0x09,
+	0x95, 0x38, 0x28, 0xbd, 0xaa, 0x43, 0xf5, 0x3a, 0x4a, 0x43, 0xef, 0x14, 0xac, 0x49, 0x9e, 0xde,
+	0xd7, 0xa5, 0xfc, 0xcf, 0x75, 0x29, 0x8e, 0x4a, 0x1d, 0xaa, 0x2a, 0x37, 0xaf, 0x01, 0x35, 0x4d,
+	0xdf, 0xb3, 0xa1, 0x5e, 0x10, 0xf4, 0x5e, 0x42, 0xc3, 0x10, 0x20, 0x8f, 0x61, 0x7b, 0xde, 0xc9,
+	0x7e, 0x86, 0xdc, 0x17, 0x18, 0x30, 0x53, 0xa4, 0x9a, 0xaa, 0xda, 0x2d, 0xe3, 0xd7, 0x68, 0x5a,
+	0xa7, 0xe6, 0xfd, 0x6e, 0xc1, 0xc6, 0xd2, 0xde, 0x2d, 0xb0, 0x72, 0x1e, 0xdf, 0x35, 0x5a, 0x41,
+	0xa4, 0x60, 0x40, 0xb6, 0xa0, 0x69, 0x88, 0x45, 0xa1, 0x29, 0x96, 0x3a, 0x8c, 0x79, 0xea, 0xcb,
+	0x28, 0x41, 0x5d, 0xb1, 0x1a, 0xd9, 0x86, 0x56, 0x42, 0x3f, 0xf8, 0xf3, 0x7d, 0xea, 0x7a, 0xf1,
+	0x21, 0xb4, 0x67, 0x9c, 0xdd, 0xca, 0x2b, 0xff, 0x92, 0x06, 0x92, 0x71, 0x2d, 0x62, 0x59, 0x91,
+	0x55, 0x9e, 0xfe, 0x3b, 0x94, 0xb7, 0x88, 0xa9, 0x5f, 0x60, 0xdc, 0xa6, 0x36, 0x3e, 0x85, 0x27,
+	0xfa, 0x4e, 0x89, 0xd2, 0x99, 0xbf, 0x2e, 0x25, 0xd0, 0x91, 0xff, 0x07, 0x3b, 0x6a, 0xbb, 0x75,
+	0x80, 0x96, 0x06, 0x3c, 0x80, 0x8d, 0x6b, 0xc4, 0xcc, 0x0f, 0x18, 0xbb, 0x8e, 0x50, 0xb8, 0x1b,
+	0x7a, 0x9a, 0x12, 0x00, 0x96, 0x61, 0xea, 0x27, 0x2c, 0xc4, 0xd8, 0x6d, 0xeb, 0xb5, 0x2f, 0xf4,
+	0xb5, 0x3a, 0x43, 0xe1, 0x76, 0x76, 0xad, 0xfb, 0x3d, 0x33, 0x95, 0xa6, 0xf1, 0x39, 0x8b, 0xe3,
+	0x3c, 0x13, 0xee, 0xa6, 0xf6, 0xda, 0x83, 0x66, 0x48, 0x25, 0xf5, 0x2f, 0x11, 0x43, 0xd7, 0x59,
+	0x77, 0x4b, 0x1d, 0x53, 0x49, 0x5f, 0x23, 0x86, 0x6a, 0x53, 0x81, 0x32, 0xcf, 0x7c, 0xe5, 0xe0,
+	0x6e, 0x69, 0x01, 0xf7, 0xa0, 0x39, 0x1f, 0x1d, 0xc2, 0x25, 0xbb, 0xd6, 0x7d, 0xf7, 0xa9, 0x31,
+	0x2b, 0x11, 0x69, 0x1c, 0xb3, 0x5b, 0x0c, 0xfd, 0x2b, 0xa6, 0x26, 0xd5, 0xf6, 0xae, 0xd5, 0x6d,
+	0x7a, 0x3f, 0x81, 0xbd, 0x80, 0x2c, 0x0f, 0x8a, 0x7d, 0x35, 0x65, 0x3e, 0xeb, 0x30, 0x7a, 0x37,
+	0x60, 0x2f, 0xe8, 0xab, 0xd4, 0x31, 0x60, 0x3c, 0x2c, 0x26, 0x86, 0xe2, 0x5e, 0x55, 0xfa, 0xe9,
+	0xe8, 0x9d, 0xd5, 0x09, 0x39, 0x77, 0x3b, 0x38, 0x61, 0x21, 0x7a, 0x07, 0x50, 0x55, 0x4f, 0xd2,
+	0x01, 0x98, 0xf6, 0xdf, 0x9e, 0xf7, 0x47, 0x67, 0xc3, 0xa3, 0x37, 0x4e, 0x89, 0x00, 0xd4, 0x27,
+	0x47, 0xa3, 0xe3, 0xd3, 0x13, 0xa7, 0xac, 0xde, 0xcf, 0x47, 0xc3, 0xb7, 0xe7, 0x7d, 0xa7, 0xe2,
+	0xfd, 0x5a, 0x86, 0x5a, 0x21, 0xf8, 0xfd, 0x4b, 0xcc, 0x83, 0x47, 0x92, 0xf2, 0x19, 0xca, 0xb5,
+	0x55, 0xd7, 0x8d, 0x4d, 0xbe, 0x83, 0xb6, 0xbe, 0x4a, 0x32, 0x16, 0x17, 0xae, 0x96, 0xe6, 0xf8,
+	0xff, 0x35, 0x25, 0x3d, 0x18, 0x7e, 0x0a, 0xf4, 0x9e, 0x41, 0x7b, 0x69, 0x41, 0xd1, 0x7a, 0x33,
+	0x1c, 0xf5, 0x8f, 0x26, 0x4e, 0x89, 0xd8, 0x50, 0x9d, 0x9e, 0xf5, 0xc7, 0x4e, 0x79, 0xff, 0x10,
+	0x36, 0x57, 0x7e, 0x06, 0xc8, 0x26, 0xb4, 0xce, 0x47, 0xd3, 0x71, 0xbf, 0x37, 0x7c, 0x3d, 0xec,
+	0x1f, 0x3b, 0x25, 0x52, 0x87, 0xca, 0xc5, 0x0b, 0xa7, 0xac, 0x9f, 0x87, 0x4e, 0x65, 0x3f, 0x86,
+	0xe6, 0xdd, 0x55, 0xb8, 0x01, 0xf6, 0x70, 0x74, 0xd6, 0x9f, 0x8c, 0xb4, 0x0e, 0xdb, 0xb0, 0x39,
+	0x1c, 0x5d, 0x1c, 0xbd, 0x19, 0x1e, 0xfb, 0xbd, 0xd3, 0x93, 0x93, 0xa3, 0xd1, 0xb1, 0xa3, 0x6e,
+	0xbf, 0xce, 0x7c, 0x71, 0xda, 0x9b, 0x0c, 0xc7, 0x67, 0x4e, 0x85, 0xec, 0xc0, 0xf6, 0xf9, 0x68,
+	0x7a, 0x3e, 0x1e, 0x9f, 0x4e, 0xce, 0xfa, 0x77, 0x60, 0x4b, 0xed, 0x3e, 0xee, 0x4f, 0xa6, 0xc3,
+	0xe9, 0x59, 0x7f, 0xd4, 0xeb, 0x3b, 0xd5, 0xc3, 0x9f, 0xa1, 0x69, 0x86, 0x36, 0x72, 0xf2, 0x16,
+	0x3a, 0x7d, 0x9d, 0x39, 0x9a, 0x35, 0xf2, 0x64, 0xe5, 0x42, 0x5b, 0x9a, 0xef, 0x8f, 0x1e, 0xdf,
+	0x13, 0xea, 0xee, 0xff, 0xd3, 0x2b, 0x75, 0xcb, 0x5f, 0x97, 0xdf, 0xd5, 0xf5, 0x2f, 0xd3, 0x37,
+	0x7f, 0x0d, 0x00, 0x1e, 0xc9, 0x4c, 0x9e, 0xc1, 0x0a, 0x00, 0x00,
 }
@@ -3,21 +3,38 @@ package persister
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	client "github.com/influxdb/influxdb/client/v2"
 )
 
 // TestPersister is a persister that will save the output to a file
+// Hold its lock to read it while an execution runs: the points of the
+// scenarios, or of a tick and of the end, can be persisted concurrently.
 type TestPersister struct {
+	sync.Mutex
 	GetRequestContent []string
 	LoggingContent    []string
+	// How many points were persisted per table
+	PointCounts map[string]int
+	// How many executions were dropped
+	DroppedIterations int
 }
 
 // Persist TestPersister the data to a file with public permissions
 func (f *TestPersister) Persist(bps client.BatchPoints) error {
 	log.Println(bps)
+	f.Lock()
+	defer f.Unlock()
+	if f.PointCounts == nil {
+		f.PointCounts = make(map[string]int)
+	}
 	for _, point := range bps.Points() {
-		if point.Name() == "GetRequestTable" {
+		f.PointCounts[point.Name()]++
+		if dropped, ok := point.Fields()["dropped"].(int64); ok {
+			f.DroppedIterations += int(dropped)
+		}
+		if point.Name() == "GetRequestTable" || point.Name() == "RequestRollupTable" && point.Tags()["method"] == "GET" {
 			//fmt.Printf("%v\n", point.Fields())
 			data := fmt.Sprintf("%s: %s %d", point.Fields()["id"], point.Fields()["url"], point.Fields()["code"])
 			f.GetRequestContent = append(f.GetRequestContent, data)
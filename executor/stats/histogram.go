@@ -0,0 +1,144 @@
+// Package stats aggregates measurements so that those of many workers, or
+// many executors, can be merged together.
+package stats
+
+import (
+	"math"
+	"time"
+
+	"github.com/lgpeterson/loadtests/executor/pb"
+)
+
+const (
+	// the upper bound of the first bucket
+	firstBucket = time.Microsecond
+	// every bucket is 10% wider than the previous one, which is the worst
+	// error of a quantile
+	bucketGrowth = 1.1
+	// the last bucket starts after ~10 hours
+	maxBuckets = 256
+)
+
+// Histogram counts durations in buckets of exponentially growing width.
+// The zero value is an empty histogram.
+type Histogram struct {
+	counts []int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func bucketOf(d time.Duration) int {
+	if d <= firstBucket {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(d)/float64(firstBucket)) / math.Log(bucketGrowth)))
+	if i >= maxBuckets {
+		return maxBuckets - 1
+	}
+	return i
+}
+
+// upperBound is the longest duration counted by a bucket.
+func upperBound(i int) time.Duration {
+	return time.Duration(float64(firstBucket) * math.Pow(bucketGrowth, float64(i)))
+}
+
+// Record counts a duration.
+func (h *Histogram) Record(d time.Duration) {
+	i := bucketOf(d)
+	if i >= len(h.counts) {
+		counts := make([]int64, i+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Merge adds the durations counted by `other`.
+func (h *Histogram) Merge(other *Histogram) {
+	if len(other.counts) > len(h.counts) {
+		counts := make([]int64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.count += other.count
+	h.sum += other.sum
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count is the number of durations recorded.
+func (h *Histogram) Count() int64 { return h.count }
+
+// Max is the longest duration recorded.
+func (h *Histogram) Max() time.Duration { return h.max }
+
+// Mean is the average of the durations recorded.
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Quantile returns the duration under which a fraction `q` of the recorded
+// durations fall, at most 10% too long.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			if bound := upperBound(i); bound < h.max {
+				return bound
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+// Proto is the histogram as sent between executors and the scheduler.
+func (h *Histogram) Proto() *executorGRPC.Histogram {
+	counts := make([]int64, len(h.counts))
+	copy(counts, h.counts)
+	return &executorGRPC.Histogram{
+		Counts: counts,
+		Sum:    h.sum.Nanoseconds(),
+		Max:    h.max.Nanoseconds(),
+	}
+}
+
+// FromProto is the opposite of Proto.
+func FromProto(p *executorGRPC.Histogram) *Histogram {
+	h := &Histogram{
+		sum: time.Duration(p.Sum),
+		max: time.Duration(p.Max),
+	}
+	if len(p.Counts) > maxBuckets {
+		// from an executor that isn't bucketing the same way, can't be trusted
+		return h
+	}
+	h.counts = make([]int64, len(p.Counts))
+	for i, count := range p.Counts {
+		h.counts[i] = count
+		h.count += count
+	}
+	return h
+}
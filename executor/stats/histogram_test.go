@@ -0,0 +1,72 @@
+package stats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lgpeterson/loadtests/executor/stats"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	var h stats.Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	if h.Count() != 1000 {
+		t.Fatalf("want 1000 durations, got %d", h.Count())
+	}
+	if h.Max() != time.Second {
+		t.Errorf("want max of %v, got %v", time.Second, h.Max())
+	}
+	for _, tt := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, 500 * time.Millisecond},
+		{0.95, 950 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{1, time.Second},
+	} {
+		got := h.Quantile(tt.q)
+		if got < tt.want || float64(got) > float64(tt.want)*1.1 {
+			t.Errorf("quantile %v: want %v (+10%%), got %v", tt.q, tt.want, got)
+		}
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	var all, fast, slow stats.Histogram
+	for i := 1; i <= 100; i++ {
+		d := time.Duration(i) * time.Millisecond
+		all.Record(d)
+		if i <= 50 {
+			fast.Record(d)
+		} else {
+			slow.Record(d)
+		}
+	}
+
+	// merging after going through the wire must lose nothing
+	merged := stats.FromProto(fast.Proto())
+	merged.Merge(stats.FromProto(slow.Proto()))
+
+	if merged.Count() != all.Count() {
+		t.Errorf("want %d durations, got %d", all.Count(), merged.Count())
+	}
+	if merged.Mean() != all.Mean() {
+		t.Errorf("want mean of %v, got %v", all.Mean(), merged.Mean())
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9, 0.99} {
+		if merged.Quantile(q) != all.Quantile(q) {
+			t.Errorf("quantile %v: want %v, got %v", q, all.Quantile(q), merged.Quantile(q))
+		}
+	}
+}
+
+func TestHistogramEmpty(t *testing.T) {
+	var h stats.Histogram
+	if h.Quantile(0.99) != 0 || h.Mean() != 0 || h.Max() != 0 {
+		t.Errorf("want zeroes from an empty histogram")
+	}
+}
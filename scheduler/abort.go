@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// How often the abort conditions of a running load test are evaluated
+var abortInterval = time.Second
+
+// An abort condition isn't judged on too little: until the load test ran for
+// abortMinElapsed, and for the window of the condition, what it measures must
+// have at least abortMinExecutions.
+var (
+	abortMinElapsed          = 10 * time.Second
+	abortMinExecutions int64 = 50
+)
+
+// abortCondition halts a load test as soon as it holds. It's an expression
+// like those of the thresholds, measured over a sliding window or, without
+// one, over the whole run:
+//
+//	error_rate > 5% over 30s
+//	p99(step:login) > 2s over 1m
+//	p99 > 2s
+type abortCondition struct {
+	expr      string
+	condition *threshold
+	// zero for the whole run
+	window time.Duration
+}
+
+func parseAbortCondition(expr string) (*abortCondition, error) {
+	a := &abortCondition{expr: expr}
+	condition := expr
+	// " over " can be part of the condition, like the name of a step, it's
+	// only a window when a duration follows
+	if i := strings.LastIndex(expr, " over "); i >= 0 {
+		if window, err := time.ParseDuration(strings.TrimSpace(expr[i+len(" over "):])); err == nil {
+			if window <= 0 {
+				return nil, fmt.Errorf("abort condition %q: the window must be positive", expr)
+			}
+			a.window, condition = window, expr[:i]
+		}
+	}
+	t, err := parseCondition(strings.TrimSpace(condition))
+	if err != nil {
+		return nil, fmt.Errorf("abort condition %q: %v", expr, err)
+	}
+	a.condition = t
+	return a, nil
+}
+
+func parseAbortConditions(exprs []string) ([]*abortCondition, error) {
+	var conditions []*abortCondition
+	for _, expr := range exprs {
+		a, err := parseAbortCondition(expr)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, a)
+	}
+	return conditions, nil
+}
+
+// abortError is the error of a load test halted by an abort condition.
+type abortError struct {
+	abort *pb.Abort
+}
+
+func (e *abortError) Error() string {
+	return fmt.Sprintf("aborted because %s (observed %s)", e.abort.Condition, e.abort.Observed)
+}
+
+// history keeps the snapshots of a load test for as long as the abort
+// conditions look back.
+type history struct {
+	lock      sync.Mutex
+	keep      time.Duration
+	snapshots []timedSnapshot
+}
+
+type timedSnapshot struct {
+	at   time.Time
+	snap *executorpb.Snapshot
+}
+
+func newHistory(conditions []*abortCondition) *history {
+	h := &history{}
+	for _, a := range conditions {
+		if a.window == 0 {
+			// The whole run is covered by the totals
+			continue
+		}
+		if a.window > h.keep {
+			h.keep = a.window
+		}
+	}
+	return h
+}
+
+// add records a snapshot received at `now`.
+func (h *history) add(now time.Time, snap *executorpb.Snapshot) {
+	if h.keep == 0 {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.snapshots = append(h.snapshots, timedSnapshot{at: now, snap: snap})
+	// The oldest are dropped once outside of every window
+	drop := 0
+	for drop < len(h.snapshots) && now.Sub(h.snapshots[drop].at) > h.keep {
+		drop++
+	}
+	h.snapshots = h.snapshots[drop:]
+}
+
+// since merges the snapshots received since `t`.
+func (h *history) since(t time.Time) *progress {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	merged := newProgress()
+	for _, s := range h.snapshots {
+		if !s.at.Before(t) {
+			merged.add(s.snap)
+		}
+	}
+	return merged
+}
+
+// checkAborts returns the first of the conditions that holds, nil if none
+// does. `totals` covers the whole run, which started `elapsed` ago.
+func checkAborts(conditions []*abortCondition, h *history, totals *progress, now time.Time, elapsed time.Duration) *pb.Abort {
+	for _, a := range conditions {
+		measured, over := totals, elapsed
+		if a.window > 0 {
+			measured = h.since(now.Add(-a.window))
+			if a.window < elapsed {
+				over = a.window
+			}
+		}
+		settled := elapsed >= abortMinElapsed && elapsed >= a.window
+		if !settled && measured.executionCount() < abortMinExecutions {
+			continue
+		}
+		observed, text, ok := a.condition.observe(measured, over)
+		if ok && a.condition.passes(observed) {
+			return &pb.Abort{Condition: a.expr, Observed: text}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+)
+
+func TestParseAbortCondition(t *testing.T) {
+	tests := []struct {
+		expr    string
+		metric  string
+		check   string
+		value   float64
+		window  time.Duration
+		wantErr string
+	}{
+		{expr: "error_rate > 5% over 30s", metric: "error_rate", value: 0.05, window: 30 * time.Second},
+		{expr: "p99(step:login) > 2s over 1m", metric: "p", value: 2, window: time.Minute},
+		{expr: "errors >= 100", metric: "errors", value: 100},
+		// " over " is only a window when a duration follows
+		{expr: "check_rate(game over screen) < 99%", metric: "check_rate", check: "game over screen", value: 0.99},
+		{expr: "check_rate(game over screen) < 99% over 10s", metric: "check_rate", check: "game over screen", value: 0.99, window: 10 * time.Second},
+
+		{expr: "error_rate > 5% over 0s", wantErr: "the window must be positive"},
+		{expr: "error_rate > 5% over -1m", wantErr: "the window must be positive"},
+		{expr: "error_rate > 5% over a minute", wantErr: "invalid syntax"},
+		{expr: "latency > 1s over 1m", wantErr: `unknown metric "latency"`},
+	}
+	for _, tt := range tests {
+		got, err := parseAbortCondition(tt.expr)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), tt.expr) {
+				t.Errorf("%s: want an error with %q, got %v", tt.expr, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		c := got.condition
+		if c.metric != tt.metric || c.check != tt.check || c.value != tt.value || got.window != tt.window {
+			t.Errorf("%s: want %s(%q) %v over %v, got %s(%q) %v over %v", tt.expr,
+				tt.metric, tt.check, tt.value, tt.window, c.metric, c.check, c.value, got.window)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h, _ := parseHistory(t, "errors > 1 over 10s", "errors > 1 over 30s", "errors > 1")
+	if h.keep != 30*time.Second {
+		t.Fatalf("want the longest window kept, got %v", h.keep)
+	}
+	start := time.Unix(0, 0)
+	for _, s := range []int{0, 10, 25, 40} {
+		h.add(start.Add(time.Duration(s)*time.Second), &executorpb.Snapshot{Executions: int64(s)})
+	}
+	tests := []struct {
+		since int
+		want  int64
+	}{
+		// The snapshot at 0s is too old for any window
+		{0, 10 + 25 + 40},
+		{10, 10 + 25 + 40},
+		{11, 25 + 40},
+		{40, 40},
+		{41, 0},
+	}
+	for _, tt := range tests {
+		if got := h.since(start.Add(time.Duration(tt.since) * time.Second)).executionCount(); got != tt.want {
+			t.Errorf("since %ds: want %d executions, got %d", tt.since, tt.want, got)
+		}
+	}
+
+	// Without windows, the totals are enough
+	h, _ = parseHistory(t, "errors > 1")
+	h.add(start, &executorpb.Snapshot{Executions: 1})
+	if got := h.since(start).executionCount(); got != 0 {
+		t.Errorf("want nothing kept without windows, got %d executions", got)
+	}
+}
+
+func parseHistory(t *testing.T, exprs ...string) (*history, []*abortCondition) {
+	conditions, err := parseAbortConditions(exprs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newHistory(conditions), conditions
+}
+
+func TestCheckAborts(t *testing.T) {
+	start := time.Unix(0, 0)
+	at := func(s int) (time.Time, time.Duration) {
+		elapsed := time.Duration(s) * time.Second
+		return start.Add(elapsed), elapsed
+	}
+	snapshot := func(executions, failed int64) *executorpb.Snapshot {
+		return &executorpb.Snapshot{Executions: executions, Requests: executions, FailedRequests: failed, Errors: failed}
+	}
+
+	// A windowed condition only measures the window
+	h, conditions := parseHistory(t, "error_rate > 5% over 30s")
+	totals := newProgress()
+	add := func(s int, snap *executorpb.Snapshot) {
+		now, _ := at(s)
+		h.add(now, snap)
+		totals.add(snap)
+	}
+	add(5, snapshot(100, 100))
+	add(40, snapshot(100, 1))
+	if now, elapsed := at(40); checkAborts(conditions, h, totals, now, elapsed) != nil {
+		t.Error("want the failures out of the window ignored")
+	}
+	add(45, snapshot(100, 20))
+	now, elapsed := at(45)
+	abort := checkAborts(conditions, h, totals, now, elapsed)
+	if abort == nil || abort.Condition != "error_rate > 5% over 30s" || abort.Observed != "10.50%" {
+		t.Errorf("want the load test aborted at 10.50%%, got %v", abort)
+	}
+
+	// A condition isn't judged until there are enough executions, or the
+	// load test ran for long enough, and for its window
+	for _, tt := range []struct {
+		expr       string
+		at         int
+		executions int64
+		want       bool
+	}{
+		{"error_rate > 5%", 1, 10, false},
+		{"error_rate > 5%", 1, 50, true},
+		{"error_rate > 5%", 10, 10, true},
+		{"error_rate > 5% over 30s", 10, 10, false},
+		{"error_rate > 5% over 30s", 29, 10, false},
+		{"error_rate > 5% over 30s", 29, 50, true},
+		{"error_rate > 5% over 30s", 30, 10, true},
+		{"errors > 5", 5, 10, false},
+		{"errors > 5", 11, 10, true},
+	} {
+		h, conditions := parseHistory(t, tt.expr)
+		totals := newProgress()
+		now, elapsed := at(tt.at)
+		snap := snapshot(tt.executions, tt.executions)
+		h.add(now, snap)
+		totals.add(snap)
+		if got := checkAborts(conditions, h, totals, now, elapsed) != nil; got != tt.want {
+			t.Errorf("%s at %ds with %d executions: want aborted %v, got %v", tt.expr, tt.at, tt.executions, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"crypto/subtle"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"github.com/lgpeterson/loadtests/scheduler/rpcauth"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// authenticator stands for the interceptors the vendored grpc doesn't have
+// yet: it authenticates every call to the scheduler service, and checks that
+// the key of the caller allows it, before handing it over to the service.
+// Executors authenticate with the token they were launched with instead.
+type authenticator struct {
+	svc  *Server
+	keys *KeyStore
+}
+
+var _ pb.SchedulerServer = new(authenticator)
+
+// Authenticate serves the scheduler service to the holders of the keys of
+// the store only.
+func (s *Server) Authenticate(keys *KeyStore) pb.SchedulerServer {
+	return &authenticator{svc: s, keys: keys}
+}
+
+type callerKey struct{}
+
+// callerFrom returns the key a call was authenticated with, nil if the
+// scheduler doesn't authenticate its callers.
+func callerFrom(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(callerKey{}).(*APIKey)
+	return key
+}
+
+// authenticate returns the context of a call by the holder of a key with the
+// role.
+func (a *authenticator) authenticate(ctx context.Context, role Role) (context.Context, error) {
+	secret := rpcauth.Credentials(ctx)
+	if secret == "" {
+		return nil, grpc.Errorf(codes.Unauthenticated, "an API key is required")
+	}
+	key, err := a.keys.Lookup(secret)
+	if err != nil {
+		logrus.WithError(err).Error("can't look up API keys")
+		return nil, grpc.Errorf(codes.Internal, "can't look up API keys")
+	}
+	if key == nil {
+		return nil, grpc.Errorf(codes.Unauthenticated, "invalid API key")
+	}
+	if role != "" && !key.Has(role) {
+		return nil, grpc.Errorf(codes.PermissionDenied, "key %q doesn't have the role %q", key.Name, role)
+	}
+	return context.WithValue(ctx, callerKey{}, key), nil
+}
+
+// authorizeOwner checks that the caller can control the load test `id`: it
+// started it, or can do so with the load tests of others.
+func (a *authenticator) authorizeOwner(ctx context.Context, id string) (context.Context, error) {
+	ctx, err := a.authenticate(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	caller := callerFrom(ctx)
+	if caller.Has(RoleCancelOthers) {
+		return ctx, nil
+	}
+	test, ok := a.svc.tests.get(id)
+	if ok && test.Owner != caller.Name {
+		return nil, grpc.Errorf(codes.PermissionDenied, "load test %q was started by another key", id)
+	}
+	// Unknown load tests are reported by the service
+	return ctx, nil
+}
+
+func (a *authenticator) RegisterExecutor(ctx context.Context, req *pb.RegisterExecutorReq) (*pb.RegisterExecutorResp, error) {
+	token := rpcauth.Credentials(ctx)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(a.svc.cfg.ExecutorToken)) != 1 || token == "" {
+		return nil, grpc.Errorf(codes.Unauthenticated, "invalid executor token")
+	}
+	// The commands hold the scripts of the callers, and their secrets
+	if len(req.Certificate) == 0 {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "executors must serve over TLS when callers are authenticated")
+	}
+	return a.svc.RegisterExecutor(ctx, req)
+}
+
+func (a *authenticator) LoadTest(req *pb.LoadTestReq, srv pb.Scheduler_LoadTestServer) error {
+	ctx, err := a.authenticate(srv.Context(), RoleRun)
+	if err != nil {
+		return err
+	}
+	return a.svc.LoadTest(req, &authenticatedStream{Scheduler_LoadTestServer: srv, ctx: ctx})
+}
+
+func (a *authenticator) CancelLoadTest(ctx context.Context, req *pb.CancelLoadTestReq) (*pb.CancelLoadTestResp, error) {
+	ctx, err := a.authorizeOwner(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return a.svc.CancelLoadTest(ctx, req)
+}
+
+func (a *authenticator) ControlLoadTest(ctx context.Context, req *pb.ControlLoadTestReq) (*pb.ControlLoadTestResp, error) {
+	ctx, err := a.authorizeOwner(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return a.svc.ControlLoadTest(ctx, req)
+}
+
+func (a *authenticator) ListLoadTests(ctx context.Context, req *pb.ListLoadTestsReq) (*pb.ListLoadTestsResp, error) {
+	ctx, err := a.authenticate(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return a.svc.ListLoadTests(ctx, req)
+}
+
+func (a *authenticator) GetLoadTest(ctx context.Context, req *pb.GetLoadTestReq) (*pb.GetLoadTestResp, error) {
+	ctx, err := a.authenticate(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return a.svc.GetLoadTest(ctx, req)
+}
+
+// authenticatedStream is a load test stream whose context holds the key of
+// the caller.
+type authenticatedStream struct {
+	pb.Scheduler_LoadTestServer
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context { return s.ctx }
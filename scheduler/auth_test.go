@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuthenticate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "keys.json")
+
+	store, err := OpenKeyStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]string)
+	for name, roles := range map[string][]Role{
+		"alice": {RoleRun},
+		"bob":   {RoleRun},
+		"ops":   {RoleCancelOthers},
+		"root":  {RoleAdmin},
+	} {
+		if keys[name], err = store.Add(name, roles); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Add("alice", []Role{RoleRun}); err == nil {
+		t.Error("want an error adding a key named like another")
+	}
+	if _, err := store.Add("eve", []Role{"root"}); err == nil {
+		t.Error("want an error adding a key with an unknown role")
+	}
+
+	svc := NewServer(&Config{ExecutorToken: "executor-token"}, nil)
+	auth := svc.Authenticate(store)
+	as := func(name string) context.Context {
+		key := keys[name]
+		if key == "" {
+			key = name
+		}
+		return metadata.NewContext(context.Background(), metadata.Pairs("authorization", "Bearer "+key))
+	}
+	cancel := func(ctx context.Context, test *loadTest) error {
+		_, err := auth.CancelLoadTest(ctx, &pb.CancelLoadTestReq{Id: test.info.Id})
+		return err
+	}
+	check := func(what string, err error, want codes.Code) {
+		if grpc.Code(err) != want {
+			t.Errorf("%s: want %v, got %v", what, want, err)
+		}
+	}
+
+	_, err = auth.ListLoadTests(context.Background(), &pb.ListLoadTestsReq{})
+	check("without a key", err, codes.Unauthenticated)
+	_, err = auth.ListLoadTests(as("not-a-key"), &pb.ListLoadTestsReq{})
+	check("with an invalid key", err, codes.Unauthenticated)
+	_, err = auth.RegisterExecutor(as("alice"), &pb.RegisterExecutorReq{})
+	check("registering an executor with a key", err, codes.Unauthenticated)
+	_, err = auth.RegisterExecutor(as("executor-token"), &pb.RegisterExecutorReq{})
+	check("registering an executor that doesn't serve over TLS", err, codes.FailedPrecondition)
+	err = auth.LoadTest(&pb.LoadTestReq{}, &authenticatedStream{ctx: as("ops")})
+	check("running a load test without the role", err, codes.PermissionDenied)
+
+	// Not even an admin load tests a target that wasn't verified
+	svc.targets = newTargetVerifier("s3cr3t", nil, time.Hour, clock.NewMock())
+	svc.targets.lookupTXT = func(string) ([]string, error) { return nil, fmt.Errorf("no such host") }
+	err = auth.LoadTest(&pb.LoadTestReq{Url: "http://127.0.0.1:1"}, &authenticatedStream{ctx: as("root")})
+	if err == nil || !strings.Contains(err.Error(), "can't verify") {
+		t.Errorf("running a load test of an unverified target as an admin: want it refused, got %v", err)
+	}
+	svc.targets = nil
+
+	tests := make([]*loadTest, 4)
+	for i := range tests {
+		tests[i] = svc.tests.add(&pb.LoadTestReq{ScriptName: "script"}, func() {}, "alice")
+	}
+	check("cancelling a load test of another key", cancel(as("bob"), tests[0]), codes.PermissionDenied)
+	check("cancelling its own load test", cancel(as("alice"), tests[0]), codes.OK)
+	check("cancelling the load tests of others", cancel(as("ops"), tests[1]), codes.OK)
+	check("cancelling as an admin", cancel(as("root"), tests[2]), codes.OK)
+
+	// Keys removed from the file are revoked right away
+	var stored []*APIKey
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	var kept []*APIKey
+	for _, key := range stored {
+		if key.Name != "alice" {
+			kept = append(kept, key)
+		}
+	}
+	if data, err = json.Marshal(kept); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	check("using a revoked key", cancel(as("alice"), tests[3]), codes.Unauthenticated)
+
+	res, err := auth.ListLoadTests(as("bob"), &pb.ListLoadTestsReq{})
+	check("listing the load tests", err, codes.OK)
+	if err == nil && (len(res.LoadTests) != 4 || res.LoadTests[0].Owner != "alice") {
+		t.Errorf("want the 4 load tests of alice, got %v", res.LoadTests)
+	}
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// parseDataFeed turns the file of a data feed into records, JSON objects the
+// executors hand to the scripts as they are. Returns nil without a feed.
+func parseDataFeed(feed *pb.DataFeed) (*executorpb.DataFeed, error) {
+	if feed == nil {
+		return nil, nil
+	}
+	var (
+		records []string
+		err     error
+	)
+	switch feed.Format {
+	case pb.DataFeed_CSV:
+		records, err = parseCSV(feed.Content)
+	case pb.DataFeed_NDJSON:
+		records, err = parseNDJSON(feed.Content)
+	default:
+		err = fmt.Errorf("unknown format %v", feed.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("data feed: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("data feed: no records")
+	}
+	return &executorpb.DataFeed{
+		Records: records,
+		Mode:    executorpb.DataFeed_Mode(feed.Mode),
+	}, nil
+}
+
+// parseCSV names the fields of the records after the header row.
+func parseCSV(content []byte) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var records []string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		record := make(map[string]string, len(header))
+		for i, name := range header {
+			record[name] = row[i]
+		}
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, string(encoded))
+	}
+}
+
+func parseNDJSON(content []byte) ([]string, error) {
+	var records []string
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("line %d isn't a JSON object: %v", i+1, err)
+		}
+		records = append(records, string(line))
+	}
+	return records, nil
+}
+
+// partitionDataFeed splits the records in `n` parts of about the same size,
+// in order. When there are fewer records than parts, every part has all of
+// them unless each record must only be used once.
+func partitionDataFeed(feed *executorpb.DataFeed, n int) []*executorpb.DataFeed {
+	parts := make([]*executorpb.DataFeed, n)
+	if feed == nil {
+		return parts
+	}
+	records := feed.Records
+	for i := range parts {
+		part := &executorpb.DataFeed{Mode: feed.Mode}
+		if len(records) < n && feed.Mode != executorpb.DataFeed_UNIQUE {
+			part.Records = records
+		} else {
+			part.Records = records[i*len(records)/n : (i+1)*len(records)/n]
+		}
+		parts[i] = part
+	}
+	return parts
+}
@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			content: "user,pass\nann,a\nbob,\"b,2\"\n",
+			want:    []string{`{"pass":"a","user":"ann"}`, `{"pass":"b,2","user":"bob"}`},
+		},
+		// A header alone has no records
+		{content: "user,pass\n"},
+		{content: ""},
+		{content: "user,pass\nann\n", wantErr: "wrong number of fields"},
+		{content: "user\n\"ann\n", wantErr: "extraneous or missing"},
+	}
+	for _, tt := range tests {
+		got, err := parseCSV([]byte(tt.content))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: want an error with %q, got %v", tt.content, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.content, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%q: want %v, got %v", tt.content, tt.want, got)
+		}
+	}
+}
+
+func TestParseNDJSON(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			content: "{\"user\": \"ann\", \"age\": 30}\n\n  {\"user\": \"bob\", \"tags\": [1]}  \n",
+			want:    []string{`{"user": "ann", "age": 30}`, `{"user": "bob", "tags": [1]}`},
+		},
+		{content: "\n\n"},
+		{content: "{\"user\": \"ann\"}\n[1, 2]\n", wantErr: "line 2 isn't a JSON object"},
+		{content: "{\"user\": \n", wantErr: "line 1 isn't a JSON object"},
+	}
+	for _, tt := range tests {
+		got, err := parseNDJSON([]byte(tt.content))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: want an error with %q, got %v", tt.content, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.content, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%q: want %v, got %v", tt.content, tt.want, got)
+		}
+	}
+}
+
+func TestParseDataFeed(t *testing.T) {
+	if feed, err := parseDataFeed(nil); feed != nil || err != nil {
+		t.Errorf("want no feed, got %v, %v", feed, err)
+	}
+	feed, err := parseDataFeed(&pb.DataFeed{Format: pb.DataFeed_CSV, Content: []byte("user\nann\n"), Mode: pb.DataFeed_UNIQUE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Records) != 1 || feed.Mode != executorpb.DataFeed_UNIQUE {
+		t.Errorf("want the record handed out once, got %v", feed)
+	}
+	if _, err := parseDataFeed(&pb.DataFeed{Format: pb.DataFeed_NDJSON, Content: []byte("\n")}); err == nil || !strings.Contains(err.Error(), "no records") {
+		t.Errorf("want an error without records, got %v", err)
+	}
+}
+
+func TestPartitionDataFeed(t *testing.T) {
+	records := func(n int) []string {
+		var records []string
+		for i := 0; i < n; i++ {
+			records = append(records, fmt.Sprint(i))
+		}
+		return records
+	}
+	tests := []struct {
+		records int
+		mode    executorpb.DataFeed_Mode
+		parts   int
+		want    string
+	}{
+		{7, executorpb.DataFeed_SEQUENTIAL, 3, "[[0 1] [2 3] [4 5 6]]"},
+		{6, executorpb.DataFeed_UNIQUE, 3, "[[0 1] [2 3] [4 5]]"},
+		{3, executorpb.DataFeed_RANDOM, 1, "[[0 1 2]]"},
+		// Too few records to go around: all of them are shared, unless
+		// each must only be used once
+		{2, executorpb.DataFeed_SEQUENTIAL, 3, "[[0 1] [0 1] [0 1]]"},
+		{2, executorpb.DataFeed_UNIQUE, 3, "[[] [0] [1]]"},
+	}
+	for _, tt := range tests {
+		parts := partitionDataFeed(&executorpb.DataFeed{Records: records(tt.records), Mode: tt.mode}, tt.parts)
+		var got [][]string
+		for _, part := range parts {
+			if part.Mode != tt.mode {
+				t.Errorf("want the mode kept, got %v", part.Mode)
+			}
+			got = append(got, part.Records)
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%d %v records in %d parts: want %s, got %v", tt.records, tt.mode, tt.parts, tt.want, got)
+		}
+	}
+
+	if parts := partitionDataFeed(nil, 2); len(parts) != 2 || parts[0] != nil || parts[1] != nil {
+		t.Errorf("want no feed for any part, got %v", parts)
+	}
+}
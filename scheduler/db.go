@@ -2,70 +2,43 @@ package scheduler
 
 import (
 	"fmt"
-	"github.com/Sirupsen/logrus"
 	"math/rand"
-	"strings"
 	"sync"
 	"time"
 
-	"github.com/digitalocean/godo"
+	"github.com/Sirupsen/logrus"
 	pb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/scheduler/rpcauth"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 )
 
-const (
-	executorPrefix = "executor"
-	bootSequence   = `#!/usr/bin/env bash
-
-echo "the boot script started" > /tmp/bootscript.log
-mkdir -p /opt
-curl %q > /opt/executord
-chmod +x /opt/executord
-
-cat > /etc/systemd/system/load_executor.service <<EOF
-[Unit]
-Description=Load executor service
-
-[Service]
-ExecStart=/opt/executord -scheduler_addr %q
-Restart=always
-RestartSec=1
-
-[Install]
-WantedBy=multi-user.target
-EOF
-
-systemctl enable load_executor.service
-systemctl start load_executor.service
-`
-)
+// controlTimeout is how long the executors have to answer a control.
+const controlTimeout = 10 * time.Second
 
 type DB struct {
-	cfg          *Config
-	cloud        *godo.Client
-	lock         sync.Mutex
-	waitDroplets map[int]chan<- int
+	cfg           *Config
+	provider      Provider
+	lock          sync.Mutex
+	waitExecutors map[int]chan<- registration
+}
+
+// registration is what an executor tells when it joins.
+type registration struct {
+	port    int
+	version pb.ProtocolVersion
+	// the certificate the executor serves with, nil if it doesn't use TLS
+	certificate []byte
 }
 
-func NewDB(cfg *Config, cloud *godo.Client) (*DB, error) {
+func NewDB(cfg *Config, provider Provider) (*DB, error) {
 
 	// cleanup any executors that are still running, if we crashed
-	droplets, _, err := cloud.Droplets.List(&godo.ListOptions{})
-	if err != nil {
+	if err := provider.Cleanup(); err != nil {
 		return nil, err
 	}
-	for _, droplet := range droplets {
-		if !strings.HasPrefix(droplet.Name, executorPrefix) {
-			continue
-		}
-		_, err := cloud.Droplets.Delete(droplet.ID)
-		if err != nil {
-			return nil, err
-		}
-	}
 
-	return &DB{cfg: cfg, cloud: cloud, waitDroplets: make(map[int]chan<- int)}, nil
+	return &DB{cfg: cfg, provider: provider, waitExecutors: make(map[int]chan<- registration)}, nil
 }
 
 func (db *DB) LaunchExecutors(ctx context.Context, count int) (*executors, error) {
@@ -84,67 +57,57 @@ func (db *DB) LaunchExecutors(ctx context.Context, count int) (*executors, error
 		go func(id int) {
 			defer wg.Done()
 
-			req := &godo.DropletCreateRequest{
-				Name:              fmt.Sprintf("%s.%s.%d", executorPrefix, suffix, id),
-				SSHKeys:           db.cfg.SSHKeyIDs,
-				PrivateNetworking: true,
-				Region:            db.cfg.DropletRegion,
-				Size:              db.cfg.DropletSize,
-				UserData: fmt.Sprintf(bootSequence,
-					db.cfg.PullExecutorBinaryURL,
-					db.cfg.AdvertiseListenAddr,
-				),
-				Image: godo.DropletCreateImage{Slug: db.cfg.DropletImageSlug},
-			}
+			name := fmt.Sprintf("%s.%s.%d", executorPrefix, suffix, id)
 
 			db.lock.Lock()
-			droplet, _, err := db.cloud.Droplets.Create(req)
+			executorID, err := db.provider.Launch(name)
 			if err != nil {
 				defer db.lock.Unlock()
 				errc <- err
 				return
 			}
-			portc := make(chan int, 1)
-			db.waitDroplets[droplet.ID] = portc
+			registrationc := make(chan registration, 1)
+			db.waitExecutors[executorID] = registrationc
 			db.lock.Unlock()
 			defer func() {
 				db.lock.Lock()
-				delete(db.waitDroplets, droplet.ID)
+				delete(db.waitExecutors, executorID)
 				db.lock.Unlock()
 			}()
 
 			select {
-			case port := <-portc:
-				droplet, _, err := db.cloud.Droplets.Get(droplet.ID)
+			case reg := <-registrationc:
+				port := reg.port
+				host, err := db.provider.Inspect(executorID)
 				if err != nil {
 					logrus.WithError(err).WithFields(logrus.Fields{
-						"port":       port,
-						"droplet.id": droplet.ID,
+						"port":        port,
+						"executor.id": executorID,
 					}).Error("failed to retrieve details about executor")
 					errc <- err
 					return
 				}
 
-				ip, found := ipv4PublicAddress(droplet)
-				if !found {
-					errc <- fmt.Errorf("no public IPv4 found on droplet %d", droplet.ID)
-					return
-				}
-
 				logrus.WithFields(logrus.Fields{
-					"port":       port,
-					"droplet.id": droplet.ID,
-					"droplet.ip": ip,
+					"port":          port,
+					"executor.id":   executorID,
+					"executor.host": host,
+					"protocol":      reg.version,
+					"tls":           reg.certificate != nil,
 				}).Info("executor joined")
 				executorc <- &executor{
-					cloud:   db.cloud,
-					droplet: droplet,
-					ip:      ip,
-					port:    port,
+					provider:    db.provider,
+					id:          executorID,
+					host:        host,
+					port:        port,
+					version:     reg.version,
+					certificate: reg.certificate,
+					token:       db.cfg.ExecutorToken,
+					answers:     make(chan error, 1),
 				}
 			case <-ctx.Done():
 				logrus.WithFields(logrus.Fields{
-					"droplet.id": droplet.ID,
+					"executor.id": executorID,
 				}).Info("timedout waiting for executor")
 			}
 		}(i)
@@ -171,58 +134,71 @@ func (db *DB) LaunchExecutors(ctx context.Context, count int) (*executors, error
 	}
 }
 
-func ipv4PublicAddress(droplet *godo.Droplet) (string, bool) {
-	if droplet.Networks == nil {
-		return "", false
-	}
-	for _, network := range droplet.Networks.V4 {
-		switch network.Type {
-		case "public":
-			return network.IPAddress, true
-		}
-	}
-	return "", false
-}
-
-func (db *DB) RegisterExecutorUp(dropletID int, port int) error {
+// RegisterExecutorUp lets a launched executor join, it speaks the version of
+// the protocol of `reg`, V1 if unspecified.
+func (db *DB) RegisterExecutorUp(executorID int, reg registration) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 	ll := logrus.WithFields(logrus.Fields{
-		"droplet.id": dropletID,
-		"port":       port,
+		"executor.id": executorID,
+		"port":        reg.port,
 	})
 	ll.Info("executor is registering")
-	wait, ok := db.waitDroplets[dropletID]
+	wait, ok := db.waitExecutors[executorID]
 	if !ok {
 		ll.Warn("unexpected executor attempted to join")
-		_, err := db.cloud.Droplets.Delete(dropletID)
-		return fmt.Errorf("unexpected droplet %d registered, delete request sent: %v", dropletID, err)
+		err := db.provider.Destroy(executorID)
+		return fmt.Errorf("unexpected executor %d registered, destroy request sent: %v", executorID, err)
+	}
+	if reg.version == pb.ProtocolVersion_UNSPECIFIED {
+		reg.version = pb.ProtocolVersion_V1
 	}
-	wait <- port
+	wait <- reg
 	return nil
 }
 
 type executor struct {
-	cloud   *godo.Client
-	droplet *godo.Droplet
-	ip      string
-	port    int
-	client  pb.CommanderClient
+	provider Provider
+	id       int
+	host     string
+	port     int
+	client   pb.CommanderClient
+	// the version of the protocol the executor speaks
+	version pb.ProtocolVersion
+	// the executor is only trusted to serve with this certificate, if set
+	certificate []byte
+	// the token the commands present to the executor, if set
+	token string
 
 	// set when there's an ongoing command execution
 	cmdClient pb.Commander_ExecuteCommandClient
+	// the answers to the controls, read with the statuses of the execution
+	answers chan error
 }
 
 func (e *executor) waitTilAlive(ctx context.Context) error {
 	if e.client != nil {
 		return nil
 	}
-	url := fmt.Sprintf("%s:%d", e.ip, e.port)
+	url := fmt.Sprintf("%s:%d", e.host, e.port)
 	ll := logrus.WithFields(logrus.Fields{
-		"droplet.id":   e.droplet.ID,
-		"droplet.ip":   e.ip,
+		"executor.id":  e.id,
 		"executor.url": url,
 	})
+	opts := []grpc.DialOption{grpc.WithBlock(), grpc.WithTimeout(time.Second)}
+	if e.certificate != nil {
+		creds, err := rpcauth.PinnedTLS(e.certificate)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+		// The executors registered with a token require it of the commands
+		if e.token != "" {
+			opts = append(opts, grpc.WithPerRPCCredentials(rpcauth.BearerToken(e.token)))
+		}
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
 	ll.Info("attempting to dial executor service")
 	for {
 		if e.client != nil {
@@ -234,7 +210,7 @@ func (e *executor) waitTilAlive(ctx context.Context) error {
 			return ctx.Err()
 		default:
 		}
-		cc, err := grpc.Dial(url, grpc.WithBlock(), grpc.WithTimeout(time.Second), grpc.WithInsecure())
+		cc, err := grpc.Dial(url, opts...)
 		switch err {
 		case grpc.ErrClientConnTimeout:
 			ll.Info("timed out...")
@@ -249,36 +225,28 @@ func (e *executor) waitTilAlive(ctx context.Context) error {
 }
 
 type executors struct {
-	cloud     *godo.Client
 	executors []*executor
 }
 
 func (e *executors) killall() error {
 	logrus.WithField("count", len(e.executors)).Info("killing all")
 	return e.each(context.Background(), func(ctx context.Context, exec *executor) error {
-		logrus.WithField("droplet.id", exec.droplet.ID).Info("destroying executor")
-		_, err := exec.cloud.Droplets.Delete(exec.droplet.ID)
-		return err
+		logrus.WithField("executor.id", exec.id).Info("destroying executor")
+		return exec.provider.Destroy(exec.id)
 	})
 }
 
-func (e *executors) executeCommand(
-	parent context.Context,
-	url string,
-	script string,
-	scriptID string,
-	runtime int32,
-	maxWorkers int32,
-	growthFactor float64,
-	timeBetweenGrowth float64,
-	startingRPS int32,
-	maxRPS int32,
-	scriptConfig string,
-) error {
+// executeCommand runs the load test described by `params` on every executor,
+// the rates of `params`, and of its scenarios, are shared between them.
+func (e *executors) executeCommand(parent context.Context, params *pb.ScriptParams, scriptConfig string) error {
+	commands := make(map[*executor]*pb.ScriptParams, len(e.executors))
+	for i, command := range e.commands(params) {
+		commands[e.executors[i]] = command
+	}
 	return e.each(parent, func(ctx context.Context, exec *executor) error {
 		ll := logrus.WithFields(logrus.Fields{
-			"droplet.id": exec.droplet.ID,
-			"port":       exec.port,
+			"executor.id": exec.id,
+			"port":        exec.port,
 		})
 		ll.Info("waiting til executor is alive")
 		if err := exec.waitTilAlive(ctx); err != nil {
@@ -295,27 +263,17 @@ func (e *executors) executeCommand(
 			exec.cmdClient = cmdCLient
 		}
 
-		in := &pb.CommandMessage{
-			Command: "Run",
-			ScriptParams: &pb.ScriptParams{
-				Url:                       url,
-				Script:                    script,
-				ScriptId:                  scriptID,
-				RunTime:                   runtime,
-				MaxWorkers:                maxWorkers,
-				GrowthFactor:              growthFactor,
-				TimeBetweenGrowth:         timeBetweenGrowth,
-				StartingRequestsPerSecond: startingRPS / int32(len(e.executors)),
-				MaxRequestsPerSecond:      maxRPS / int32(len(e.executors)),
-			},
-			ScriptConfig: scriptConfig,
-		}
+		shared := commands[exec]
 		ll = ll.WithFields(logrus.Fields{
-			"script_params": in.GetScriptParams(),
+			"script_params": loggable(shared),
+			"protocol":      exec.version,
 		})
+		if params.DataFeed != nil {
+			ll = ll.WithField("data_feed.records", feedRecords(shared))
+		}
 
 		ll.Info("sending commands to executor")
-		err := exec.cmdClient.Send(in)
+		err := exec.run(shared, scriptConfig)
 		if err != nil {
 			ll.WithError(err).Error("couldn't send RUN command")
 		}
@@ -323,19 +281,123 @@ func (e *executors) executeCommand(
 	})
 }
 
+// commands are the parts of the command `params` every executor runs. Every
+// executor gets its own part of the data feed. Its scenarios all read that
+// part, unless each record must only be used once: then every scenario of
+// every executor gets its own.
+func (e *executors) commands(params *pb.ScriptParams) []*pb.ScriptParams {
+	perExecutor := 1
+	if params.DataFeed != nil && params.DataFeed.Mode == pb.DataFeed_UNIQUE && len(params.Scenarios) > 0 {
+		perExecutor = len(params.Scenarios)
+	}
+	feeds := partitionDataFeed(params.DataFeed, len(e.executors)*perExecutor)
+
+	commands := make([]*pb.ScriptParams, len(e.executors))
+	for i := range e.executors {
+		own := feeds[i*perExecutor : (i+1)*perExecutor]
+		if len(params.Scenarios) == 0 {
+			commands[i] = e.share(params, i, own[0])
+			continue
+		}
+		// the scenarios read the data feed, not the load test
+		commands[i] = e.share(params, i, nil)
+		for j, scenario := range params.Scenarios {
+			commands[i].Scenarios = append(commands[i].Scenarios, &pb.Scenario{
+				Name:         scenario.Name,
+				Params:       e.share(scenario.Params, i, own[j%perExecutor]),
+				ScriptConfig: scenario.ScriptConfig,
+			})
+		}
+	}
+	return commands
+}
+
+// feedRecords is how many records of the data feed the command has, all its
+// scenarios together.
+func feedRecords(params *pb.ScriptParams) int {
+	var records int
+	if params.DataFeed != nil {
+		records = len(params.DataFeed.Records)
+	}
+	for _, scenario := range params.Scenarios {
+		records += feedRecords(scenario.Params)
+	}
+	return records
+}
+
+// share is the part of the command `params` the i-th executor runs, with the
+// part `feed` of the data feed.
+func (e *executors) share(params *pb.ScriptParams, i int, feed *pb.DataFeed) *pb.ScriptParams {
+	share := func(rps int32) int32 { return shareOf(rps, len(e.executors), i) }
+	stages := make([]*pb.Stage, 0, len(params.Stages))
+	for _, stage := range params.Stages {
+		stages = append(stages, &pb.Stage{
+			Duration:                stage.Duration,
+			TargetRequestsPerSecond: share(stage.TargetRequestsPerSecond),
+			Interpolation:           stage.Interpolation,
+		})
+	}
+	return &pb.ScriptParams{
+		Url:                       params.Url,
+		Script:                    params.Script,
+		ScriptId:                  params.ScriptId,
+		RunTime:                   params.RunTime,
+		MaxWorkers:                params.MaxWorkers,
+		GrowthFactor:              params.GrowthFactor,
+		TimeBetweenGrowth:         params.TimeBetweenGrowth,
+		StartingRequestsPerSecond: share(params.StartingRequestsPerSecond),
+		MaxRequestsPerSecond:      share(params.MaxRequestsPerSecond),
+		KeepCookies:               params.KeepCookies,
+		OpenModel:                 params.OpenModel,
+		Rollups:                   params.Rollups,
+		Stages:                    stages,
+		DataFeed:                  feed,
+		SetupData:                 params.SetupData,
+		AllowedHosts:              params.AllowedHosts,
+	}
+}
+
+// shareOf is the part of `total` the i-th of n executors gets: the first ones
+// get one more each when it can't be divided evenly, so that the parts add up
+// to it.
+func shareOf(total int32, n, i int) int32 {
+	share := total / int32(n)
+	if int32(i) < total%int32(n) {
+		share++
+	}
+	return share
+}
+
+// loggable is a command without what is too long, or too secret, to be
+// logged: the records of the data feed, and like them the setup data can
+// hold credentials.
+func loggable(params *pb.ScriptParams) *pb.ScriptParams {
+	logged := *params
+	logged.DataFeed = nil
+	logged.SetupData = ""
+	logged.Scenarios = nil
+	for _, scenario := range params.Scenarios {
+		logged.Scenarios = append(logged.Scenarios, &pb.Scenario{
+			Name:         scenario.Name,
+			Params:       loggable(scenario.Params),
+			ScriptConfig: scenario.ScriptConfig,
+		})
+	}
+	return &logged
+}
+
 func (e *executors) haltCommand(parent context.Context) error {
 	return e.each(parent, func(ctx context.Context, exec *executor) error {
 		ll := logrus.WithFields(logrus.Fields{
-			"droplet.id": exec.droplet.ID,
-			"port":       exec.port,
+			"executor.id": exec.id,
+			"port":        exec.port,
 		})
 		if exec.cmdClient == nil {
 			return fmt.Errorf("nothing to halt")
 		}
 
-		in := &pb.CommandMessage{Command: "Halt"}
 		ll.Info("sending commands to executor")
-		err := exec.cmdClient.Send(in)
+		err := exec.halt()
 		if err != nil {
 			ll.WithError(err).Error("couldn't send halt command")
 		}
@@ -343,22 +405,63 @@ func (e *executors) haltCommand(parent context.Context) error {
 	})
 }
 
-func (e *executors) waitCompletion(parent context.Context) error {
+// controlCommand sends its control to every executor, they must all speak V2
+// of the protocol. It fails if any executor rejects it, or doesn't answer
+// within controlTimeout.
+func (e *executors) controlCommand(parent context.Context, cmds []*pb.CommandMessage) error {
+	for _, exec := range e.executors {
+		if exec.version < pb.ProtocolVersion_V2 {
+			return fmt.Errorf("executor %d speaks %v of the protocol, it can't be controlled", exec.id, exec.version)
+		}
+	}
+	commands := make(map[*executor]*pb.CommandMessage, len(e.executors))
+	for i, exec := range e.executors {
+		commands[exec] = cmds[i]
+	}
+	ctx, cancel := context.WithTimeout(parent, controlTimeout)
+	defer cancel()
+	return e.each(ctx, func(ctx context.Context, exec *executor) error {
+		ll := logrus.WithFields(logrus.Fields{
+			"executor.id": exec.id,
+			"port":        exec.port,
+		})
+		if exec.cmdClient == nil {
+			return fmt.Errorf("nothing to control")
+		}
+
+		// An answer to a previous control that came too late isn't the
+		// answer to this one
+		select {
+		case <-exec.answers:
+		default:
+		}
+		cmd := commands[exec]
+		ll.WithField("command", cmd).Info("sending commands to executor")
+		if err := exec.cmdClient.Send(cmd); err != nil {
+			ll.WithError(err).Error("couldn't send control command")
+			return err
+		}
+		select {
+		case err := <-exec.answers:
+			return err
+		case <-ctx.Done():
+			return fmt.Errorf("executor %d didn't answer the control: %v", exec.id, ctx.Err())
+		}
+	})
+}
+
+// waitCompletion waits for every executor to be done, the snapshots they
+// send meanwhile are given to `onSnapshot`.
+func (e *executors) waitCompletion(parent context.Context, onSnapshot func(*pb.Snapshot)) error {
 	return e.each(parent, func(ctx context.Context, exec *executor) error {
 		ll := logrus.WithFields(logrus.Fields{
-			"droplet.id": exec.droplet.ID,
-			"port":       exec.port,
+			"executor.id": exec.id,
+			"port":        exec.port,
 		})
 		if exec.cmdClient == nil {
 			return fmt.Errorf("no execution running")
 		}
-		res, err := exec.cmdClient.Recv()
-		if err != nil {
-			ll.WithError(err).Error("couldn't wait to receive status")
-		} else {
-			ll.WithField("status", res.Status).Info("execution completed")
-		}
-		return err
+		return exec.waitCompletion(ll, onSnapshot)
 	})
 }
 
@@ -371,7 +474,7 @@ func (e *executors) each(parent context.Context, fn func(ctx context.Context, ex
 		wg.Add(1)
 		go func(exec *executor) {
 			defer wg.Done()
-			logrus.WithField("droplet.id", exec.droplet.ID).Debug("request to executor")
+			logrus.WithField("executor.id", exec.id).Debug("request to executor")
 			if err := fn(ctx, exec); err != nil {
 				errc <- err
 			}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	pb "github.com/lgpeterson/loadtests/executor/pb"
+)
+
+func TestShare(t *testing.T) {
+	params := &pb.ScriptParams{
+		StartingRequestsPerSecond: 10,
+		MaxRequestsPerSecond:      100,
+		Stages: []*pb.Stage{
+			{Duration: 60, TargetRequestsPerSecond: 7},
+			{Duration: 60, TargetRequestsPerSecond: 2},
+		},
+	}
+	tests := []struct {
+		executors int
+		// the starting rate, max rate and stage targets of every executor
+		want [][]int32
+	}{
+		{1, [][]int32{{10, 100, 7, 2}}},
+		{2, [][]int32{{5, 50, 4, 1}, {5, 50, 3, 1}}},
+		{3, [][]int32{{4, 34, 3, 1}, {3, 33, 2, 1}, {3, 33, 2, 0}}},
+		{4, [][]int32{{3, 25, 2, 1}, {3, 25, 2, 1}, {2, 25, 2, 0}, {2, 25, 1, 0}}},
+	}
+	for _, tt := range tests {
+		e := &executors{executors: make([]*executor, tt.executors)}
+		for i, want := range tt.want {
+			shared := e.share(params, i, nil)
+			got := []int32{shared.StartingRequestsPerSecond, shared.MaxRequestsPerSecond}
+			for _, stage := range shared.Stages {
+				got = append(got, stage.TargetRequestsPerSecond)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%d executors, #%d: want %v, got %v", tt.executors, i, want, got)
+			}
+			for j := range want {
+				if got[j] != want[j] {
+					t.Errorf("%d executors, #%d: want %v, got %v", tt.executors, i, want, got)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestCommandsDataFeed(t *testing.T) {
+	scenarios := []*pb.Scenario{
+		{Name: "browse", Params: &pb.ScriptParams{MaxRequestsPerSecond: 20}},
+		{Name: "search", Params: &pb.ScriptParams{MaxRequestsPerSecond: 10}},
+	}
+	tests := []struct {
+		mode      pb.DataFeed_Mode
+		scenarios []*pb.Scenario
+		// the records of every executor, or of every scenario of every
+		// executor
+		want string
+	}{
+		{pb.DataFeed_SEQUENTIAL, nil, "[[0 1 2] [3 4 5]]"},
+		{pb.DataFeed_UNIQUE, nil, "[[0 1 2] [3 4 5]]"},
+		// The scenarios of an executor read the same part, unless each
+		// record must only be used once
+		{pb.DataFeed_RANDOM, scenarios, "[[[0 1 2] [0 1 2]] [[3 4 5] [3 4 5]]]"},
+		{pb.DataFeed_UNIQUE, scenarios, "[[[0] [1 2]] [[3] [4 5]]]"},
+	}
+	for _, tt := range tests {
+		params := &pb.ScriptParams{
+			MaxRequestsPerSecond: 30,
+			Scenarios:            tt.scenarios,
+			DataFeed:             &pb.DataFeed{Records: []string{"0", "1", "2", "3", "4", "5"}, Mode: tt.mode},
+		}
+		e := &executors{executors: make([]*executor, 2)}
+		var got []interface{}
+		for _, command := range e.commands(params) {
+			if len(tt.scenarios) == 0 {
+				got = append(got, command.DataFeed.Records)
+				continue
+			}
+			if command.DataFeed != nil {
+				t.Errorf("%v: want the feed given to the scenarios only", tt.mode)
+			}
+			var scenarios [][]string
+			for _, scenario := range command.Scenarios {
+				if scenario.Params.DataFeed.Mode != tt.mode {
+					t.Errorf("%v: want the mode kept, got %v", tt.mode, scenario.Params.DataFeed.Mode)
+				}
+				scenarios = append(scenarios, scenario.Params.DataFeed.Records)
+			}
+			got = append(got, scenarios)
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%v with %d scenarios: want %s, got %v", tt.mode, len(tt.scenarios), tt.want, got)
+		}
+	}
+}
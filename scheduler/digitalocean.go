@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+const bootSequence = `#!/usr/bin/env bash
+
+echo "the boot script started" > /tmp/bootscript.log
+mkdir -p /opt
+curl %q > /opt/executord
+chmod +x /opt/executord
+mkdir -p /etc/loadtests
+%s
+cat > /etc/systemd/system/load_executor.service <<EOF
+[Unit]
+Description=Load executor service
+
+[Service]
+ExecStart=/opt/executord -scheduler_addr %q%s
+Restart=always
+RestartSec=1
+
+[Install]
+WantedBy=multi-user.target
+EOF
+
+systemctl enable load_executor.service
+systemctl start load_executor.service
+`
+
+const (
+	// tokenFile is only readable by root, unlike the units of systemd
+	tokenFile     = "/etc/loadtests/token"
+	schedulerCert = "/etc/loadtests/scheduler.pem"
+)
+
+// bootScript is the boot sequence of an executor registering with the
+// scheduler at `schedulerAddr` using `token`, over TLS if `cert` is the
+// certificate of the scheduler.
+func bootScript(binaryURL, schedulerAddr, token string, cert []byte) string {
+	var files, args bytes.Buffer
+	if token != "" {
+		fmt.Fprintf(&files, "install -m 0600 /dev/null %s\nprintf '%%s' %q > %s\n", tokenFile, token, tokenFile)
+		fmt.Fprintf(&args, " -scheduler_token_file %s", tokenFile)
+	}
+	if len(cert) > 0 {
+		fmt.Fprintf(&files, "cat > %s <<'EOF'\n%s\nEOF\n", schedulerCert, bytes.TrimSpace(cert))
+		fmt.Fprintf(&args, " -scheduler_cert %s", schedulerCert)
+	}
+	return fmt.Sprintf(bootSequence, binaryURL, files.String(), schedulerAddr, args.String())
+}
+
+func ipv4PublicAddress(droplet *godo.Droplet) (string, bool) {
+	if droplet.Networks == nil {
+		return "", false
+	}
+	for _, network := range droplet.Networks.V4 {
+		switch network.Type {
+		case "public":
+			return network.IPAddress, true
+		}
+	}
+	return "", false
+}
+
+var _ Provider = new(DigitalOcean)
+
+// DigitalOcean runs every executor on its own droplet, they pull the executor
+// binary from the scheduler when they boot.
+type DigitalOcean struct {
+	cfg   *Config
+	cloud *godo.Client
+}
+
+func NewDigitalOcean(cfg *Config, cloud *godo.Client) *DigitalOcean {
+	return &DigitalOcean{cfg: cfg, cloud: cloud}
+}
+
+func (d *DigitalOcean) Cleanup() error {
+	droplets, _, err := d.cloud.Droplets.List(&godo.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, droplet := range droplets {
+		if !strings.HasPrefix(droplet.Name, executorPrefix) {
+			continue
+		}
+		_, err := d.cloud.Droplets.Delete(droplet.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DigitalOcean) Launch(name string) (int, error) {
+	var cert []byte
+	if d.cfg.TLSCertFile != "" {
+		var err error
+		if cert, err = ioutil.ReadFile(d.cfg.TLSCertFile); err != nil {
+			return 0, err
+		}
+	}
+	req := &godo.DropletCreateRequest{
+		Name:              name,
+		SSHKeys:           d.cfg.SSHKeyIDs,
+		PrivateNetworking: true,
+		Region:            d.cfg.DropletRegion,
+		Size:              d.cfg.DropletSize,
+		UserData: bootScript(
+			d.cfg.PullExecutorBinaryURL,
+			d.cfg.AdvertiseListenAddr,
+			d.cfg.ExecutorToken,
+			cert,
+		),
+		Image: godo.DropletCreateImage{Slug: d.cfg.DropletImageSlug},
+	}
+	droplet, _, err := d.cloud.Droplets.Create(req)
+	if err != nil {
+		return 0, err
+	}
+	return droplet.ID, nil
+}
+
+func (d *DigitalOcean) Inspect(id int) (string, error) {
+	droplet, _, err := d.cloud.Droplets.Get(id)
+	if err != nil {
+		return "", err
+	}
+	ip, found := ipv4PublicAddress(droplet)
+	if !found {
+		return "", fmt.Errorf("no public IPv4 found on droplet %d", id)
+	}
+	return ip, nil
+}
+
+func (d *DigitalOcean) Destroy(id int) error {
+	_, err := d.cloud.Droplets.Delete(id)
+	return err
+}
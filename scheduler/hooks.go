@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/lgpeterson/loadtests/executor/engine"
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"golang.org/x/net/context"
+)
+
+// defaultHooksTimeout is how long setup() and teardown() can each run when
+// Config.HooksTimeout isn't set.
+const defaultHooksTimeout = time.Minute
+
+// scriptHooks runs the hooks of a script that must run once for the whole
+// load test rather than on every executor: setup() before the executors
+// start, and teardown() after they are done.
+type scriptHooks struct {
+	prgm    *engine.LuaProgram
+	log     *logrus.Entry
+	timeout time.Duration
+	// where the script logs, until the teardown
+	out io.Closer
+}
+
+// runSetup runs the setup of the script of `params`, for at most `timeout`
+// or until ctx is done, and returns what it returned as JSON for the
+// executors.
+func runSetup(ctx context.Context, params *executorpb.ScriptParams, config string, timeout time.Duration, ll *logrus.Entry) (*scriptHooks, []byte, error) {
+	if timeout <= 0 {
+		timeout = defaultHooksTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := ll.Logger.Writer()
+	prgm, data, err := setup(ctx, params, config, out)
+	if err != nil {
+		out.Close()
+		return nil, nil, err
+	}
+	return &scriptHooks{prgm: prgm, log: ll, timeout: timeout, out: out}, data, nil
+}
+
+func setup(ctx context.Context, params *executorpb.ScriptParams, config string, out io.Writer) (*engine.LuaProgram, []byte, error) {
+	prgm, err := engine.Lua(strings.NewReader(params.Script),
+		engine.SetLogger(out),
+		engine.AllowHosts(params.AllowedHosts...),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config != "" {
+		cfg, err := engine.ParseConfig(config)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := prgm.AddConfig(cfg); err != nil {
+			return nil, nil, err
+		}
+	}
+	data, err := prgm.Setup(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := prgm.SetSetupData(data); err != nil {
+		return nil, nil, err
+	}
+	return prgm, data, nil
+}
+
+// teardown runs the teardown of the script, which can only be logged if it
+// fails as the load test is over. It runs even if the load test was
+// cancelled, for at most the timeout of the hooks.
+func (h *scriptHooks) teardown() {
+	defer h.out.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	if err := h.prgm.Teardown(ctx); err != nil {
+		h.log.WithError(err).Error("running teardown")
+	}
+}
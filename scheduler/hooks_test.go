@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"golang.org/x/net/context"
+)
+
+func testLogger(out *bytes.Buffer) *logrus.Entry {
+	logger := logrus.New()
+	logger.Out = out
+	return logrus.NewEntry(logger)
+}
+
+func TestScriptHooks(t *testing.T) {
+	var tornDown []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		tornDown = append(tornDown, string(body))
+	}))
+	defer target.Close()
+
+	script := fmt.Sprintf(`
+function setup()
+	return {token = token, users = {"a", "b"}}
+end
+
+function teardown(data)
+	post(%q, "text/plain", data.token)
+end`, target.URL)
+	params := &executorpb.ScriptParams{Script: script}
+
+	var logs bytes.Buffer
+	ctx, cancel := context.WithCancel(context.Background())
+	hooks, data, err := runSetup(ctx, params, `{"token": "s3cr3t"}`, time.Second, testLogger(&logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"token":"s3cr3t","users":["a","b"]}`; string(data) != want {
+		t.Errorf("want setup data %s, got %s", want, data)
+	}
+
+	// The teardown runs even when the load test was cancelled
+	cancel()
+	hooks.teardown()
+	if len(tornDown) != 1 || tornDown[0] != "s3cr3t" {
+		t.Errorf("want the teardown to get the setup data once, got %q", tornDown)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("want no error logged, got %s", logs.String())
+	}
+}
+
+func TestScriptHooksFailing(t *testing.T) {
+	for _, script := range []string{
+		`function setup() local users; return users.all end`,
+		`function setup() return function() end end`,
+		`function setup() while true do end end`,
+	} {
+		var logs bytes.Buffer
+		params := &executorpb.ScriptParams{Script: script}
+		_, _, err := runSetup(context.Background(), params, "", 50*time.Millisecond, testLogger(&logs))
+		if err == nil {
+			t.Errorf("%s: want an error", script)
+		}
+	}
+
+	// The load test is cancelled while the setup runs
+	params := &executorpb.ScriptParams{Script: `function setup() while true do end end`}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	var logs bytes.Buffer
+	if _, _, err := runSetup(ctx, params, "", time.Minute, testLogger(&logs)); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("want the setup cancelled, got %v", err)
+	}
+
+	// A failing or hanging teardown is logged
+	for _, script := range []string{
+		`function teardown(data) return data.users.all end`,
+		`function teardown() while true do end end`,
+	} {
+		var logs bytes.Buffer
+		params := &executorpb.ScriptParams{Script: script}
+		hooks, _, err := runSetup(context.Background(), params, "", 50*time.Millisecond, testLogger(&logs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hooks.teardown()
+		if !strings.Contains(logs.String(), "running teardown") {
+			t.Errorf("%s: want the error logged, got %q", script, logs.String())
+		}
+	}
+}
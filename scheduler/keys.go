@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Role is what an API key allows its holder to do.
+type Role string
+
+const (
+	// RoleRun starts load tests, and controls or cancels them
+	RoleRun Role = "run"
+	// RoleCancelOthers controls and cancels the load tests of other keys
+	RoleCancelOthers Role = "cancel-others"
+	// RoleAdmin can do anything the other roles can
+	RoleAdmin Role = "admin"
+)
+
+// Roles are all the roles a key can have
+var Roles = []Role{RoleRun, RoleCancelOthers, RoleAdmin}
+
+// APIKey is a key of the store. Only a hash of the key itself is kept.
+type APIKey struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Roles  []Role `json:"roles"`
+}
+
+// Has tells if the key has the role, admins have them all.
+func (k *APIKey) Has(role Role) bool {
+	for _, r := range k.Roles {
+		if r == role || r == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyStore keeps the API keys in a JSON file. The file is read again when it
+// changes, so keys can be revoked without restarting the scheduler.
+type KeyStore struct {
+	filename string
+
+	lock sync.Mutex
+	// of the file when it was last read
+	modTime time.Time
+	size    int64
+	keys    []*APIKey
+	// the keys by the hash of their key
+	hashes map[string]*APIKey
+}
+
+// OpenKeyStore reads the keys in `filename`, which is created by the first
+// key added if it doesn't exist.
+func OpenKeyStore(filename string) (*KeyStore, error) {
+	k := &KeyStore{filename: filename, hashes: make(map[string]*APIKey)}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err := k.reload(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return k, nil
+}
+
+// reload reads the file again if it changed since it was last read.
+func (k *KeyStore) reload() error {
+	fi, err := os.Stat(k.filename)
+	if err != nil {
+		k.modTime, k.size, k.keys, k.hashes = time.Time{}, 0, nil, make(map[string]*APIKey)
+		return err
+	}
+	if fi.ModTime().Equal(k.modTime) && fi.Size() == k.size {
+		return nil
+	}
+	data, err := ioutil.ReadFile(k.filename)
+	if err != nil {
+		return err
+	}
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("reading keys from %s: %v", k.filename, err)
+	}
+	hashes := make(map[string]*APIKey, len(keys))
+	for _, key := range keys {
+		if err := validRoles(key.Roles); err != nil {
+			return fmt.Errorf("key %q of %s: %v", key.Name, k.filename, err)
+		}
+		hashes[key.SHA256] = key
+	}
+	k.modTime, k.size, k.keys, k.hashes = fi.ModTime(), fi.Size(), keys, hashes
+	return nil
+}
+
+// Lookup returns the key of the store that `key` is, nil if it's none of
+// them.
+func (k *KeyStore) Lookup(key string) (*APIKey, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err := k.reload(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return k.hashes[hashKey(key)], nil
+}
+
+// Add creates a key named `name` with the roles and returns it, it can't be
+// retrieved afterwards.
+func (k *KeyStore) Add(name string, roles []Role) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("a key needs a name")
+	}
+	if len(roles) == 0 {
+		return "", fmt.Errorf("a key needs at least a role")
+	}
+	if err := validRoles(roles); err != nil {
+		return "", err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err := k.reload(); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	for _, key := range k.keys {
+		if key.Name == name {
+			return "", fmt.Errorf("there's already a key named %q", name)
+		}
+	}
+	key, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	keys := append(k.keys, &APIKey{Name: name, SHA256: hashKey(key), Roles: roles})
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// The file is replaced at once, a scheduler reading it never sees half
+	// of it
+	tmp, err := ioutil.TempFile(filepath.Dir(k.filename), filepath.Base(k.filename))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), k.filename); err != nil {
+		return "", err
+	}
+	return key, k.reload()
+}
+
+// ParseRoles parses the names of roles.
+func ParseRoles(names []string) ([]Role, error) {
+	var roles []Role
+	for _, name := range names {
+		roles = append(roles, Role(name))
+	}
+	return roles, validRoles(roles)
+}
+
+func validRoles(roles []Role) error {
+	for _, role := range roles {
+		known := false
+		for _, r := range Roles {
+			known = known || r == role
+		}
+		if !known {
+			return fmt.Errorf("unknown role %q, must be one of %v", role, Roles)
+		}
+	}
+	return nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken returns a secret too long to be guessed.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewExecutorToken returns a secret the executors authenticate with when
+// they register.
+func NewExecutorToken() (string, error) {
+	return randomToken()
+}
@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+)
+
+var _ Provider = new(Local)
+
+// Local runs every executor as a process on the same machine as the
+// scheduler, it needs no cloud account.
+type Local struct {
+	binary        string
+	schedulerAddr string
+	// the executors register over TLS trusting it, if not empty
+	certFile string
+	// the executors register with it, if not empty
+	token string
+	// the pids of the executors are kept there, so that the next scheduler
+	// can kill those left running
+	pidFile string
+
+	lock      sync.Mutex
+	lastID    int
+	processes map[int]*exec.Cmd
+}
+
+// NewLocal runs the executord binary found at `binary`, its executors
+// register with the scheduler at `schedulerAddr` using `token`, over TLS if
+// `certFile` is the certificate of the scheduler. The pids of the executors
+// are written to `pidFile`.
+func NewLocal(binary, schedulerAddr, certFile, token, pidFile string) *Local {
+	return &Local{
+		binary:        binary,
+		schedulerAddr: schedulerAddr,
+		certFile:      certFile,
+		token:         token,
+		pidFile:       pidFile,
+		processes:     make(map[int]*exec.Cmd),
+	}
+}
+
+// Cleanup kills the executors a previous scheduler left running, as found in
+// the pid file. A pid is only killed if it's still an executor, it might have
+// been reused since.
+func (l *Local) Cleanup() error {
+	data, err := ioutil.ReadFile(l.pidFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, field := range strings.Fields(string(data)) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("invalid pid file %s: %v", l.pidFile, err)
+		}
+		if !l.runs(pid) {
+			continue
+		}
+		logrus.WithField("pid", pid).Info("killing executor process left over")
+		if proc, err := os.FindProcess(pid); err == nil {
+			if err := proc.Kill(); err != nil {
+				logrus.WithError(err).WithField("pid", pid).Warn("can't kill executor process")
+			}
+		}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.writePids()
+}
+
+// runs tells if the process `pid` runs the executor binary. Without /proc,
+// it can't be told and is assumed to.
+func (l *Local) runs(pid int) bool {
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if os.IsNotExist(err) {
+		_, err := os.Stat("/proc/self")
+		return err != nil
+	}
+	if err != nil {
+		return false
+	}
+	for _, arg := range bytes.Split(cmdline, []byte{0}) {
+		if string(arg) == l.binary {
+			return true
+		}
+	}
+	return false
+}
+
+// writePids records the pids of the executors running, it must be called
+// with the lock held.
+func (l *Local) writePids() error {
+	var pids []int
+	for _, cmd := range l.processes {
+		pids = append(pids, cmd.Process.Pid)
+	}
+	sort.Ints(pids)
+	var buf bytes.Buffer
+	for _, pid := range pids {
+		fmt.Fprintln(&buf, pid)
+	}
+	return ioutil.WriteFile(l.pidFile, buf.Bytes(), 0644)
+}
+
+func (l *Local) Launch(name string) (int, error) {
+	port, err := freePort()
+	if err != nil {
+		return 0, err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.lastID++
+	id := l.lastID
+
+	args := []string{
+		"-scheduler_addr", l.schedulerAddr,
+		"-port", strconv.Itoa(port),
+		"-dropletId", strconv.Itoa(id),
+	}
+	if l.certFile != "" {
+		args = append(args, "-scheduler_cert", l.certFile)
+	}
+	cmd := exec.Command(l.binary, args...)
+	if l.token != "" {
+		// Not an argument, which anyone on the machine can read
+		cmd.Env = append(os.Environ(), "SCHEDULER_TOKEN="+l.token)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	logrus.WithFields(logrus.Fields{
+		"executor.id":   id,
+		"executor.name": name,
+		"pid":           cmd.Process.Pid,
+	}).Info("started executor process")
+	l.processes[id] = cmd
+	if err := l.writePids(); err != nil {
+		logrus.WithError(err).WithField("pid_file", l.pidFile).Warn("can't record the pid of the executor")
+	}
+	return id, nil
+}
+
+func (l *Local) Inspect(id int) (string, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, ok := l.processes[id]; !ok {
+		return "", fmt.Errorf("no executor process %d", id)
+	}
+	return "127.0.0.1", nil
+}
+
+func (l *Local) Destroy(id int) error {
+	l.lock.Lock()
+	cmd, ok := l.processes[id]
+	delete(l.processes, id)
+	if err := l.writePids(); err != nil {
+		logrus.WithError(err).WithField("pid_file", l.pidFile).Warn("can't record the pids of the executors")
+	}
+	l.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("no executor process %d", id)
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		return err
+	}
+	// reap the process, it was killed so it exits with an error
+	_ = cmd.Wait()
+	return nil
+}
+
+// freePort finds a port nothing listens on yet.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -10,9 +10,24 @@ It is generated from these files:
 
 It has these top-level messages:
 	LoadTestReq
+	Scenario
+	DataFeed
+	Stage
 	LoadTestResp
+	Abort
+	Check
+	Verdict
 	RegisterExecutorReq
 	RegisterExecutorResp
+	LoadTest
+	CancelLoadTestReq
+	CancelLoadTestResp
+	ControlLoadTestReq
+	ControlLoadTestResp
+	ListLoadTestsReq
+	ListLoadTestsResp
+	GetLoadTestReq
+	GetLoadTestResp
 */
 package pb
 
@@ -30,16 +45,152 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+type DataFeed_Format int32
+
+const (
+	DataFeed_CSV    DataFeed_Format = 0
+	DataFeed_NDJSON DataFeed_Format = 1
+)
+
+var DataFeed_Format_name = map[int32]string{
+	0: "CSV",
+	1: "NDJSON",
+}
+var DataFeed_Format_value = map[string]int32{
+	"CSV":    0,
+	"NDJSON": 1,
+}
+
+func (x DataFeed_Format) String() string {
+	return proto.EnumName(DataFeed_Format_name, int32(x))
+}
+func (DataFeed_Format) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{2, 0} }
+
+type DataFeed_Mode int32
+
+const (
+	DataFeed_SEQUENTIAL DataFeed_Mode = 0
+	DataFeed_RANDOM     DataFeed_Mode = 1
+	DataFeed_UNIQUE     DataFeed_Mode = 2
+)
+
+var DataFeed_Mode_name = map[int32]string{
+	0: "SEQUENTIAL",
+	1: "RANDOM",
+	2: "UNIQUE",
+}
+var DataFeed_Mode_value = map[string]int32{
+	"SEQUENTIAL": 0,
+	"RANDOM":     1,
+	"UNIQUE":     2,
+}
+
+func (x DataFeed_Mode) String() string {
+	return proto.EnumName(DataFeed_Mode_name, int32(x))
+}
+func (DataFeed_Mode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{2, 1} }
+
+type Stage_Interpolation int32
+
+const (
+	Stage_LINEAR Stage_Interpolation = 0
+	Stage_STEP   Stage_Interpolation = 1
+)
+
+var Stage_Interpolation_name = map[int32]string{
+	0: "LINEAR",
+	1: "STEP",
+}
+var Stage_Interpolation_value = map[string]int32{
+	"LINEAR": 0,
+	"STEP":   1,
+}
+
+func (x Stage_Interpolation) String() string {
+	return proto.EnumName(Stage_Interpolation_name, int32(x))
+}
+func (Stage_Interpolation) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{3, 0} }
+
+type LoadTest_State int32
+
+const (
+	LoadTest_PREPARING  LoadTest_State = 0
+	LoadTest_RUNNING    LoadTest_State = 1
+	LoadTest_CANCELLING LoadTest_State = 2
+	LoadTest_FINISHED   LoadTest_State = 3
+	LoadTest_CANCELLED  LoadTest_State = 4
+	LoadTest_ERRORED    LoadTest_State = 5
+)
+
+var LoadTest_State_name = map[int32]string{
+	0: "PREPARING",
+	1: "RUNNING",
+	2: "CANCELLING",
+	3: "FINISHED",
+	4: "CANCELLED",
+	5: "ERRORED",
+}
+var LoadTest_State_value = map[string]int32{
+	"PREPARING":  0,
+	"RUNNING":    1,
+	"CANCELLING": 2,
+	"FINISHED":   3,
+	"CANCELLED":  4,
+	"ERRORED":    5,
+}
+
+func (x LoadTest_State) String() string {
+	return proto.EnumName(LoadTest_State_name, int32(x))
+}
+func (LoadTest_State) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{10, 0} }
+
+type ControlLoadTestReq_Action int32
+
+const (
+	ControlLoadTestReq_UNSPECIFIED ControlLoadTestReq_Action = 0
+	ControlLoadTestReq_PAUSE       ControlLoadTestReq_Action = 1
+	ControlLoadTestReq_RESUME      ControlLoadTestReq_Action = 2
+	ControlLoadTestReq_SET_RATE    ControlLoadTestReq_Action = 3
+)
+
+var ControlLoadTestReq_Action_name = map[int32]string{
+	0: "UNSPECIFIED",
+	1: "PAUSE",
+	2: "RESUME",
+	3: "SET_RATE",
+}
+var ControlLoadTestReq_Action_value = map[string]int32{
+	"UNSPECIFIED": 0,
+	"PAUSE":       1,
+	"RESUME":      2,
+	"SET_RATE":    3,
+}
+
+func (x ControlLoadTestReq_Action) String() string {
+	return proto.EnumName(ControlLoadTestReq_Action_name, int32(x))
+}
+func (ControlLoadTestReq_Action) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{13, 0}
+}
+
 type LoadTestReq struct {
-	Url                       string  `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
-	Script                    string  `protobuf:"bytes,2,opt,name=script" json:"script,omitempty"`
-	ScriptName                string  `protobuf:"bytes,3,opt,name=script_name" json:"script_name,omitempty"`
-	RunTime                   int32   `protobuf:"varint,4,opt,name=run_time" json:"run_time,omitempty"`
-	GrowthFactor              float64 `protobuf:"fixed64,8,opt,name=growth_factor" json:"growth_factor,omitempty"`
-	TimeBetweenGrowth         float64 `protobuf:"fixed64,9,opt,name=time_between_growth" json:"time_between_growth,omitempty"`
-	StartingRequestsPerSecond int32   `protobuf:"varint,10,opt,name=starting_requests_per_second" json:"starting_requests_per_second,omitempty"`
-	MaxRequestsPerSecond      int32   `protobuf:"varint,11,opt,name=max_requests_per_second" json:"max_requests_per_second,omitempty"`
-	ScriptConfig              string  `protobuf:"bytes,12,opt,name=script_config" json:"script_config,omitempty"`
+	Url                       string      `protobuf:"bytes,1,opt,name=url" json:"url,omitempty"`
+	Script                    string      `protobuf:"bytes,2,opt,name=script" json:"script,omitempty"`
+	ScriptName                string      `protobuf:"bytes,3,opt,name=script_name" json:"script_name,omitempty"`
+	RunTime                   int32       `protobuf:"varint,4,opt,name=run_time" json:"run_time,omitempty"`
+	GrowthFactor              float64     `protobuf:"fixed64,8,opt,name=growth_factor" json:"growth_factor,omitempty"`
+	TimeBetweenGrowth         float64     `protobuf:"fixed64,9,opt,name=time_between_growth" json:"time_between_growth,omitempty"`
+	StartingRequestsPerSecond int32       `protobuf:"varint,10,opt,name=starting_requests_per_second" json:"starting_requests_per_second,omitempty"`
+	MaxRequestsPerSecond      int32       `protobuf:"varint,11,opt,name=max_requests_per_second" json:"max_requests_per_second,omitempty"`
+	ScriptConfig              string      `protobuf:"bytes,12,opt,name=script_config" json:"script_config,omitempty"`
+	KeepCookies               bool        `protobuf:"varint,13,opt,name=keep_cookies" json:"keep_cookies,omitempty"`
+	OpenModel                 bool        `protobuf:"varint,14,opt,name=open_model" json:"open_model,omitempty"`
+	Stages                    []*Stage    `protobuf:"bytes,15,rep,name=stages" json:"stages,omitempty"`
+	Thresholds                []string    `protobuf:"bytes,16,rep,name=thresholds" json:"thresholds,omitempty"`
+	Rollups                   bool        `protobuf:"varint,17,opt,name=rollups" json:"rollups,omitempty"`
+	DataFeed                  *DataFeed   `protobuf:"bytes,18,opt,name=data_feed" json:"data_feed,omitempty"`
+	Scenarios                 []*Scenario `protobuf:"bytes,19,rep,name=scenarios" json:"scenarios,omitempty"`
+	AbortConditions           []string    `protobuf:"bytes,20,rep,name=abort_conditions" json:"abort_conditions,omitempty"`
 }
 
 func (m *LoadTestReq) Reset()                    { *m = LoadTestReq{} }
@@ -47,19 +198,85 @@ func (m *LoadTestReq) String() string            { return proto.CompactTextStrin
 func (*LoadTestReq) ProtoMessage()               {}
 func (*LoadTestReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+func (m *LoadTestReq) GetStages() []*Stage {
+	if m != nil {
+		return m.Stages
+	}
+	return nil
+}
+
+func (m *LoadTestReq) GetDataFeed() *DataFeed {
+	if m != nil {
+		return m.DataFeed
+	}
+	return nil
+}
+
+func (m *LoadTestReq) GetScenarios() []*Scenario {
+	if m != nil {
+		return m.Scenarios
+	}
+	return nil
+}
+
+type Scenario struct {
+	Name              string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Script            string   `protobuf:"bytes,2,opt,name=script" json:"script,omitempty"`
+	ScriptConfig      string   `protobuf:"bytes,3,opt,name=script_config" json:"script_config,omitempty"`
+	Weight            float64  `protobuf:"fixed64,4,opt,name=weight" json:"weight,omitempty"`
+	RequestsPerSecond int32    `protobuf:"varint,5,opt,name=requests_per_second" json:"requests_per_second,omitempty"`
+	Stages            []*Stage `protobuf:"bytes,6,rep,name=stages" json:"stages,omitempty"`
+}
+
+func (m *Scenario) Reset()                    { *m = Scenario{} }
+func (m *Scenario) String() string            { return proto.CompactTextString(m) }
+func (*Scenario) ProtoMessage()               {}
+func (*Scenario) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Scenario) GetStages() []*Stage {
+	if m != nil {
+		return m.Stages
+	}
+	return nil
+}
+
+type DataFeed struct {
+	Content []byte          `protobuf:"bytes,1,opt,name=content" json:"content,omitempty"`
+	Format  DataFeed_Format `protobuf:"varint,2,opt,name=format,enum=loadtests.DataFeed_Format" json:"format,omitempty"`
+	Mode    DataFeed_Mode   `protobuf:"varint,3,opt,name=mode,enum=loadtests.DataFeed_Mode" json:"mode,omitempty"`
+}
+
+func (m *DataFeed) Reset()                    { *m = DataFeed{} }
+func (m *DataFeed) String() string            { return proto.CompactTextString(m) }
+func (*DataFeed) ProtoMessage()               {}
+func (*DataFeed) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type Stage struct {
+	Duration                float64             `protobuf:"fixed64,1,opt,name=duration" json:"duration,omitempty"`
+	TargetRequestsPerSecond int32               `protobuf:"varint,2,opt,name=target_requests_per_second" json:"target_requests_per_second,omitempty"`
+	Interpolation           Stage_Interpolation `protobuf:"varint,3,opt,name=interpolation,enum=loadtests.Stage_Interpolation" json:"interpolation,omitempty"`
+}
+
+func (m *Stage) Reset()                    { *m = Stage{} }
+func (m *Stage) String() string            { return proto.CompactTextString(m) }
+func (*Stage) ProtoMessage()               {}
+func (*Stage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
 type LoadTestResp struct {
 	// Types that are valid to be assigned to Phase:
 	//	*LoadTestResp_Preparing_
 	//	*LoadTestResp_Start
 	//	*LoadTestResp_Finish
 	//	*LoadTestResp_Error
+	//	*LoadTestResp_Cancel
+	//	*LoadTestResp_Progress_
 	Phase isLoadTestResp_Phase `protobuf_oneof:"phase"`
 }
 
 func (m *LoadTestResp) Reset()                    { *m = LoadTestResp{} }
 func (m *LoadTestResp) String() string            { return proto.CompactTextString(m) }
 func (*LoadTestResp) ProtoMessage()               {}
-func (*LoadTestResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*LoadTestResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
 
 type isLoadTestResp_Phase interface {
 	isLoadTestResp_Phase()
@@ -77,11 +294,19 @@ type LoadTestResp_Finish struct {
 type LoadTestResp_Error struct {
 	Error *LoadTestResp_Errored `protobuf:"bytes,4,opt,name=error,oneof"`
 }
+type LoadTestResp_Cancel struct {
+	Cancel *LoadTestResp_Cancelled `protobuf:"bytes,5,opt,name=cancel,oneof"`
+}
+type LoadTestResp_Progress_ struct {
+	Progress *LoadTestResp_Progress `protobuf:"bytes,6,opt,name=progress,oneof"`
+}
 
 func (*LoadTestResp_Preparing_) isLoadTestResp_Phase() {}
 func (*LoadTestResp_Start) isLoadTestResp_Phase()      {}
 func (*LoadTestResp_Finish) isLoadTestResp_Phase()     {}
 func (*LoadTestResp_Error) isLoadTestResp_Phase()      {}
+func (*LoadTestResp_Cancel) isLoadTestResp_Phase()     {}
+func (*LoadTestResp_Progress_) isLoadTestResp_Phase()  {}
 
 func (m *LoadTestResp) GetPhase() isLoadTestResp_Phase {
 	if m != nil {
@@ -118,6 +343,20 @@ func (m *LoadTestResp) GetError() *LoadTestResp_Errored {
 	return nil
 }
 
+func (m *LoadTestResp) GetCancel() *LoadTestResp_Cancelled {
+	if x, ok := m.GetPhase().(*LoadTestResp_Cancel); ok {
+		return x.Cancel
+	}
+	return nil
+}
+
+func (m *LoadTestResp) GetProgress() *LoadTestResp_Progress {
+	if x, ok := m.GetPhase().(*LoadTestResp_Progress_); ok {
+		return x.Progress
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*LoadTestResp) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), []interface{}) {
 	return _LoadTestResp_OneofMarshaler, _LoadTestResp_OneofUnmarshaler, []interface{}{
@@ -125,6 +364,8 @@ func (*LoadTestResp) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer)
 		(*LoadTestResp_Start)(nil),
 		(*LoadTestResp_Finish)(nil),
 		(*LoadTestResp_Error)(nil),
+		(*LoadTestResp_Cancel)(nil),
+		(*LoadTestResp_Progress_)(nil),
 	}
 }
 
@@ -152,6 +393,16 @@ func _LoadTestResp_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Error); err != nil {
 			return err
 		}
+	case *LoadTestResp_Cancel:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Cancel); err != nil {
+			return err
+		}
+	case *LoadTestResp_Progress_:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Progress); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("LoadTestResp.Phase has unexpected type %T", x)
@@ -194,19 +445,36 @@ func _LoadTestResp_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.B
 		err := b.DecodeMessage(msg)
 		m.Phase = &LoadTestResp_Error{msg}
 		return true, err
+	case 5: // phase.cancel
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(LoadTestResp_Cancelled)
+		err := b.DecodeMessage(msg)
+		m.Phase = &LoadTestResp_Cancel{msg}
+		return true, err
+	case 6: // phase.progress
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(LoadTestResp_Progress)
+		err := b.DecodeMessage(msg)
+		m.Phase = &LoadTestResp_Progress_{msg}
+		return true, err
 	default:
 		return false, nil
 	}
 }
 
 type LoadTestResp_Preparing struct {
-	Count int32 `protobuf:"varint,1,opt,name=count" json:"count,omitempty"`
+	Count int32  `protobuf:"varint,1,opt,name=count" json:"count,omitempty"`
+	Id    string `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
 }
 
 func (m *LoadTestResp_Preparing) Reset()                    { *m = LoadTestResp_Preparing{} }
 func (m *LoadTestResp_Preparing) String() string            { return proto.CompactTextString(m) }
 func (*LoadTestResp_Preparing) ProtoMessage()               {}
-func (*LoadTestResp_Preparing) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 0} }
+func (*LoadTestResp_Preparing) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 0} }
 
 type LoadTestResp_Started struct {
 }
@@ -214,34 +482,164 @@ type LoadTestResp_Started struct {
 func (m *LoadTestResp_Started) Reset()                    { *m = LoadTestResp_Started{} }
 func (m *LoadTestResp_Started) String() string            { return proto.CompactTextString(m) }
 func (*LoadTestResp_Started) ProtoMessage()               {}
-func (*LoadTestResp_Started) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 1} }
+func (*LoadTestResp_Started) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 1} }
 
 type LoadTestResp_Finished struct {
+	Verdict *Verdict `protobuf:"bytes,1,opt,name=verdict" json:"verdict,omitempty"`
+	Checks  []*Check `protobuf:"bytes,2,rep,name=checks" json:"checks,omitempty"`
 }
 
 func (m *LoadTestResp_Finished) Reset()                    { *m = LoadTestResp_Finished{} }
 func (m *LoadTestResp_Finished) String() string            { return proto.CompactTextString(m) }
 func (*LoadTestResp_Finished) ProtoMessage()               {}
-func (*LoadTestResp_Finished) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 2} }
+func (*LoadTestResp_Finished) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 2} }
+
+func (m *LoadTestResp_Finished) GetVerdict() *Verdict {
+	if m != nil {
+		return m.Verdict
+	}
+	return nil
+}
+
+func (m *LoadTestResp_Finished) GetChecks() []*Check {
+	if m != nil {
+		return m.Checks
+	}
+	return nil
+}
 
 type LoadTestResp_Errored struct {
 	Error string `protobuf:"bytes,1,opt,name=error" json:"error,omitempty"`
+	Abort *Abort `protobuf:"bytes,2,opt,name=abort" json:"abort,omitempty"`
 }
 
 func (m *LoadTestResp_Errored) Reset()                    { *m = LoadTestResp_Errored{} }
 func (m *LoadTestResp_Errored) String() string            { return proto.CompactTextString(m) }
 func (*LoadTestResp_Errored) ProtoMessage()               {}
-func (*LoadTestResp_Errored) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1, 3} }
+func (*LoadTestResp_Errored) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 3} }
+
+func (m *LoadTestResp_Errored) GetAbort() *Abort {
+	if m != nil {
+		return m.Abort
+	}
+	return nil
+}
+
+type LoadTestResp_Cancelled struct {
+}
+
+func (m *LoadTestResp_Cancelled) Reset()                    { *m = LoadTestResp_Cancelled{} }
+func (m *LoadTestResp_Cancelled) String() string            { return proto.CompactTextString(m) }
+func (*LoadTestResp_Cancelled) ProtoMessage()               {}
+func (*LoadTestResp_Cancelled) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 4} }
+
+type LoadTestResp_Progress struct {
+	Interval          float64                       `protobuf:"fixed64,1,opt,name=interval" json:"interval,omitempty"`
+	RequestsPerSecond float64                       `protobuf:"fixed64,2,opt,name=requests_per_second" json:"requests_per_second,omitempty"`
+	Executions        int64                         `protobuf:"varint,3,opt,name=executions" json:"executions,omitempty"`
+	Requests          int64                         `protobuf:"varint,4,opt,name=requests" json:"requests,omitempty"`
+	Errors            int64                         `protobuf:"varint,5,opt,name=errors" json:"errors,omitempty"`
+	Steps             []*LoadTestResp_Progress_Step `protobuf:"bytes,6,rep,name=steps" json:"steps,omitempty"`
+	Checks            []*Check                      `protobuf:"bytes,7,rep,name=checks" json:"checks,omitempty"`
+}
+
+func (m *LoadTestResp_Progress) Reset()                    { *m = LoadTestResp_Progress{} }
+func (m *LoadTestResp_Progress) String() string            { return proto.CompactTextString(m) }
+func (*LoadTestResp_Progress) ProtoMessage()               {}
+func (*LoadTestResp_Progress) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4, 5} }
+
+func (m *LoadTestResp_Progress) GetSteps() []*LoadTestResp_Progress_Step {
+	if m != nil {
+		return m.Steps
+	}
+	return nil
+}
+
+func (m *LoadTestResp_Progress) GetChecks() []*Check {
+	if m != nil {
+		return m.Checks
+	}
+	return nil
+}
+
+type LoadTestResp_Progress_Step struct {
+	Name   string  `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Count  int64   `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+	Errors int64   `protobuf:"varint,3,opt,name=errors" json:"errors,omitempty"`
+	P50    float64 `protobuf:"fixed64,4,opt,name=p50" json:"p50,omitempty"`
+	P90    float64 `protobuf:"fixed64,5,opt,name=p90" json:"p90,omitempty"`
+	P95    float64 `protobuf:"fixed64,6,opt,name=p95" json:"p95,omitempty"`
+	P99    float64 `protobuf:"fixed64,7,opt,name=p99" json:"p99,omitempty"`
+	Max    float64 `protobuf:"fixed64,8,opt,name=max" json:"max,omitempty"`
+}
+
+func (m *LoadTestResp_Progress_Step) Reset()         { *m = LoadTestResp_Progress_Step{} }
+func (m *LoadTestResp_Progress_Step) String() string { return proto.CompactTextString(m) }
+func (*LoadTestResp_Progress_Step) ProtoMessage()    {}
+func (*LoadTestResp_Progress_Step) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{4, 5, 0}
+}
+
+type Abort struct {
+	Condition string `protobuf:"bytes,1,opt,name=condition" json:"condition,omitempty"`
+	Observed  string `protobuf:"bytes,2,opt,name=observed" json:"observed,omitempty"`
+}
+
+func (m *Abort) Reset()                    { *m = Abort{} }
+func (m *Abort) String() string            { return proto.CompactTextString(m) }
+func (*Abort) ProtoMessage()               {}
+func (*Abort) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+type Check struct {
+	Name   string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Passes int64  `protobuf:"varint,2,opt,name=passes" json:"passes,omitempty"`
+	Fails  int64  `protobuf:"varint,3,opt,name=fails" json:"fails,omitempty"`
+}
+
+func (m *Check) Reset()                    { *m = Check{} }
+func (m *Check) String() string            { return proto.CompactTextString(m) }
+func (*Check) ProtoMessage()               {}
+func (*Check) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+type Verdict struct {
+	Passed     bool                 `protobuf:"varint,1,opt,name=passed" json:"passed,omitempty"`
+	Thresholds []*Verdict_Threshold `protobuf:"bytes,2,rep,name=thresholds" json:"thresholds,omitempty"`
+}
+
+func (m *Verdict) Reset()                    { *m = Verdict{} }
+func (m *Verdict) String() string            { return proto.CompactTextString(m) }
+func (*Verdict) ProtoMessage()               {}
+func (*Verdict) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *Verdict) GetThresholds() []*Verdict_Threshold {
+	if m != nil {
+		return m.Thresholds
+	}
+	return nil
+}
+
+type Verdict_Threshold struct {
+	Expression string `protobuf:"bytes,1,opt,name=expression" json:"expression,omitempty"`
+	Passed     bool   `protobuf:"varint,2,opt,name=passed" json:"passed,omitempty"`
+	Observed   string `protobuf:"bytes,3,opt,name=observed" json:"observed,omitempty"`
+}
+
+func (m *Verdict_Threshold) Reset()                    { *m = Verdict_Threshold{} }
+func (m *Verdict_Threshold) String() string            { return proto.CompactTextString(m) }
+func (*Verdict_Threshold) ProtoMessage()               {}
+func (*Verdict_Threshold) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7, 0} }
 
 type RegisterExecutorReq struct {
-	DropletId int64 `protobuf:"varint,1,opt,name=droplet_id" json:"droplet_id,omitempty"`
-	Port      int64 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	DropletId       int64  `protobuf:"varint,1,opt,name=droplet_id" json:"droplet_id,omitempty"`
+	Port            int64  `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	ProtocolVersion int32  `protobuf:"varint,3,opt,name=protocol_version" json:"protocol_version,omitempty"`
+	Certificate     []byte `protobuf:"bytes,4,opt,name=certificate" json:"certificate,omitempty"`
 }
 
 func (m *RegisterExecutorReq) Reset()                    { *m = RegisterExecutorReq{} }
 func (m *RegisterExecutorReq) String() string            { return proto.CompactTextString(m) }
 func (*RegisterExecutorReq) ProtoMessage()               {}
-func (*RegisterExecutorReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*RegisterExecutorReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 type RegisterExecutorResp struct {
 	InfluxAddr     string `protobuf:"bytes,1,opt,name=influx_addr" json:"influx_addr,omitempty"`
@@ -254,17 +652,161 @@ type RegisterExecutorResp struct {
 func (m *RegisterExecutorResp) Reset()                    { *m = RegisterExecutorResp{} }
 func (m *RegisterExecutorResp) String() string            { return proto.CompactTextString(m) }
 func (*RegisterExecutorResp) ProtoMessage()               {}
-func (*RegisterExecutorResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+func (*RegisterExecutorResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+type LoadTest struct {
+	Id         string         `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	ScriptName string         `protobuf:"bytes,2,opt,name=script_name" json:"script_name,omitempty"`
+	Url        string         `protobuf:"bytes,3,opt,name=url" json:"url,omitempty"`
+	State      LoadTest_State `protobuf:"varint,4,opt,name=state,enum=loadtests.LoadTest_State" json:"state,omitempty"`
+	Executors  int32          `protobuf:"varint,5,opt,name=executors" json:"executors,omitempty"`
+	StartedAt  int64          `protobuf:"varint,6,opt,name=started_at" json:"started_at,omitempty"`
+	EndedAt    int64          `protobuf:"varint,7,opt,name=ended_at" json:"ended_at,omitempty"`
+	Error      string         `protobuf:"bytes,8,opt,name=error" json:"error,omitempty"`
+	Paused     bool           `protobuf:"varint,9,opt,name=paused" json:"paused,omitempty"`
+	Owner      string         `protobuf:"bytes,10,opt,name=owner" json:"owner,omitempty"`
+}
+
+func (m *LoadTest) Reset()                    { *m = LoadTest{} }
+func (m *LoadTest) String() string            { return proto.CompactTextString(m) }
+func (*LoadTest) ProtoMessage()               {}
+func (*LoadTest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type CancelLoadTestReq struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *CancelLoadTestReq) Reset()                    { *m = CancelLoadTestReq{} }
+func (m *CancelLoadTestReq) String() string            { return proto.CompactTextString(m) }
+func (*CancelLoadTestReq) ProtoMessage()               {}
+func (*CancelLoadTestReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type CancelLoadTestResp struct {
+	LoadTest *LoadTest `protobuf:"bytes,1,opt,name=load_test" json:"load_test,omitempty"`
+}
+
+func (m *CancelLoadTestResp) Reset()                    { *m = CancelLoadTestResp{} }
+func (m *CancelLoadTestResp) String() string            { return proto.CompactTextString(m) }
+func (*CancelLoadTestResp) ProtoMessage()               {}
+func (*CancelLoadTestResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *CancelLoadTestResp) GetLoadTest() *LoadTest {
+	if m != nil {
+		return m.LoadTest
+	}
+	return nil
+}
+
+type ControlLoadTestReq struct {
+	Id                string                    `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Action            ControlLoadTestReq_Action `protobuf:"varint,2,opt,name=action,enum=loadtests.ControlLoadTestReq_Action" json:"action,omitempty"`
+	RequestsPerSecond int32                     `protobuf:"varint,3,opt,name=requests_per_second" json:"requests_per_second,omitempty"`
+	Workers           int32                     `protobuf:"varint,4,opt,name=workers" json:"workers,omitempty"`
+}
+
+func (m *ControlLoadTestReq) Reset()                    { *m = ControlLoadTestReq{} }
+func (m *ControlLoadTestReq) String() string            { return proto.CompactTextString(m) }
+func (*ControlLoadTestReq) ProtoMessage()               {}
+func (*ControlLoadTestReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+type ControlLoadTestResp struct {
+	LoadTest *LoadTest `protobuf:"bytes,1,opt,name=load_test" json:"load_test,omitempty"`
+}
+
+func (m *ControlLoadTestResp) Reset()                    { *m = ControlLoadTestResp{} }
+func (m *ControlLoadTestResp) String() string            { return proto.CompactTextString(m) }
+func (*ControlLoadTestResp) ProtoMessage()               {}
+func (*ControlLoadTestResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *ControlLoadTestResp) GetLoadTest() *LoadTest {
+	if m != nil {
+		return m.LoadTest
+	}
+	return nil
+}
+
+type ListLoadTestsReq struct {
+}
+
+func (m *ListLoadTestsReq) Reset()                    { *m = ListLoadTestsReq{} }
+func (m *ListLoadTestsReq) String() string            { return proto.CompactTextString(m) }
+func (*ListLoadTestsReq) ProtoMessage()               {}
+func (*ListLoadTestsReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type ListLoadTestsResp struct {
+	LoadTests []*LoadTest `protobuf:"bytes,1,rep,name=load_tests" json:"load_tests,omitempty"`
+}
+
+func (m *ListLoadTestsResp) Reset()                    { *m = ListLoadTestsResp{} }
+func (m *ListLoadTestsResp) String() string            { return proto.CompactTextString(m) }
+func (*ListLoadTestsResp) ProtoMessage()               {}
+func (*ListLoadTestsResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *ListLoadTestsResp) GetLoadTests() []*LoadTest {
+	if m != nil {
+		return m.LoadTests
+	}
+	return nil
+}
+
+type GetLoadTestReq struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *GetLoadTestReq) Reset()                    { *m = GetLoadTestReq{} }
+func (m *GetLoadTestReq) String() string            { return proto.CompactTextString(m) }
+func (*GetLoadTestReq) ProtoMessage()               {}
+func (*GetLoadTestReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+type GetLoadTestResp struct {
+	LoadTest *LoadTest `protobuf:"bytes,1,opt,name=load_test" json:"load_test,omitempty"`
+}
+
+func (m *GetLoadTestResp) Reset()                    { *m = GetLoadTestResp{} }
+func (m *GetLoadTestResp) String() string            { return proto.CompactTextString(m) }
+func (*GetLoadTestResp) ProtoMessage()               {}
+func (*GetLoadTestResp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *GetLoadTestResp) GetLoadTest() *LoadTest {
+	if m != nil {
+		return m.LoadTest
+	}
+	return nil
+}
 
 func init() {
 	proto.RegisterType((*LoadTestReq)(nil), "loadtests.LoadTestReq")
+	proto.RegisterType((*Scenario)(nil), "loadtests.Scenario")
+	proto.RegisterType((*DataFeed)(nil), "loadtests.DataFeed")
+	proto.RegisterType((*Stage)(nil), "loadtests.Stage")
 	proto.RegisterType((*LoadTestResp)(nil), "loadtests.LoadTestResp")
 	proto.RegisterType((*LoadTestResp_Preparing)(nil), "loadtests.LoadTestResp.Preparing")
 	proto.RegisterType((*LoadTestResp_Started)(nil), "loadtests.LoadTestResp.Started")
 	proto.RegisterType((*LoadTestResp_Finished)(nil), "loadtests.LoadTestResp.Finished")
 	proto.RegisterType((*LoadTestResp_Errored)(nil), "loadtests.LoadTestResp.Errored")
+	proto.RegisterType((*LoadTestResp_Cancelled)(nil), "loadtests.LoadTestResp.Cancelled")
+	proto.RegisterType((*LoadTestResp_Progress)(nil), "loadtests.LoadTestResp.Progress")
+	proto.RegisterType((*LoadTestResp_Progress_Step)(nil), "loadtests.LoadTestResp.Progress.Step")
+	proto.RegisterType((*Abort)(nil), "loadtests.Abort")
+	proto.RegisterType((*Check)(nil), "loadtests.Check")
+	proto.RegisterType((*Verdict)(nil), "loadtests.Verdict")
+	proto.RegisterType((*Verdict_Threshold)(nil), "loadtests.Verdict.Threshold")
 	proto.RegisterType((*RegisterExecutorReq)(nil), "loadtests.RegisterExecutorReq")
 	proto.RegisterType((*RegisterExecutorResp)(nil), "loadtests.RegisterExecutorResp")
+	proto.RegisterType((*LoadTest)(nil), "loadtests.LoadTest")
+	proto.RegisterType((*CancelLoadTestReq)(nil), "loadtests.CancelLoadTestReq")
+	proto.RegisterType((*CancelLoadTestResp)(nil), "loadtests.CancelLoadTestResp")
+	proto.RegisterType((*ControlLoadTestReq)(nil), "loadtests.ControlLoadTestReq")
+	proto.RegisterType((*ControlLoadTestResp)(nil), "loadtests.ControlLoadTestResp")
+	proto.RegisterType((*ListLoadTestsReq)(nil), "loadtests.ListLoadTestsReq")
+	proto.RegisterType((*ListLoadTestsResp)(nil), "loadtests.ListLoadTestsResp")
+	proto.RegisterType((*GetLoadTestReq)(nil), "loadtests.GetLoadTestReq")
+	proto.RegisterType((*GetLoadTestResp)(nil), "loadtests.GetLoadTestResp")
+	proto.RegisterEnum("loadtests.DataFeed_Format", DataFeed_Format_name, DataFeed_Format_value)
+	proto.RegisterEnum("loadtests.DataFeed_Mode", DataFeed_Mode_name, DataFeed_Mode_value)
+	proto.RegisterEnum("loadtests.Stage_Interpolation", Stage_Interpolation_name, Stage_Interpolation_value)
+	proto.RegisterEnum("loadtests.LoadTest_State", LoadTest_State_name, LoadTest_State_value)
+	proto.RegisterEnum("loadtests.ControlLoadTestReq_Action", ControlLoadTestReq_Action_name, ControlLoadTestReq_Action_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -276,6 +818,10 @@ var _ grpc.ClientConn
 type SchedulerClient interface {
 	LoadTest(ctx context.Context, in *LoadTestReq, opts ...grpc.CallOption) (Scheduler_LoadTestClient, error)
 	RegisterExecutor(ctx context.Context, in *RegisterExecutorReq, opts ...grpc.CallOption) (*RegisterExecutorResp, error)
+	CancelLoadTest(ctx context.Context, in *CancelLoadTestReq, opts ...grpc.CallOption) (*CancelLoadTestResp, error)
+	ControlLoadTest(ctx context.Context, in *ControlLoadTestReq, opts ...grpc.CallOption) (*ControlLoadTestResp, error)
+	ListLoadTests(ctx context.Context, in *ListLoadTestsReq, opts ...grpc.CallOption) (*ListLoadTestsResp, error)
+	GetLoadTest(ctx context.Context, in *GetLoadTestReq, opts ...grpc.CallOption) (*GetLoadTestResp, error)
 }
 
 type schedulerClient struct {
@@ -327,11 +873,51 @@ func (c *schedulerClient) RegisterExecutor(ctx context.Context, in *RegisterExec
 	return out, nil
 }
 
+func (c *schedulerClient) CancelLoadTest(ctx context.Context, in *CancelLoadTestReq, opts ...grpc.CallOption) (*CancelLoadTestResp, error) {
+	out := new(CancelLoadTestResp)
+	err := grpc.Invoke(ctx, "/loadtests.Scheduler/CancelLoadTest", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerClient) ControlLoadTest(ctx context.Context, in *ControlLoadTestReq, opts ...grpc.CallOption) (*ControlLoadTestResp, error) {
+	out := new(ControlLoadTestResp)
+	err := grpc.Invoke(ctx, "/loadtests.Scheduler/ControlLoadTest", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerClient) ListLoadTests(ctx context.Context, in *ListLoadTestsReq, opts ...grpc.CallOption) (*ListLoadTestsResp, error) {
+	out := new(ListLoadTestsResp)
+	err := grpc.Invoke(ctx, "/loadtests.Scheduler/ListLoadTests", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schedulerClient) GetLoadTest(ctx context.Context, in *GetLoadTestReq, opts ...grpc.CallOption) (*GetLoadTestResp, error) {
+	out := new(GetLoadTestResp)
+	err := grpc.Invoke(ctx, "/loadtests.Scheduler/GetLoadTest", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Scheduler service
 
 type SchedulerServer interface {
 	LoadTest(*LoadTestReq, Scheduler_LoadTestServer) error
 	RegisterExecutor(context.Context, *RegisterExecutorReq) (*RegisterExecutorResp, error)
+	CancelLoadTest(context.Context, *CancelLoadTestReq) (*CancelLoadTestResp, error)
+	ControlLoadTest(context.Context, *ControlLoadTestReq) (*ControlLoadTestResp, error)
+	ListLoadTests(context.Context, *ListLoadTestsReq) (*ListLoadTestsResp, error)
+	GetLoadTest(context.Context, *GetLoadTestReq) (*GetLoadTestResp, error)
 }
 
 func RegisterSchedulerServer(s *grpc.Server, srv SchedulerServer) {
@@ -371,6 +957,54 @@ func _Scheduler_RegisterExecutor_Handler(srv interface{}, ctx context.Context, d
 	return out, nil
 }
 
+func _Scheduler_CancelLoadTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(CancelLoadTestReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SchedulerServer).CancelLoadTest(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Scheduler_ControlLoadTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ControlLoadTestReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SchedulerServer).ControlLoadTest(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Scheduler_ListLoadTests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ListLoadTestsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SchedulerServer).ListLoadTests(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Scheduler_GetLoadTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(GetLoadTestReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(SchedulerServer).GetLoadTest(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var _Scheduler_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "loadtests.Scheduler",
 	HandlerType: (*SchedulerServer)(nil),
@@ -379,6 +1013,22 @@ var _Scheduler_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterExecutor",
 			Handler:    _Scheduler_RegisterExecutor_Handler,
 		},
+		{
+			MethodName: "CancelLoadTest",
+			Handler:    _Scheduler_CancelLoadTest_Handler,
+		},
+		{
+			MethodName: "ControlLoadTest",
+			Handler:    _Scheduler_ControlLoadTest_Handler,
+		},
+		{
+			MethodName: "ListLoadTests",
+			Handler:    _Scheduler_ListLoadTests_Handler,
+		},
+		{
+			MethodName: "GetLoadTest",
+			Handler:    _Scheduler_GetLoadTest_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -390,37 +1040,103 @@ var _Scheduler_serviceDesc = grpc.ServiceDesc{
 }
 
 var fileDescriptor0 = []byte{
-	// 503 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x7c, 0x53, 0xc1, 0x6e, 0xd3, 0x40,
-	0x10, 0xad, 0x93, 0x38, 0xb5, 0xc7, 0x2d, 0x94, 0x0d, 0x10, 0xcb, 0x20, 0x52, 0x2c, 0x0e, 0x9c,
-	0x42, 0x65, 0x24, 0x38, 0x22, 0x55, 0x2a, 0xe2, 0xc0, 0x01, 0xb5, 0x70, 0xe1, 0xb2, 0xb2, 0xbd,
-	0x93, 0xc4, 0xc2, 0xf5, 0x6e, 0x76, 0xd7, 0x4a, 0x3e, 0x80, 0x3f, 0xe1, 0x17, 0xf8, 0x21, 0xfe,
-	0x84, 0xdd, 0x8d, 0x53, 0xaa, 0x92, 0xf4, 0x38, 0x6f, 0xde, 0xdb, 0x99, 0x79, 0x7e, 0x06, 0x22,
-	0x8a, 0x37, 0xaa, 0x5c, 0x20, 0x6b, 0x6b, 0x94, 0x53, 0x21, 0xb9, 0xe6, 0x24, 0xac, 0x79, 0xce,
-	0x34, 0x2a, 0xad, 0xd2, 0x3f, 0x1e, 0x44, 0x9f, 0x4d, 0xf5, 0xd5, 0x54, 0x97, 0xb8, 0x24, 0x11,
-	0xf4, 0x5b, 0x59, 0xc7, 0xde, 0xa9, 0xf7, 0x3a, 0x24, 0x0f, 0x60, 0xa8, 0x4a, 0x59, 0x09, 0x1d,
-	0xf7, 0x5c, 0x3d, 0x82, 0x68, 0x53, 0xd3, 0x26, 0xbf, 0xc6, 0xb8, 0xef, 0xc0, 0x13, 0x08, 0x64,
-	0xdb, 0x50, 0x5d, 0x19, 0x64, 0x60, 0x10, 0xdf, 0xd2, 0xae, 0xf3, 0x35, 0x5d, 0x71, 0xf9, 0x03,
-	0xa5, 0x8a, 0x87, 0x0e, 0x7c, 0x02, 0xc7, 0x73, 0xc9, 0x57, 0x7a, 0x41, 0x67, 0x79, 0xa9, 0xb9,
-	0x8c, 0x03, 0x03, 0x7b, 0xe4, 0x19, 0x8c, 0xac, 0x92, 0x16, 0xa8, 0x57, 0x88, 0x0d, 0xdd, 0x70,
-	0xe2, 0xd0, 0x35, 0x5f, 0xc1, 0x73, 0xa5, 0x73, 0xa9, 0xab, 0x66, 0x4e, 0x25, 0x2e, 0x5b, 0xbb,
-	0x31, 0x15, 0x28, 0xa9, 0xc2, 0x92, 0x37, 0x2c, 0x06, 0xf7, 0xf2, 0x04, 0xc6, 0x76, 0xdc, 0x2e,
-	0x42, 0x64, 0x09, 0xe9, 0xef, 0x1e, 0x1c, 0xfd, 0xbb, 0x51, 0x09, 0xf2, 0x0e, 0x42, 0x21, 0x51,
-	0xe4, 0xd2, 0x3c, 0xec, 0x4e, 0x8d, 0xb2, 0x97, 0xd3, 0x1b, 0x4f, 0xa6, 0xb7, 0xb9, 0xd3, 0x2f,
-	0x5b, 0xe2, 0xa7, 0x03, 0x72, 0x06, 0xbe, 0xdb, 0xc7, 0xd9, 0x11, 0x65, 0x93, 0x7d, 0x9a, 0x2b,
-	0x4b, 0x42, 0x66, 0x14, 0x19, 0x0c, 0x67, 0x55, 0x53, 0xa9, 0x85, 0x33, 0x2b, 0xca, 0x4e, 0xf7,
-	0x49, 0x3e, 0x3a, 0x96, 0xd3, 0x98, 0x29, 0x28, 0xa5, 0x71, 0x68, 0x70, 0xff, 0x94, 0x0b, 0x4b,
-	0xb2, 0x8a, 0x24, 0x81, 0xf0, 0x66, 0x4d, 0x72, 0x0c, 0x7e, 0xc9, 0xdb, 0x46, 0xbb, 0xc3, 0xfc,
-	0x24, 0x84, 0xc3, 0x6e, 0x9d, 0x04, 0x20, 0xd8, 0x8e, 0x49, 0x62, 0x38, 0xec, 0xf4, 0x56, 0xb0,
-	0x99, 0xe7, 0x3e, 0xfa, 0xf9, 0x21, 0xf8, 0x62, 0x91, 0x2b, 0x4c, 0xdf, 0xc3, 0xe8, 0x12, 0xe7,
-	0x95, 0xd2, 0x28, 0x2f, 0xd6, 0x58, 0xb6, 0xe6, 0xa3, 0xd9, 0x84, 0x10, 0x00, 0x26, 0xb9, 0xa8,
-	0x51, 0xd3, 0x8a, 0x39, 0x4d, 0x9f, 0x1c, 0xc1, 0x40, 0xf0, 0xce, 0x97, 0x7e, 0xfa, 0xd3, 0x83,
-	0xc7, 0xff, 0x2b, 0x8d, 0xef, 0x26, 0x18, 0x55, 0x33, 0xab, 0xdb, 0x35, 0xcd, 0x19, 0xeb, 0xe6,
-	0x91, 0x31, 0x3c, 0xec, 0xc0, 0x56, 0xa1, 0x74, 0xc1, 0xea, 0xdd, 0x69, 0x88, 0x5c, 0x29, 0x93,
-	0x26, 0xd6, 0x25, 0xee, 0x11, 0x84, 0x5d, 0x83, 0x15, 0xce, 0xa4, 0xd0, 0x2e, 0xd5, 0x41, 0x4a,
-	0xd5, 0xb1, 0x6f, 0xb0, 0x20, 0xfb, 0xe5, 0x41, 0x78, 0xb5, 0x4d, 0x3e, 0xf9, 0x00, 0xc1, 0xd6,
-	0x3d, 0xf2, 0x74, 0xa7, 0xa5, 0xcb, 0x64, 0xbc, 0xc7, 0xea, 0xf4, 0xe0, 0xcc, 0x23, 0xdf, 0xe0,
-	0xe4, 0xee, 0x51, 0xe4, 0xc5, 0x2d, 0xc1, 0x0e, 0xaf, 0x92, 0xc9, 0xbd, 0x7d, 0xfb, 0xf0, 0xf9,
-	0xe0, 0x7b, 0x4f, 0x14, 0xc5, 0xd0, 0xfd, 0x98, 0x6f, 0xff, 0x06, 0x00, 0x00, 0xff, 0xff, 0x8f,
-	0xf8, 0xe3, 0x3a, 0xae, 0x03, 0x00, 0x00,
+	// 1553 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x57, 0x5d, 0x6e, 0xe3, 0xc8,
+	0x11, 0x16, 0x45, 0x51, 0x22, 0x4b, 0x96, 0xc4, 0x69, 0x4f, 0x32, 0x0c, 0x77, 0x66, 0xad, 0x30,
+	0xbb, 0x13, 0x21, 0x08, 0x14, 0x43, 0x3b, 0x13, 0x60, 0x80, 0x4d, 0x02, 0xad, 0x4d, 0xef, 0x28,
+	0xf0, 0xc8, 0x1e, 0xc9, 0xda, 0x87, 0x00, 0x01, 0x41, 0x93, 0x2d, 0x89, 0xb0, 0xcc, 0xa6, 0xbb,
+	0x5b, 0xb6, 0x0f, 0x90, 0xe7, 0x5c, 0x20, 0x17, 0xc8, 0x25, 0xb2, 0x4f, 0x39, 0x47, 0x90, 0xa3,
+	0x04, 0xdd, 0x24, 0x65, 0x5a, 0x7f, 0x49, 0xde, 0xd4, 0xac, 0xaf, 0xba, 0xab, 0xbe, 0xaa, 0xfe,
+	0xba, 0x04, 0x28, 0xb9, 0xfe, 0x0d, 0x0b, 0xe6, 0x38, 0x5c, 0x2e, 0x30, 0xed, 0x26, 0x94, 0x70,
+	0x82, 0x8c, 0x05, 0xf1, 0x43, 0x8e, 0x19, 0x67, 0xce, 0x8f, 0x2a, 0xd4, 0xcf, 0x89, 0x1f, 0x5e,
+	0x61, 0xc6, 0x47, 0xf8, 0x0e, 0xd5, 0x41, 0x5d, 0xd2, 0x85, 0xa5, 0xb4, 0x95, 0x8e, 0x81, 0x9a,
+	0x50, 0x65, 0x01, 0x8d, 0x12, 0x6e, 0x95, 0xe5, 0xfa, 0x10, 0xea, 0xe9, 0xda, 0x8b, 0xfd, 0x5b,
+	0x6c, 0xa9, 0xf2, 0xa3, 0x09, 0x3a, 0x5d, 0xc6, 0x1e, 0x8f, 0x6e, 0xb1, 0x55, 0x69, 0x2b, 0x1d,
+	0x0d, 0xfd, 0x04, 0x1a, 0x33, 0x4a, 0x1e, 0xf8, 0xdc, 0x9b, 0xfa, 0x01, 0x27, 0xd4, 0xd2, 0xdb,
+	0x4a, 0x47, 0x41, 0x5f, 0xc0, 0xa1, 0x00, 0x79, 0xd7, 0x98, 0x3f, 0x60, 0x1c, 0x7b, 0x29, 0xc6,
+	0x32, 0xa4, 0xf1, 0x2b, 0x78, 0xcd, 0xb8, 0x4f, 0x79, 0x14, 0xcf, 0x3c, 0x8a, 0xef, 0x96, 0x22,
+	0x38, 0x2f, 0xc1, 0xd4, 0x63, 0x38, 0x20, 0x71, 0x68, 0x81, 0xdc, 0xf9, 0x08, 0x5e, 0xdd, 0xfa,
+	0x8f, 0x5b, 0x01, 0xf5, 0xfc, 0xe8, 0x2c, 0xc2, 0x80, 0xc4, 0xd3, 0x68, 0x66, 0x1d, 0xc8, 0x18,
+	0x5f, 0xc2, 0xc1, 0x0d, 0xc6, 0x89, 0x17, 0x10, 0x72, 0x13, 0x61, 0x66, 0x35, 0xda, 0x4a, 0x47,
+	0x47, 0x08, 0x80, 0x24, 0x38, 0xf6, 0x6e, 0x49, 0x88, 0x17, 0x56, 0x53, 0x7e, 0x6b, 0x43, 0x95,
+	0x71, 0x7f, 0x86, 0x99, 0xd5, 0x6a, 0xab, 0x9d, 0x7a, 0xcf, 0xec, 0xae, 0xb8, 0xea, 0x8e, 0x85,
+	0x41, 0x78, 0xf1, 0x39, 0xc5, 0x6c, 0x4e, 0x16, 0x21, 0xb3, 0xcc, 0xb6, 0xda, 0x31, 0x50, 0x0b,
+	0x6a, 0x94, 0x2c, 0x16, 0xcb, 0x84, 0x59, 0x2f, 0xe4, 0x36, 0x6f, 0xc1, 0x08, 0x7d, 0xee, 0x7b,
+	0x53, 0x8c, 0x43, 0x0b, 0xb5, 0x95, 0x4e, 0xbd, 0x77, 0x58, 0xd8, 0xe9, 0xd4, 0xe7, 0xfe, 0x19,
+	0xc6, 0xa1, 0xc0, 0xb1, 0x00, 0xc7, 0x3e, 0x8d, 0x08, 0xb3, 0x0e, 0xdb, 0xea, 0x1a, 0x6e, 0x9c,
+	0xd9, 0x90, 0x05, 0xa6, 0x7f, 0x4d, 0xa8, 0x4c, 0x2b, 0x8c, 0x78, 0x44, 0x62, 0x66, 0xbd, 0x14,
+	0x47, 0x3b, 0x7f, 0x55, 0x40, 0x5f, 0xc1, 0x0e, 0xa0, 0x22, 0x2b, 0xb3, 0xbd, 0x7c, 0x1b, 0xe4,
+	0xa8, 0x39, 0xec, 0x01, 0x47, 0xb3, 0x39, 0xb7, 0x2a, 0x79, 0x9d, 0xb6, 0x11, 0xac, 0x49, 0x82,
+	0x9f, 0xf8, 0xa9, 0x6e, 0xe7, 0xc7, 0xf9, 0x51, 0x01, 0x7d, 0x95, 0x5f, 0x0b, 0x6a, 0x01, 0x89,
+	0x39, 0x8e, 0xb9, 0x8c, 0xe9, 0x00, 0xfd, 0x0a, 0xaa, 0x53, 0x42, 0x6f, 0xfd, 0x34, 0xa6, 0x66,
+	0xcf, 0xde, 0xc2, 0x4a, 0xf7, 0x4c, 0x22, 0xd0, 0x5b, 0xa8, 0x88, 0xd2, 0xc8, 0x30, 0x9b, 0x3d,
+	0x6b, 0x1b, 0xf2, 0x13, 0x09, 0xb1, 0xf3, 0x06, 0xaa, 0x99, 0x47, 0x0d, 0xd4, 0x93, 0xf1, 0x0f,
+	0x66, 0x09, 0x01, 0x54, 0x87, 0xa7, 0x7f, 0x1c, 0x5f, 0x0c, 0x4d, 0xc5, 0xe9, 0x42, 0x45, 0xc0,
+	0x50, 0x13, 0x60, 0xec, 0x7e, 0x9e, 0xb8, 0xc3, 0xab, 0x41, 0xff, 0x3c, 0xc5, 0x8c, 0xfa, 0xc3,
+	0xd3, 0x8b, 0x4f, 0xa6, 0x22, 0x7e, 0x4f, 0x86, 0x83, 0xcf, 0x13, 0xd7, 0x2c, 0x3b, 0x7f, 0x53,
+	0x40, 0x4b, 0x4b, 0x6d, 0x82, 0x1e, 0x2e, 0xa9, 0x2f, 0xe8, 0x96, 0xe1, 0x2b, 0xc8, 0x01, 0x9b,
+	0xfb, 0x74, 0x86, 0xf9, 0xd6, 0x1e, 0x2c, 0x4b, 0x8a, 0xde, 0x43, 0x23, 0x8a, 0x39, 0xa6, 0x09,
+	0x59, 0xa4, 0xae, 0x69, 0xfc, 0x5f, 0xae, 0x33, 0xd5, 0x1d, 0x14, 0x51, 0xce, 0xd7, 0xd0, 0x78,
+	0xf6, 0x41, 0xc4, 0x74, 0x3e, 0x18, 0xba, 0xfd, 0x91, 0x59, 0x42, 0x3a, 0x54, 0xc6, 0x57, 0xee,
+	0xa5, 0xa9, 0x38, 0xff, 0xae, 0xc2, 0xc1, 0xd3, 0x85, 0x65, 0x09, 0xfa, 0x2d, 0x18, 0x09, 0xc5,
+	0x89, 0x4f, 0xa3, 0x78, 0x26, 0xa3, 0xac, 0xf7, 0x7e, 0x5e, 0x38, 0xaa, 0x88, 0xed, 0x5e, 0xe6,
+	0xc0, 0x8f, 0x25, 0x74, 0x0c, 0x9a, 0xbc, 0x71, 0x32, 0xea, 0x7a, 0xef, 0x68, 0x97, 0xcf, 0x58,
+	0x80, 0x70, 0xf8, 0xb1, 0x84, 0x7a, 0x50, 0x9d, 0x46, 0x71, 0xc4, 0xe6, 0x32, 0xa3, 0x7a, 0xaf,
+	0xbd, 0xcb, 0xe5, 0x4c, 0xa2, 0xa4, 0xcf, 0x31, 0x68, 0x98, 0x52, 0x42, 0xad, 0xca, 0xfe, 0x53,
+	0x5c, 0x01, 0x92, 0x1e, 0xdf, 0x40, 0x35, 0xf0, 0xe3, 0x00, 0x2f, 0x2c, 0x6d, 0x7f, 0x32, 0x27,
+	0x12, 0xb5, 0x90, 0x4e, 0xef, 0x40, 0x4f, 0x28, 0x99, 0x51, 0xcc, 0x44, 0x63, 0xee, 0x0d, 0xee,
+	0x32, 0xc3, 0x7d, 0x2c, 0xd9, 0x6f, 0xc1, 0x58, 0x31, 0x82, 0x1a, 0xa0, 0x05, 0x64, 0x99, 0x35,
+	0xaa, 0x86, 0x00, 0xca, 0x51, 0x5a, 0x51, 0xc3, 0x36, 0xa0, 0x96, 0xb1, 0x60, 0x7f, 0x06, 0x3d,
+	0xcf, 0x0e, 0xfd, 0x02, 0x6a, 0xf7, 0x98, 0x86, 0x51, 0xc0, 0x33, 0xde, 0x51, 0xe1, 0xcc, 0x1f,
+	0x52, 0x8b, 0xb8, 0x30, 0xc1, 0x1c, 0x07, 0x37, 0xcc, 0x2a, 0x6f, 0x5c, 0x98, 0x13, 0x61, 0xb0,
+	0x3f, 0x40, 0x2d, 0xcb, 0x1e, 0x35, 0x72, 0xb6, 0xd2, 0x0b, 0x7c, 0x04, 0x9a, 0xbc, 0xf5, 0x59,
+	0x89, 0x8a, 0xae, 0x7d, 0xf1, 0xdd, 0xae, 0x83, 0xb1, 0x62, 0xc1, 0xfe, 0x47, 0x19, 0xf4, 0x3c,
+	0x39, 0xd1, 0xba, 0xb2, 0x09, 0xef, 0xfd, 0x85, 0xa5, 0xec, 0xbb, 0xd6, 0x65, 0x69, 0x44, 0x00,
+	0xf8, 0x11, 0x07, 0xcb, 0x54, 0x59, 0x44, 0x79, 0x55, 0x29, 0xec, 0x99, 0x83, 0xac, 0x9e, 0x2a,
+	0x94, 0x42, 0x86, 0xc7, 0x64, 0x69, 0x54, 0xf4, 0x4e, 0xb4, 0x10, 0x4e, 0x72, 0x2d, 0xf8, 0xfa,
+	0xbf, 0x51, 0xde, 0x1d, 0x73, 0x9c, 0x14, 0x18, 0xa9, 0xed, 0x60, 0x84, 0x43, 0x45, 0x22, 0x9f,
+	0xcb, 0xd9, 0xaa, 0x40, 0xe5, 0xb5, 0x60, 0xd2, 0x70, 0xeb, 0xa0, 0x26, 0xef, 0x8f, 0x33, 0x0d,
+	0x13, 0x8b, 0x0f, 0xc7, 0x96, 0xf6, 0xb4, 0x78, 0x6f, 0x55, 0x9f, 0x16, 0x1f, 0xac, 0x5a, 0xbe,
+	0xb8, 0xf5, 0x1f, 0xd3, 0xf7, 0xe9, 0xbb, 0x1a, 0x68, 0xc9, 0xdc, 0x67, 0xd8, 0xf9, 0x35, 0x68,
+	0x92, 0x5e, 0xf4, 0x02, 0x8c, 0x95, 0xde, 0x66, 0x41, 0x98, 0xa0, 0x93, 0x6b, 0x86, 0xe9, 0x3d,
+	0xce, 0x9a, 0xc3, 0x79, 0x07, 0x9a, 0x8c, 0x7a, 0x53, 0x7c, 0x13, 0x9f, 0x31, 0xcc, 0xb2, 0x70,
+	0x1b, 0xa0, 0x4d, 0xfd, 0x68, 0x91, 0x45, 0x2b, 0x64, 0xbb, 0x96, 0xb7, 0x48, 0x0e, 0x0d, 0xa5,
+	0xab, 0x8e, 0x8e, 0x9f, 0xbd, 0x30, 0x69, 0xdb, 0xbc, 0xde, 0x6c, 0xad, 0xee, 0x55, 0x0e, 0xb2,
+	0xfb, 0x60, 0xac, 0x16, 0x69, 0x2d, 0x13, 0xc1, 0xf7, 0x53, 0xd8, 0x4f, 0x47, 0x94, 0xe5, 0x11,
+	0xc5, 0x34, 0xe4, 0x2b, 0xe0, 0x4c, 0xe1, 0x70, 0x84, 0x67, 0x11, 0xe3, 0x98, 0xba, 0xb2, 0x13,
+	0x08, 0x15, 0xf3, 0x00, 0x02, 0x08, 0x29, 0x49, 0x16, 0x98, 0x7b, 0x51, 0x1a, 0x9f, 0x2a, 0x12,
+	0x4d, 0xf2, 0xae, 0x54, 0xc5, 0xd3, 0x24, 0xa7, 0x8a, 0x80, 0x2c, 0xbc, 0x7b, 0x4c, 0x59, 0xae,
+	0x78, 0x9a, 0x18, 0x17, 0x02, 0x4c, 0x79, 0x34, 0x8d, 0x02, 0x9f, 0xa7, 0xc3, 0xc1, 0x81, 0xf3,
+	0x17, 0x05, 0x5e, 0x6e, 0x1e, 0xc4, 0x12, 0x81, 0x8e, 0xe2, 0xe9, 0x62, 0xf9, 0xe8, 0xf9, 0x61,
+	0x98, 0xdf, 0x80, 0x57, 0xd0, 0xca, 0x3e, 0x2e, 0x19, 0xa6, 0x92, 0xde, 0xf2, 0x9a, 0x41, 0xe4,
+	0xf5, 0x40, 0x68, 0x96, 0x87, 0xa8, 0x59, 0x66, 0x08, 0xaf, 0xe5, 0x91, 0x86, 0xc8, 0x21, 0xfb,
+	0xc4, 0x58, 0xaa, 0x2a, 0xba, 0xf3, 0xf7, 0x32, 0xe8, 0x79, 0x8f, 0x66, 0x77, 0x5d, 0xd9, 0x36,
+	0xe3, 0xa4, 0xa7, 0x65, 0x53, 0x51, 0x7a, 0x42, 0x47, 0x0a, 0x67, 0x96, 0x50, 0xb3, 0xf7, 0xb3,
+	0x2d, 0x5d, 0x2f, 0x44, 0x93, 0x63, 0x11, 0x0b, 0xce, 0x52, 0x64, 0xd9, 0xfb, 0x89, 0x00, 0x58,
+	0x2a, 0x25, 0x9e, 0xcf, 0xad, 0x6a, 0x7e, 0xd1, 0x70, 0x1c, 0xa6, 0x5f, 0x6a, 0x79, 0xb3, 0xa4,
+	0x3a, 0xa0, 0x3f, 0x55, 0x6f, 0x29, 0xaa, 0x67, 0xc8, 0xea, 0x35, 0x40, 0x23, 0x0f, 0x31, 0xa6,
+	0x72, 0x2a, 0x32, 0x9c, 0x3f, 0xcb, 0xf7, 0x8a, 0x63, 0xd4, 0x00, 0xe3, 0x72, 0xe4, 0x5e, 0xf6,
+	0x47, 0x83, 0xe1, 0xf7, 0x66, 0x09, 0xd5, 0xa1, 0x36, 0x9a, 0x0c, 0x87, 0x62, 0xa1, 0x88, 0xd7,
+	0xef, 0xa4, 0x3f, 0x3c, 0x71, 0xcf, 0xcf, 0xc5, 0xba, 0x8c, 0x0e, 0x40, 0x3f, 0x1b, 0x0c, 0x07,
+	0xe3, 0x8f, 0xee, 0xa9, 0x29, 0x0e, 0x34, 0x32, 0xab, 0x7b, 0x6a, 0x56, 0x84, 0xa7, 0x3b, 0x1a,
+	0x5d, 0x8c, 0xdc, 0x53, 0x53, 0x73, 0x8e, 0xe0, 0x45, 0x2a, 0x32, 0xc5, 0x39, 0xb1, 0x40, 0x99,
+	0xf3, 0x2d, 0xa0, 0x75, 0x00, 0x4b, 0xc4, 0x68, 0x23, 0x88, 0xf1, 0x04, 0x33, 0x99, 0x3e, 0x1e,
+	0x6e, 0xa1, 0xca, 0xf9, 0xa7, 0x02, 0xe8, 0x84, 0xc4, 0x9c, 0x92, 0x5d, 0x07, 0xa0, 0x77, 0x50,
+	0xf5, 0x03, 0x79, 0x09, 0xd3, 0xa1, 0xe1, 0xab, 0xa2, 0x62, 0x6c, 0xb8, 0x76, 0xfb, 0x12, 0xbb,
+	0x4b, 0xf0, 0xd2, 0xde, 0x6c, 0x41, 0xed, 0x81, 0xd0, 0x1b, 0x4c, 0x53, 0x6d, 0xd3, 0x9c, 0xdf,
+	0x43, 0x35, 0xf3, 0x6b, 0x41, 0x7d, 0x32, 0x1c, 0x5f, 0xba, 0x27, 0x83, 0xb3, 0x81, 0x7b, 0x6a,
+	0x96, 0x90, 0x01, 0xda, 0x65, 0x7f, 0x32, 0x76, 0xd3, 0x39, 0x61, 0xe4, 0x8e, 0x27, 0x9f, 0xdc,
+	0x94, 0xc1, 0xb1, 0x7b, 0xe5, 0x8d, 0xfa, 0x57, 0xae, 0xa9, 0x3a, 0xbf, 0x83, 0xc3, 0x8d, 0x50,
+	0xfe, 0x0f, 0x16, 0x10, 0x98, 0xe7, 0x11, 0xe3, 0xf9, 0x9a, 0x8d, 0xf0, 0x9d, 0xf3, 0x2d, 0xbc,
+	0x58, 0xfb, 0xc6, 0x12, 0xf4, 0x4b, 0x80, 0xd5, 0x86, 0xcc, 0x52, 0x36, 0x46, 0xc6, 0xd5, 0x8e,
+	0xaf, 0xa1, 0xf9, 0x3d, 0xe6, 0xbb, 0x6a, 0xf6, 0x01, 0x5a, 0xcf, 0xac, 0xff, 0x7b, 0xa8, 0xbd,
+	0x7f, 0xa9, 0x60, 0x8c, 0xf3, 0x7f, 0x14, 0xe8, 0x0f, 0x85, 0x7b, 0xf4, 0xd3, 0xad, 0x0f, 0xc0,
+	0x9d, 0xfd, 0x6a, 0xc7, 0xc3, 0xe0, 0x94, 0x8e, 0x15, 0x34, 0x01, 0x73, 0x5d, 0x0f, 0x50, 0x71,
+	0x56, 0xda, 0xa2, 0x4a, 0xf6, 0xd1, 0x5e, 0xbb, 0xd8, 0x18, 0x5d, 0x40, 0xf3, 0x79, 0x53, 0xa2,
+	0xa2, 0x84, 0x6e, 0x34, 0xb4, 0xfd, 0x66, 0x8f, 0x55, 0x6e, 0x38, 0x82, 0xd6, 0x5a, 0x81, 0xd1,
+	0x9b, 0xbd, 0x7d, 0x68, 0x7f, 0xb9, 0xcf, 0x2c, 0xf7, 0x3c, 0x87, 0xc6, 0xb3, 0x0a, 0xa3, 0x2f,
+	0x8a, 0x4c, 0xad, 0xf5, 0x83, 0xfd, 0x7a, 0xb7, 0x51, 0xee, 0x76, 0x06, 0xf5, 0x42, 0x4d, 0x51,
+	0x51, 0x98, 0x9e, 0x77, 0x82, 0x6d, 0xef, 0x32, 0x89, 0x7d, 0xbe, 0xab, 0xfc, 0xa9, 0x9c, 0x5c,
+	0x5f, 0x57, 0xa5, 0xaa, 0x7f, 0xf3, 0x9f, 0x01, 0x00, 0x19, 0xae, 0xa4, 0x0c, 0x41, 0x0e, 0x00,
+	0x00,
 }
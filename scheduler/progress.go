@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/executor/stats"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// How often the client is told how its load test is going
+var progressInterval = 2 * time.Second
+
+// progress merges the snapshots of every executor of a load test until they
+// are sent to the client.
+type progress struct {
+	lock           sync.Mutex
+	executions     int64
+	requests       int64
+	failedRequests int64
+	errors         int64
+	steps          map[string]*progressStep
+	checks         map[string]*pb.Check
+}
+
+type progressStep struct {
+	errors  int64
+	latency stats.Histogram
+}
+
+func newProgress() *progress {
+	return &progress{
+		steps:  make(map[string]*progressStep),
+		checks: make(map[string]*pb.Check),
+	}
+}
+
+func (p *progress) add(snap *executorpb.Snapshot) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.executions += snap.Executions
+	p.requests += snap.Requests
+	p.failedRequests += snap.FailedRequests
+	p.errors += snap.Errors
+	// the steps and checks of scenarios are told apart by their name
+	name := func(name string) string {
+		if snap.Scenario == "" {
+			return name
+		}
+		return snap.Scenario + "/" + name
+	}
+	for _, s := range snap.Steps {
+		step, ok := p.steps[name(s.Name)]
+		if !ok {
+			step = &progressStep{}
+			p.steps[name(s.Name)] = step
+		}
+		step.errors += s.Errors
+		if s.Latency != nil {
+			step.latency.Merge(stats.FromProto(s.Latency))
+		}
+	}
+	for _, c := range snap.Checks {
+		check, ok := p.checks[name(c.Name)]
+		if !ok {
+			check = &pb.Check{Name: name(c.Name)}
+			p.checks[name(c.Name)] = check
+		}
+		check.Passes += c.Passes
+		check.Fails += c.Fails
+	}
+}
+
+// executionCount is how many executions the progress covers.
+func (p *progress) executionCount() int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.executions
+}
+
+// checkResults returns the outcomes of every check, sorted by name.
+func (p *progress) checkResults() []*pb.Check {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.sortedChecks()
+}
+
+func (p *progress) sortedChecks() []*pb.Check {
+	names := make([]string, 0, len(p.checks))
+	for name := range p.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]*pb.Check, 0, len(names))
+	for _, name := range names {
+		c := *p.checks[name]
+		checks = append(checks, &c)
+	}
+	return checks
+}
+
+// flush returns what was merged so far, covering `interval`, and starts over.
+func (p *progress) flush(interval time.Duration) *pb.LoadTestResp_Progress {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	seconds := func(d time.Duration) float64 { return d.Seconds() }
+	prog := &pb.LoadTestResp_Progress{
+		Interval:   interval.Seconds(),
+		Executions: p.executions,
+		Requests:   p.requests,
+		Errors:     p.errors,
+	}
+	if interval > 0 {
+		prog.RequestsPerSecond = float64(p.requests) / interval.Seconds()
+	}
+	names := make([]string, 0, len(p.steps))
+	for name := range p.steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		step := p.steps[name]
+		prog.Steps = append(prog.Steps, &pb.LoadTestResp_Progress_Step{
+			Name:   name,
+			Count:  step.latency.Count(),
+			Errors: step.errors,
+			P50:    seconds(step.latency.Quantile(0.50)),
+			P90:    seconds(step.latency.Quantile(0.90)),
+			P95:    seconds(step.latency.Quantile(0.95)),
+			P99:    seconds(step.latency.Quantile(0.99)),
+			Max:    seconds(step.latency.Max()),
+		})
+	}
+	prog.Checks = p.sortedChecks()
+
+	p.executions, p.requests, p.failedRequests, p.errors = 0, 0, 0, 0
+	p.steps = make(map[string]*progressStep)
+	p.checks = make(map[string]*pb.Check)
+	return prog
+}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	pb "github.com/lgpeterson/loadtests/executor/pb"
+)
+
+// The commands are sent, and the statuses read, in the version of the
+// protocol the executor speaks. Executors speaking V2 answer the Run command
+// before running it, an invalid command fails there.
+
+// run starts an execution of the executor.
+func (e *executor) run(params *pb.ScriptParams, scriptConfig string) error {
+	if e.version < pb.ProtocolVersion_V2 {
+		return e.cmdClient.Send(&pb.CommandMessage{
+			Command:      "Run",
+			ScriptParams: params,
+			ScriptConfig: scriptConfig,
+		})
+	}
+	err := e.cmdClient.Send(&pb.CommandMessage{Kind: &pb.CommandMessage_Run{
+		Run: &pb.Run{ScriptParams: params, ScriptConfig: scriptConfig},
+	}})
+	if err != nil {
+		return err
+	}
+	res, err := e.cmdClient.Recv()
+	if err != nil {
+		return err
+	}
+	switch event := res.Event.(type) {
+	case *pb.StatusMessage_Accepted:
+		return nil
+	case *pb.StatusMessage_Failed:
+		return e.failure(event.Failed.Code, event.Failed.Message)
+	default:
+		return fmt.Errorf("executor %d answered the run command with %T", e.id, res.Event)
+	}
+}
+
+// halt ends the execution of the executor early.
+func (e *executor) halt() error {
+	if e.version < pb.ProtocolVersion_V2 {
+		return e.cmdClient.Send(&pb.CommandMessage{Command: "Halt"})
+	}
+	return e.cmdClient.Send(&pb.CommandMessage{Kind: &pb.CommandMessage_Halt{
+		Halt: &pb.Halt{},
+	}})
+}
+
+// waitCompletion reads the statuses of the execution until it's over, the
+// snapshots are given to `onSnapshot`.
+func (e *executor) waitCompletion(ll *logrus.Entry, onSnapshot func(*pb.Snapshot)) error {
+	for {
+		res, err := e.cmdClient.Recv()
+		if err != nil {
+			ll.WithError(err).Error("couldn't wait to receive status")
+			return err
+		}
+		if e.version < pb.ProtocolVersion_V2 {
+			if res.Snapshot != nil {
+				onSnapshot(res.Snapshot)
+				continue
+			}
+			ll.WithField("status", res.Status).Info("execution completed")
+			return nil
+		}
+
+		switch event := res.Event.(type) {
+		case *pb.StatusMessage_Started:
+			ll.Info("execution started")
+		case *pb.StatusMessage_Progress:
+			onSnapshot(event.Progress)
+		case *pb.StatusMessage_Accepted:
+			e.answer(ll, nil)
+		case *pb.StatusMessage_Rejected:
+			ll.WithFields(logrus.Fields{
+				"code":    event.Rejected.Code,
+				"message": event.Rejected.Message,
+			}).Warn("command rejected")
+			e.answer(ll, fmt.Errorf("executor %d rejected the control (%v): %s", e.id, event.Rejected.Code, event.Rejected.Message))
+		case *pb.StatusMessage_Finished:
+			summary := event.Finished.Summary
+			if summary == nil {
+				summary = &pb.Summary{}
+			}
+			ll.WithFields(logrus.Fields{
+				"halted":     event.Finished.Halted,
+				"duration":   summary.Duration,
+				"executions": summary.Executions,
+				"requests":   summary.Requests,
+				"errors":     summary.Errors,
+			}).Info("execution completed")
+			return nil
+		case *pb.StatusMessage_Failed:
+			ll = ll.WithFields(logrus.Fields{
+				"code":    event.Failed.Code,
+				"message": event.Failed.Message,
+			})
+			if event.Failed.Code == pb.ErrorCode_PERSISTENCE {
+				// The load was generated, and the snapshots of the results
+				// received, only the metrics stored by the executor are lost
+				ll.Error("execution completed without persisting its metrics")
+				return nil
+			}
+			ll.Error("execution failed")
+			return e.failure(event.Failed.Code, event.Failed.Message)
+		default:
+			ll.WithField("event", fmt.Sprintf("%T", res.Event)).Warn("unexpected status")
+		}
+	}
+}
+
+// answer hands the answer to a control to the one waiting for it, there's
+// none if it came too late.
+func (e *executor) answer(ll *logrus.Entry, err error) {
+	select {
+	case e.answers <- err:
+	default:
+		ll.Warn("nobody waits for the answer to a control")
+	}
+}
+
+func (e *executor) failure(code pb.ErrorCode, message string) error {
+	return fmt.Errorf("executor %d failed (%v): %s", e.id, code, message)
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"golang.org/x/net/context"
+)
+
+// commandStream plays the execution of an executor: the commands sent to it
+// are read from `sent`, and the statuses it answers written to `statuses`.
+type commandStream struct {
+	executorpb.Commander_ExecuteCommandClient
+	sent     chan *executorpb.CommandMessage
+	statuses chan *executorpb.StatusMessage
+}
+
+func (s *commandStream) Send(cmd *executorpb.CommandMessage) error {
+	s.sent <- cmd
+	return nil
+}
+
+func (s *commandStream) Recv() (*executorpb.StatusMessage, error) {
+	status, ok := <-s.statuses
+	if !ok {
+		return nil, io.EOF
+	}
+	return status, nil
+}
+
+// runningExecutors are `n` executors running an execution, which ends once
+// the statuses are closed.
+func runningExecutors(n int) (*executors, []*commandStream, chan error) {
+	e := &executors{}
+	var streams []*commandStream
+	for i := 0; i < n; i++ {
+		stream := &commandStream{
+			sent:     make(chan *executorpb.CommandMessage, 1),
+			statuses: make(chan *executorpb.StatusMessage, 1),
+		}
+		streams = append(streams, stream)
+		e.executors = append(e.executors, &executor{
+			id:        i,
+			version:   executorpb.ProtocolVersion_V2,
+			cmdClient: stream,
+			answers:   make(chan error, 1),
+		})
+	}
+	completion := make(chan error, 1)
+	go func() {
+		completion <- e.waitCompletion(context.Background(), func(*executorpb.Snapshot) {})
+	}()
+	return e, streams, completion
+}
+
+var accepted = &executorpb.StatusMessage{Event: &executorpb.StatusMessage_Accepted{Accepted: &executorpb.Accepted{}}}
+
+func rejected(message string) *executorpb.StatusMessage {
+	return &executorpb.StatusMessage{Event: &executorpb.StatusMessage_Rejected{Rejected: &executorpb.Rejected{
+		Code:    executorpb.ErrorCode_INVALID_COMMAND,
+		Message: message,
+	}}}
+}
+
+func TestControlShares(t *testing.T) {
+	svc := NewServer(&Config{MaxWorkerPerExecutor: 10, MaxExecPSPerExecutor: 100}, nil)
+	e, streams, completion := runningExecutors(3)
+
+	// Every executor accepts, the rate and workers add up to the control
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.control(context.Background(), e, false, &pb.ControlLoadTestReq{
+			Action:            pb.ControlLoadTestReq_SET_RATE,
+			RequestsPerSecond: 100,
+			Workers:           8,
+		})
+	}()
+	var got []string
+	for _, stream := range streams {
+		rate := (<-stream.sent).GetSetRate()
+		got = append(got, fmt.Sprintf("%d/%d", rate.RequestsPerSecond, rate.Workers))
+		stream.statuses <- accepted
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if want := "[34/3 33/3 33/2]"; fmt.Sprint(got) != want {
+		t.Errorf("want the rate and workers %s, got %v", want, got)
+	}
+
+	// The controls that can't be shared aren't sent
+	for _, req := range []*pb.ControlLoadTestReq{
+		{Action: pb.ControlLoadTestReq_SET_RATE},
+		{Action: pb.ControlLoadTestReq_SET_RATE, RequestsPerSecond: 301},
+		{Action: pb.ControlLoadTestReq_SET_RATE, RequestsPerSecond: 32},
+		{Action: pb.ControlLoadTestReq_SET_RATE, Workers: 2},
+		{Action: pb.ControlLoadTestReq_SET_RATE, Workers: 31},
+	} {
+		if err := svc.control(context.Background(), e, false, req); err == nil {
+			t.Errorf("%v: want an error", req)
+		}
+	}
+	// but in the open model, a low rate is fine
+	go func() {
+		done <- svc.control(context.Background(), e, true, &pb.ControlLoadTestReq{
+			Action:            pb.ControlLoadTestReq_SET_RATE,
+			RequestsPerSecond: 4,
+		})
+	}()
+	got = nil
+	for _, stream := range streams {
+		got = append(got, fmt.Sprint((<-stream.sent).GetSetRate().RequestsPerSecond))
+		stream.statuses <- accepted
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if want := "[2 1 1]"; fmt.Sprint(got) != want {
+		t.Errorf("want the rates %s, got %v", want, got)
+	}
+
+	for _, stream := range streams {
+		close(stream.statuses)
+	}
+	<-completion
+}
+
+func TestControlAnswers(t *testing.T) {
+	svc := NewServer(&Config{MaxWorkerPerExecutor: 10, MaxExecPSPerExecutor: 100}, nil)
+	e, streams, completion := runningExecutors(2)
+	pause := &pb.ControlLoadTestReq{Action: pb.ControlLoadTestReq_PAUSE}
+
+	// One executor rejecting the control fails it
+	done := make(chan error, 1)
+	go func() { done <- svc.control(context.Background(), e, false, pause) }()
+	for i, stream := range streams {
+		if (<-stream.sent).GetPause() == nil {
+			t.Fatal("want a pause sent")
+		}
+		if i == 0 {
+			stream.statuses <- accepted
+		} else {
+			stream.statuses <- rejected("already paused")
+		}
+	}
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "already paused") {
+		t.Errorf("want the rejection, got %v", err)
+	}
+
+	// An executor that doesn't answer fails it too
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	go func() { done <- svc.control(ctx, e, false, pause) }()
+	<-streams[0].sent
+	streams[0].statuses <- accepted
+	<-streams[1].sent
+	if err := <-done; err == nil {
+		t.Error("want an error without an answer")
+	}
+	cancel()
+
+	// and its late answer isn't taken for the one of the next control
+	streams[1].statuses <- rejected("late")
+	for len(e.executors[1].answers) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() { done <- svc.control(context.Background(), e, false, pause) }()
+	for _, stream := range streams {
+		<-stream.sent
+		stream.statuses <- accepted
+	}
+	if err := <-done; err != nil {
+		t.Errorf("want the control accepted, got %v", err)
+	}
+
+	for _, stream := range streams {
+		close(stream.statuses)
+	}
+	<-completion
+}
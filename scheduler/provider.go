@@ -0,0 +1,18 @@
+package scheduler
+
+// executorPrefix starts the name of every machine running an executor
+const executorPrefix = "executor"
+
+// Provider launches the machines that executors run on.
+type Provider interface {
+	// Cleanup destroys the machines left over by a previous scheduler.
+	Cleanup() error
+	// Launch starts a machine running an executor. Once it's up, the
+	// executor registers itself with the returned id.
+	Launch(name string) (int, error)
+	// Inspect returns the host where the executor of a machine can be
+	// reached.
+	Inspect(id int) (string, error)
+	// Destroy stops a machine and its executor.
+	Destroy(id int) error
+}
@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+func TestLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Stands for executord, it runs until it's killed
+	binary := filepath.Join(dir, "executord")
+	if err := ioutil.WriteFile(binary, []byte("#!/bin/sh\nwhile :; do sleep 1; done\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pidFile := filepath.Join(dir, "executors.pid")
+	pids := func() []int {
+		data, err := ioutil.ReadFile(pidFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pids []int
+		for _, field := range strings.Fields(string(data)) {
+			pid, err := strconv.Atoi(field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pids = append(pids, pid)
+		}
+		return pids
+	}
+
+	previous := NewLocal(binary, "127.0.0.1:1", "", "token", pidFile)
+	if err := previous.Cleanup(); err != nil {
+		t.Fatalf("cleaning up without a pid file: %v", err)
+	}
+	var ids []int
+	for i := 0; i < 3; i++ {
+		id, err := previous.Launch(fmt.Sprintf("executor-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	defer func() {
+		for _, cmd := range previous.processes {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}()
+	if host, err := previous.Inspect(ids[0]); err != nil || host != "127.0.0.1" {
+		t.Errorf("want the executor on 127.0.0.1, got %q, %v", host, err)
+	}
+	if _, err := previous.Inspect(42); err == nil {
+		t.Error("want an error inspecting an unknown executor")
+	}
+	if err := previous.Destroy(ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := previous.Destroy(ids[0]); err == nil {
+		t.Error("want an error destroying an executor twice")
+	}
+	left := pids()
+	if len(left) != 2 {
+		t.Fatalf("want the pids of 2 executors, got %v", left)
+	}
+
+	// The pid of a process that isn't an executor is left alone
+	if err := ioutil.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n%d\n%d\n", left[0], os.Getpid(), left[1])), 0644); err != nil {
+		t.Fatal(err)
+	}
+	next := NewLocal(binary, "127.0.0.1:1", "", "token", pidFile)
+	if err := next.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for _, pid := range left {
+		for next.runs(pid) {
+			if time.Now().After(deadline) {
+				t.Fatalf("want the executor %d killed", pid)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if left := pids(); len(left) != 0 {
+		t.Errorf("want no pid left, got %v", left)
+	}
+}
+
+// createdDroplet is how a droplet is asked for: the image and SSH keys are
+// marshalled as a slug and ids, which godo doesn't unmarshal.
+type createdDroplet struct {
+	godo.DropletCreateRequest
+	Image   string `json:"image"`
+	SSHKeys []int  `json:"ssh_keys"`
+}
+
+func TestDigitalOcean(t *testing.T) {
+	var lock sync.Mutex
+	var deleted []int
+	var created *createdDroplet
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/droplets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"droplets": [{"id": 1, "name": "executor-1"}, {"id": 2, "name": "database"}, {"id": 3, "name": "executor-2"}]}`)
+		case "POST":
+			lock.Lock()
+			defer lock.Unlock()
+			created = new(createdDroplet)
+			if err := json.NewDecoder(r.Body).Decode(created); err != nil {
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"droplet": {"id": 4, "name": "executor-3"}}`)
+		}
+	})
+	mux.HandleFunc("/v2/droplets/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/v2/droplets/"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case "DELETE":
+			lock.Lock()
+			deleted = append(deleted, id)
+			lock.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case "GET":
+			networks := `{"v4": [{"ip_address": "10.0.0.4", "type": "private"}, {"ip_address": "203.0.113.4", "type": "public"}]}`
+			if id != 4 {
+				networks = `{"v4": [{"ip_address": "10.0.0.5", "type": "private"}]}`
+			}
+			fmt.Fprintf(w, `{"droplet": {"id": %d, "networks": %s}}`, id, networks)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "digitalocean")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "scheduler.pem")
+	cert := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"
+	if err := ioutil.WriteFile(certFile, []byte(cert+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cloud := godo.NewClient(http.DefaultClient)
+	cloud.BaseURL, _ = url.Parse(srv.URL)
+	cfg := &Config{
+		DropletRegion:         "nyc3",
+		DropletSize:           "512mb",
+		DropletImageSlug:      "coreos-stable",
+		SSHKeyIDs:             []godo.DropletCreateSSHKey{{ID: 7}},
+		PullExecutorBinaryURL: "http://10.0.0.1:8080",
+		AdvertiseListenAddr:   "10.0.0.1:50051",
+		ExecutorToken:         "executor-token",
+		TLSCertFile:           certFile,
+	}
+	do := NewDigitalOcean(cfg, cloud)
+
+	// Only the droplets of executors are destroyed
+	if err := do.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(deleted)
+	if fmt.Sprint(deleted) != "[1 3]" {
+		t.Errorf("want the droplets of executors deleted, got %v", deleted)
+	}
+
+	id, err := do.Launch("executor-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Errorf("want the id of the droplet, got %d", id)
+	}
+	if created.Name != "executor-3" || created.Region != "nyc3" || created.Size != "512mb" || created.Image != "coreos-stable" || fmt.Sprint(created.SSHKeys) != "[7]" {
+		t.Errorf("unexpected droplet created: %+v", created)
+	}
+	for _, want := range []string{
+		cfg.PullExecutorBinaryURL,
+		// The token is kept in a file only root can read
+		"install -m 0600 /dev/null /etc/loadtests/token\nprintf '%s' \"executor-token\" > /etc/loadtests/token\n",
+		"cat > /etc/loadtests/scheduler.pem <<'EOF'\n" + cert + "\nEOF\n",
+		`ExecStart=/opt/executord -scheduler_addr "10.0.0.1:50051" -scheduler_token_file /etc/loadtests/token -scheduler_cert /etc/loadtests/scheduler.pem` + "\n",
+	} {
+		if !strings.Contains(created.UserData, want) {
+			t.Errorf("want %q in the user data, got:\n%s", want, created.UserData)
+		}
+	}
+	if strings.Count(created.UserData, cfg.ExecutorToken) != 1 {
+		t.Errorf("want the token only written to its file, got:\n%s", created.UserData)
+	}
+	// Without authentication, the executors register in clear
+	if script := bootScript(cfg.PullExecutorBinaryURL, cfg.AdvertiseListenAddr, "", nil); strings.Contains(script, "-scheduler_token_file") || strings.Contains(script, "-scheduler_cert") {
+		t.Errorf("want neither a token nor a certificate, got:\n%s", script)
+	}
+
+	if host, err := do.Inspect(4); err != nil || host != "203.0.113.4" {
+		t.Errorf("want the public address, got %q, %v", host, err)
+	}
+	if _, err := do.Inspect(5); err == nil {
+		t.Error("want an error without a public address")
+	}
+
+	if err := do.Destroy(4); err != nil {
+		t.Fatal(err)
+	}
+	if deleted[len(deleted)-1] != 4 {
+		t.Errorf("want the droplet deleted, got %v", deleted)
+	}
+}
@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/flynn/flynn/controller/name"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// The ended load tests are forgotten once they're older than
+// endedTestsTTL, or when more than maxEndedTests ended after them.
+const (
+	endedTestsTTL = 24 * time.Hour
+	maxEndedTests = 100
+)
+
+// registry keeps track of the load tests the scheduler runs, and of those it
+// ran recently.
+type registry struct {
+	clock clock.Clock
+	// how long, and how many, ended load tests are kept
+	endedTTL time.Duration
+	maxEnded int
+
+	lock  sync.Mutex
+	seq   uint32
+	tests map[string]*loadTest
+	// in the order they were started
+	order []*loadTest
+}
+
+type loadTest struct {
+	info pb.LoadTest
+	// stop aborts the preparation of the load test
+	stop func()
+	// halt is closed when the executors of the load test must be halted
+	halt chan struct{}
+	// controls are applied to the executors of the running load test
+	controls chan *control
+	// ended is closed once the load test ended
+	ended chan struct{}
+}
+
+// control is a change asked of a running load test, the outcome of applying
+// it is sent on `result`.
+type control struct {
+	req    *pb.ControlLoadTestReq
+	result chan error
+}
+
+func newRegistry(clock clock.Clock) *registry {
+	return &registry{
+		clock:    clock,
+		endedTTL: endedTestsTTL,
+		maxEnded: maxEndedTests,
+		tests:    make(map[string]*loadTest),
+	}
+}
+
+// prune forgets the ended load tests that are too old, or too many. The
+// lock must be held.
+func (r *registry) prune() {
+	ended := 0
+	for _, test := range r.order {
+		if hasEnded(test) {
+			ended++
+		}
+	}
+	now := r.clock.Now()
+	kept := r.order[:0]
+	for _, test := range r.order {
+		if hasEnded(test) {
+			tooOld := now.Sub(time.Unix(test.info.EndedAt, 0)) > r.endedTTL
+			if tooOld || ended > r.maxEnded {
+				ended--
+				delete(r.tests, test.info.Id)
+				continue
+			}
+		}
+		kept = append(kept, test)
+	}
+	for i := len(kept); i < len(r.order); i++ {
+		r.order[i] = nil
+	}
+	r.order = kept
+}
+
+// hasEnded tells if a load test is over, whatever its outcome.
+func hasEnded(test *loadTest) bool {
+	switch test.info.State {
+	case pb.LoadTest_FINISHED, pb.LoadTest_CANCELLED, pb.LoadTest_ERRORED:
+		return true
+	}
+	return false
+}
+
+// add registers a load test that is being prepared, started by the key named
+// `owner`.
+func (r *registry) add(req *pb.LoadTestReq, stop func(), owner string) *loadTest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.seq++
+	test := &loadTest{
+		info: pb.LoadTest{
+			Id:         name.Get(r.seq),
+			ScriptName: req.ScriptName,
+			Url:        req.Url,
+			State:      pb.LoadTest_PREPARING,
+			StartedAt:  r.clock.Now().Unix(),
+			Owner:      owner,
+		},
+		stop:     stop,
+		halt:     make(chan struct{}),
+		controls: make(chan *control),
+		ended:    make(chan struct{}),
+	}
+	r.tests[test.info.Id] = test
+	r.order = append(r.order, test)
+	r.prune()
+	return test
+}
+
+// get returns a copy of what is known about a load test.
+func (r *registry) get(id string) (*pb.LoadTest, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.prune()
+	test, ok := r.tests[id]
+	if !ok {
+		return nil, false
+	}
+	info := test.info
+	return &info, true
+}
+
+func (r *registry) list() []*pb.LoadTest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.prune()
+	list := make([]*pb.LoadTest, 0, len(r.order))
+	for _, test := range r.order {
+		info := test.info
+		list = append(list, &info)
+	}
+	return list
+}
+
+// running marks a load test as running on `count` executors. It returns
+// false if the load test was cancelled while it was being prepared.
+func (r *registry) running(test *loadTest, count int) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	test.info.Executors = int32(count)
+	if test.info.State != pb.LoadTest_PREPARING {
+		return false
+	}
+	test.info.State = pb.LoadTest_RUNNING
+	return true
+}
+
+// cancel asks for a load test to stop, whether it's still being prepared or
+// already running.
+func (r *registry) cancel(id string) (*pb.LoadTest, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	test, ok := r.tests[id]
+	if !ok {
+		return nil, fmt.Errorf("no load test with id %q", id)
+	}
+	switch test.info.State {
+	case pb.LoadTest_PREPARING:
+		test.info.State = pb.LoadTest_CANCELLING
+		test.stop()
+	case pb.LoadTest_RUNNING:
+		test.info.State = pb.LoadTest_CANCELLING
+		close(test.halt)
+	case pb.LoadTest_CANCELLING:
+		// already on its way
+	default:
+		return nil, fmt.Errorf("load test %q already ended: %v", id, test.info.State)
+	}
+	info := test.info
+	return &info, nil
+}
+
+// runningTest returns a load test that is running.
+func (r *registry) runningTest(id string) (*loadTest, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	test, ok := r.tests[id]
+	if !ok {
+		return nil, fmt.Errorf("no load test with id %q", id)
+	}
+	if test.info.State != pb.LoadTest_RUNNING {
+		return nil, fmt.Errorf("load test %q isn't running: %v", id, test.info.State)
+	}
+	return test, nil
+}
+
+// paused records whether a running load test is paused.
+func (r *registry) paused(test *loadTest, paused bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	test.info.Paused = paused
+}
+
+// cancelled tells if a load test was asked to stop.
+func (r *registry) cancelled(test *loadTest) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return test.info.State == pb.LoadTest_CANCELLING
+}
+
+// end records the outcome of a load test.
+func (r *registry) end(test *loadTest, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	switch {
+	case test.info.State == pb.LoadTest_CANCELLING:
+		test.info.State = pb.LoadTest_CANCELLED
+	case err != nil:
+		test.info.State = pb.LoadTest_ERRORED
+	default:
+		test.info.State = pb.LoadTest_FINISHED
+	}
+	select {
+	case <-test.ended:
+	default:
+		close(test.ended)
+	}
+	test.info.Paused = false
+	if err != nil {
+		test.info.Error = err.Error()
+	}
+	test.info.EndedAt = r.clock.Now().Unix()
+	r.prune()
+}
@@ -0,0 +1,180 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"golang.org/x/net/context"
+)
+
+func TestRegistry(t *testing.T) {
+	clk := clock.NewMock()
+	r := newRegistry(clk)
+
+	stopped := 0
+	preparing := r.add(&pb.LoadTestReq{ScriptName: "first", Url: "http://example.com"}, func() { stopped++ }, "alice")
+	clk.Add(time.Second)
+	running := r.add(&pb.LoadTestReq{ScriptName: "second"}, func() {}, "bob")
+	if preparing.info.Id == running.info.Id {
+		t.Fatalf("want different ids, got %q twice", running.info.Id)
+	}
+	if !r.running(running, 3) {
+		t.Fatal("want the load test running")
+	}
+
+	list := r.list()
+	if len(list) != 2 || list[0].ScriptName != "first" || list[1].ScriptName != "second" {
+		t.Fatalf("want the load tests in the order they were started, got %v", list)
+	}
+	if list[0].State != pb.LoadTest_PREPARING || list[0].Owner != "alice" || list[0].Url != "http://example.com" {
+		t.Errorf("want the first load test being prepared, got %v", list[0])
+	}
+	if list[1].State != pb.LoadTest_RUNNING || list[1].Executors != 3 || list[1].StartedAt != list[0].StartedAt+1 {
+		t.Errorf("want the second load test running, got %v", list[1])
+	}
+	// What's listed is a copy
+	list[1].State = pb.LoadTest_FINISHED
+	if info, _ := r.get(running.info.Id); info.State != pb.LoadTest_RUNNING {
+		t.Errorf("want the registry left alone, got %v", info.State)
+	}
+
+	// Cancelling a load test being prepared stops its preparation
+	info, err := r.cancel(preparing.info.Id)
+	if err != nil || info.State != pb.LoadTest_CANCELLING || stopped != 1 {
+		t.Fatalf("want the preparation stopped, got %v, %v, %d stops", info, err, stopped)
+	}
+	if r.running(preparing, 1) {
+		t.Error("want a cancelled load test not to run")
+	}
+	// Cancelling a running load test halts it
+	if _, err := r.cancel(running.info.Id); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-running.halt:
+	default:
+		t.Error("want the executors halted")
+	}
+	if !r.cancelled(running) {
+		t.Error("want the load test cancelled")
+	}
+	// Twice is fine while it's on its way
+	if _, err := r.cancel(running.info.Id); err != nil {
+		t.Errorf("cancelling twice: %v", err)
+	}
+
+	clk.Add(time.Minute)
+	r.end(preparing, nil)
+	r.end(running, errors.New("halted"))
+	for _, test := range []*loadTest{preparing, running} {
+		info, _ := r.get(test.info.Id)
+		if info.State != pb.LoadTest_CANCELLED || info.EndedAt != clk.Now().Unix() {
+			t.Errorf("%s: want cancelled now, got %v", info.ScriptName, info)
+		}
+		select {
+		case <-test.ended:
+		default:
+			t.Errorf("%s: want ended closed", info.ScriptName)
+		}
+	}
+	if info, _ := r.get(running.info.Id); info.Error != "halted" {
+		t.Errorf("want the error kept, got %q", info.Error)
+	}
+
+	finished := r.add(&pb.LoadTestReq{ScriptName: "third"}, func() {}, "")
+	r.running(finished, 1)
+	r.end(finished, nil)
+	errored := r.add(&pb.LoadTestReq{ScriptName: "fourth"}, func() {}, "")
+	r.running(errored, 1)
+	r.end(errored, errors.New("no executor"))
+	for test, want := range map[*loadTest]pb.LoadTest_State{finished: pb.LoadTest_FINISHED, errored: pb.LoadTest_ERRORED} {
+		if info, _ := r.get(test.info.Id); info.State != want {
+			t.Errorf("%s: want %v, got %v", info.ScriptName, want, info.State)
+		}
+	}
+	// Ending twice doesn't close ended again
+	r.end(finished, nil)
+
+	if _, ok := r.get("unknown"); ok {
+		t.Error("want no unknown load test")
+	}
+	if _, err := r.runningTest(finished.info.Id); err == nil {
+		t.Error("want an error controlling a finished load test")
+	}
+}
+
+func TestRegistryPrune(t *testing.T) {
+	clk := clock.NewMock()
+	clk.Add(time.Hour)
+	r := newRegistry(clk)
+	r.maxEnded = 2
+
+	names := func() []string {
+		var names []string
+		for _, info := range r.list() {
+			names = append(names, info.ScriptName)
+		}
+		return names
+	}
+	start := func(name string) *loadTest {
+		test := r.add(&pb.LoadTestReq{ScriptName: name}, func() {}, "")
+		r.running(test, 1)
+		return test
+	}
+
+	// The load tests that didn't end are always kept
+	running := start("running")
+	first, second, third := start("first"), start("second"), start("third")
+	r.end(first, nil)
+	r.end(second, errors.New("failed"))
+	if got := fmt.Sprint(names()); got != "[running first second third]" {
+		t.Errorf("want every load test kept, got %s", got)
+	}
+	// Only the last ended load tests are kept
+	clk.Add(time.Hour)
+	r.end(third, nil)
+	if got := fmt.Sprint(names()); got != "[running second third]" {
+		t.Errorf("want the first ended load test forgotten, got %s", got)
+	}
+	if _, ok := r.get(first.info.Id); ok {
+		t.Error("want the first load test unknown")
+	}
+
+	// and only for a while
+	clk.Add(endedTestsTTL - time.Minute)
+	if got := fmt.Sprint(names()); got != "[running third]" {
+		t.Errorf("want the expired load test forgotten, got %s", got)
+	}
+	clk.Add(2 * time.Minute)
+	if got := fmt.Sprint(names()); got != "[running]" {
+		t.Errorf("want the running load test kept, got %s", got)
+	}
+	if _, ok := r.get(running.info.Id); !ok {
+		t.Error("want the running load test known")
+	}
+}
+
+func TestCancelLoadTest(t *testing.T) {
+	svc := NewServer(&Config{}, nil)
+	finished := svc.tests.add(&pb.LoadTestReq{ScriptName: "script"}, func() {}, "")
+	svc.tests.running(finished, 1)
+	svc.tests.end(finished, nil)
+
+	for id, wantErr := range map[string]string{
+		"unknown":        `no load test with id "unknown"`,
+		finished.info.Id: "already ended: FINISHED",
+	} {
+		_, err := svc.CancelLoadTest(context.Background(), &pb.CancelLoadTestReq{Id: id})
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("cancelling %s: want an error with %q, got %v", id, wantErr, err)
+		}
+	}
+	if info, _ := svc.tests.get(finished.info.Id); info.State != pb.LoadTest_FINISHED {
+		t.Errorf("want the load test left finished, got %v", info.State)
+	}
+}
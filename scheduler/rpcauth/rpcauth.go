@@ -0,0 +1,100 @@
+// Package rpcauth holds the credentials the scheduler, its executors and its
+// clients share: callers authenticate with a bearer token, which is only sent
+// over TLS.
+package rpcauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// ExecutorServerName is the name in the certificates of the executors, which
+// the scheduler checks instead of their address.
+const ExecutorServerName = "executor"
+
+// BearerToken authenticates the calls to the scheduler.
+type BearerToken string
+
+func (t BearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity keeps the token from being sent in clear.
+func (t BearerToken) RequireTransportSecurity() bool { return true }
+
+// Credentials are the bearer token the caller of a call presented, if any.
+func Credentials(ctx context.Context) string {
+	md, ok := metadata.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, value := range md["authorization"] {
+		if strings.HasPrefix(value, "Bearer ") {
+			return strings.TrimPrefix(value, "Bearer ")
+		}
+	}
+	return ""
+}
+
+// ClientTLS trusts the certificates of `certFile`, or the roots of the
+// system if empty.
+func ClientTLS(certFile string) (credentials.TransportAuthenticator, error) {
+	if certFile == "" {
+		return credentials.NewTLS(&tls.Config{}), nil
+	}
+	return credentials.NewClientTLSFromFile(certFile, "")
+}
+
+// PinnedTLS only trusts `certificate`, the DER certificate an executor
+// registered with.
+func PinnedTLS(certificate []byte) (credentials.TransportAuthenticator, error) {
+	cert, err := x509.ParseCertificate(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return credentials.NewClientTLSFromCert(pool, ExecutorServerName), nil
+}
+
+// NewCertificate returns a self-signed certificate an executor serves with
+// for as long as it runs.
+func NewCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: ExecutorServerName},
+		DNSNames:              []string{ExecutorServerName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
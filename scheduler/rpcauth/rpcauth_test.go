@@ -0,0 +1,72 @@
+package rpcauth
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBearerToken(t *testing.T) {
+	md, err := BearerToken("s3cr3t").GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md["authorization"] != "Bearer s3cr3t" {
+		t.Errorf("want the token in the authorization, got %v", md)
+	}
+	if !BearerToken("s3cr3t").RequireTransportSecurity() {
+		t.Error("want the token only sent over TLS")
+	}
+
+	ctx := metadata.NewContext(context.Background(), metadata.New(md))
+	if got := Credentials(ctx); got != "s3cr3t" {
+		t.Errorf("want the token presented, got %q", got)
+	}
+	if got := Credentials(context.Background()); got != "" {
+		t.Errorf("want no token without metadata, got %q", got)
+	}
+}
+
+// handshake connects a client with `client` to a server serving `cert`.
+func handshake(client credentials.TransportAuthenticator, cert tls.Certificate) error {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	go credentials.NewServerTLSFromCert(&cert).ServerHandshake(serverConn)
+	_, _, err := client.ClientHandshake("10.0.0.4:50053", clientConn, time.Second)
+	return err
+}
+
+func TestPinnedTLS(t *testing.T) {
+	cert, err := NewCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, err := PinnedTLS(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The executor serving the certificate it registered with is trusted,
+	// whatever its address
+	if err := handshake(pinned, cert); err != nil {
+		t.Errorf("want the pinned certificate trusted, got %v", err)
+	}
+	// but not another one
+	if err := handshake(pinned, other); err == nil {
+		t.Error("want another certificate rejected")
+	}
+
+	if _, err := PinnedTLS([]byte("not a certificate")); err == nil {
+		t.Error("want an error for an invalid certificate")
+	}
+}
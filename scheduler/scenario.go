@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// ownRate tells if a scenario has a rate of its own, rather than a share of
+// the rate of the load test.
+func ownRate(scenario *pb.Scenario) bool {
+	return scenario.RequestsPerSecond > 0 || len(scenario.Stages) > 0
+}
+
+func verifyScenarios(req *pb.LoadTestReq) error {
+	if req.Script != "" {
+		return fmt.Errorf("a load test has either a script or scenarios, not both")
+	}
+	names := make(map[string]bool, len(req.Scenarios))
+	for _, scenario := range req.Scenarios {
+		switch {
+		case scenario.Name == "" || strings.Contains(scenario.Name, "/"):
+			return fmt.Errorf("scenarios need a name, without /")
+		case names[scenario.Name]:
+			return fmt.Errorf("scenario %q is given twice", scenario.Name)
+		case !ownRate(scenario) && scenario.Weight <= 0:
+			return fmt.Errorf("scenario %q needs a weight, a rate or stages", scenario.Name)
+		}
+		names[scenario.Name] = true
+		if err := verifySource(scenario.Script, scenario.ScriptConfig); err != nil {
+			return fmt.Errorf("scenario %q: %v", scenario.Name, err)
+		}
+	}
+	return nil
+}
+
+// scenarioParams are the commands of the scenarios of a load test, for all
+// the executors together. The scenarios without a rate of their own share
+// that of the load test, or its stages, in proportion to their weights. The
+// workers of an executor are shared in proportion to the peak rate of every
+// scenario, evenly if none has a rate.
+func scenarioParams(req *pb.LoadTestReq, maxWorkers int32) []*executorpb.Scenario {
+	var totalWeight float64
+	for _, scenario := range req.Scenarios {
+		if !ownRate(scenario) {
+			totalWeight += scenario.Weight
+		}
+	}
+	share := func(rps int32, weight float64) int32 {
+		return int32(math.Floor(float64(rps)*weight/totalWeight + 0.5))
+	}
+
+	var scenarios []*executorpb.Scenario
+	var totalRate int32
+	for _, scenario := range req.Scenarios {
+		sub := *req
+		sub.Script, sub.ScriptConfig, sub.Scenarios = scenario.Script, scenario.ScriptConfig, nil
+		switch {
+		case len(scenario.Stages) > 0:
+			sub.Stages = scenario.Stages
+		case scenario.RequestsPerSecond > 0:
+			sub.Stages = nil
+			sub.StartingRequestsPerSecond = scenario.RequestsPerSecond
+			sub.MaxRequestsPerSecond = scenario.RequestsPerSecond
+		default:
+			sub.StartingRequestsPerSecond = share(req.StartingRequestsPerSecond, scenario.Weight)
+			sub.MaxRequestsPerSecond = share(req.MaxRequestsPerSecond, scenario.Weight)
+			sub.Stages = nil
+			for _, stage := range req.Stages {
+				sub.Stages = append(sub.Stages, &pb.Stage{
+					Duration:                stage.Duration,
+					TargetRequestsPerSecond: share(stage.TargetRequestsPerSecond, scenario.Weight),
+					Interpolation:           stage.Interpolation,
+				})
+			}
+		}
+		params := scriptParams(&sub, maxWorkers)
+		totalRate += params.MaxRequestsPerSecond
+		scenarios = append(scenarios, &executorpb.Scenario{
+			Name:         scenario.Name,
+			Params:       params,
+			ScriptConfig: scenario.ScriptConfig,
+		})
+	}
+	rates := make([]float64, len(scenarios))
+	for i, scenario := range scenarios {
+		rates[i] = float64(scenario.Params.MaxRequestsPerSecond)
+	}
+	for i, workers := range apportion(maxWorkers, rates) {
+		scenarios[i].Params.MaxWorkers = workers
+	}
+	return scenarios
+}
+
+// apportion splits `total` in parts proportional to `weights`, or even parts
+// if they are all zero. The parts add up to `total`, unless it's too small
+// for every part to get at least one.
+func apportion(total int32, weights []float64) []int32 {
+	parts := make([]int32, len(weights))
+	if total <= int32(len(weights)) {
+		for i := range parts {
+			parts[i] = 1
+		}
+		return parts
+	}
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
+	}
+	shares := make([]float64, len(weights))
+	byRemainder := make([]int, len(weights))
+	given := int32(0)
+	for i, weight := range weights {
+		shares[i] = float64(total) / float64(len(weights))
+		if sum > 0 {
+			shares[i] = float64(total) * weight / sum
+		}
+		parts[i] = int32(shares[i])
+		given += parts[i]
+		byRemainder[i] = i
+	}
+	// What rounding down left goes to the largest remainders
+	sort.SliceStable(byRemainder, func(a, b int) bool {
+		i, j := byRemainder[a], byRemainder[b]
+		return shares[i]-math.Floor(shares[i]) > shares[j]-math.Floor(shares[j])
+	})
+	for k := int32(0); k < total-given; k++ {
+		parts[byRemainder[k]]++
+	}
+	// and the parts left without any get one of the largest
+	for i := range parts {
+		if parts[i] > 0 {
+			continue
+		}
+		largest := 0
+		for j := range parts {
+			if parts[j] > parts[largest] {
+				largest = j
+			}
+		}
+		parts[largest]--
+		parts[i] = 1
+	}
+	return parts
+}
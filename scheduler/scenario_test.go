@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+	"golang.org/x/net/context"
+)
+
+func TestScenarioParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *pb.LoadTestReq
+		maxWorkers int32
+		// the starting rate, max rate and workers of every scenario
+		want string
+	}{
+		{
+			name: "weights share the rate of the load test",
+			req: &pb.LoadTestReq{
+				StartingRequestsPerSecond: 100,
+				MaxRequestsPerSecond:      200,
+				Scenarios: []*pb.Scenario{
+					{Name: "browse", Weight: 70},
+					{Name: "search", Weight: 25},
+					{Name: "checkout", Weight: 5},
+				},
+			},
+			maxWorkers: 100,
+			want:       "[[70 140 70] [25 50 25] [5 10 5]]",
+		},
+		{
+			name: "a fixed rate isn't shared",
+			req: &pb.LoadTestReq{
+				StartingRequestsPerSecond: 100,
+				MaxRequestsPerSecond:      100,
+				Scenarios: []*pb.Scenario{
+					{Name: "browse", Weight: 3},
+					{Name: "search", Weight: 1},
+					{Name: "checkout", RequestsPerSecond: 50},
+				},
+			},
+			maxWorkers: 10,
+			want:       "[[75 75 5] [25 25 2] [50 50 3]]",
+		},
+		{
+			name: "workers add up to the max",
+			req: &pb.LoadTestReq{
+				StartingRequestsPerSecond: 90,
+				MaxRequestsPerSecond:      90,
+				Scenarios: []*pb.Scenario{
+					{Name: "a", Weight: 1},
+					{Name: "b", Weight: 1},
+					{Name: "c", Weight: 1},
+				},
+			},
+			maxWorkers: 10,
+			want:       "[[30 30 4] [30 30 3] [30 30 3]]",
+		},
+		{
+			name: "a scenario with little rate keeps a worker",
+			req: &pb.LoadTestReq{
+				MaxRequestsPerSecond: 1000,
+				Scenarios: []*pb.Scenario{
+					{Name: "a", Weight: 999},
+					{Name: "b", Weight: 1},
+				},
+			},
+			maxWorkers: 10,
+			want:       "[[0 999 9] [0 1 1]]",
+		},
+		{
+			name: "no rate shares the workers evenly",
+			req: &pb.LoadTestReq{
+				Scenarios: []*pb.Scenario{
+					{Name: "a", Weight: 1},
+					{Name: "b", Weight: 1},
+				},
+			},
+			maxWorkers: 5,
+			want:       "[[0 0 3] [0 0 2]]",
+		},
+	}
+	for _, tt := range tests {
+		var got [][]int32
+		var workers int32
+		for _, scenario := range scenarioParams(tt.req, tt.maxWorkers) {
+			params := scenario.Params
+			got = append(got, []int32{params.StartingRequestsPerSecond, params.MaxRequestsPerSecond, params.MaxWorkers})
+			workers += params.MaxWorkers
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%s: want %s, got %v", tt.name, tt.want, got)
+		}
+		if workers != tt.maxWorkers {
+			t.Errorf("%s: want %d workers in all, got %d", tt.name, tt.maxWorkers, workers)
+		}
+	}
+}
+
+func TestScenarioParamsStages(t *testing.T) {
+	req := &pb.LoadTestReq{
+		Stages: []*pb.Stage{
+			{Duration: 30, TargetRequestsPerSecond: 100},
+			{Duration: 60, TargetRequestsPerSecond: 40},
+		},
+		Scenarios: []*pb.Scenario{
+			{Name: "browse", Weight: 3},
+			{Name: "search", Weight: 1},
+			{Name: "spike", Stages: []*pb.Stage{{Duration: 10, TargetRequestsPerSecond: 500}}},
+		},
+	}
+	var got []string
+	for _, scenario := range scenarioParams(req, 100) {
+		var targets []int32
+		for _, stage := range scenario.Params.Stages {
+			targets = append(targets, stage.TargetRequestsPerSecond)
+		}
+		got = append(got, fmt.Sprint(scenario.Name, targets, scenario.Params.RunTime, scenario.Params.MaxRequestsPerSecond))
+	}
+	want := "[browse[75 30] 90 75 search[25 10] 90 25 spike[500] 10 500]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("want %s, got %v", want, got)
+	}
+}
+
+func TestApportion(t *testing.T) {
+	tests := []struct {
+		total   int32
+		weights []float64
+		want    string
+	}{
+		{10, []float64{1, 1}, "[5 5]"},
+		{10, []float64{1, 1, 1}, "[4 3 3]"},
+		{10, []float64{0, 0, 0}, "[4 3 3]"},
+		{100, []float64{70, 25, 5}, "[70 25 5]"},
+		{10, []float64{1, 2, 3}, "[2 3 5]"},
+		// every part gets one, even when it's more than the total
+		{3, []float64{100, 0, 0}, "[1 1 1]"},
+		{2, []float64{1, 1, 1}, "[1 1 1]"},
+		{0, nil, "[]"},
+	}
+	for _, tt := range tests {
+		if got := apportion(tt.total, tt.weights); fmt.Sprint(got) != tt.want {
+			t.Errorf("apportion(%d, %v): want %s, got %v", tt.total, tt.weights, tt.want, got)
+		}
+	}
+}
+
+func TestLoadTestWithoutRate(t *testing.T) {
+	svc := NewServer(&Config{MaxWorkerPerExecutor: 10, MaxExecPSPerExecutor: 100}, nil)
+	for _, req := range []*pb.LoadTestReq{
+		{Script: "step.a = function() end"},
+		{Scenarios: []*pb.Scenario{
+			{Name: "a", Script: "step.a = function() end", Weight: 1},
+			{Name: "b", Script: "step.b = function() end", Weight: 1},
+		}},
+		{Script: "step.a = function() end", Stages: []*pb.Stage{{Duration: 10}}},
+	} {
+		err := svc.LoadTest(req, &authenticatedStream{ctx: context.Background()})
+		if err == nil || !strings.Contains(err.Error(), "more than 0 requests per second") {
+			t.Errorf("%v: want an error about the rate, got %v", req, err)
+		}
+	}
+}
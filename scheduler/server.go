@@ -1,7 +1,6 @@
 package scheduler
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
@@ -11,6 +10,7 @@ import (
 	"github.com/benbjohnson/clock"
 	"github.com/digitalocean/godo"
 	"github.com/lgpeterson/loadtests/executor/engine"
+	executorpb "github.com/lgpeterson/loadtests/executor/pb"
 	"github.com/lgpeterson/loadtests/scheduler/pb"
 	"golang.org/x/net/context"
 )
@@ -31,11 +31,30 @@ type Config struct {
 	MaxWorkerPerExecutor int
 	MaxExecPSPerExecutor int
 
+	// max duration of the setup, and of the teardown, of a script.
+	// defaultHooksTimeout if zero
+	HooksTimeout time.Duration
+
 	InfluxAddr     string
 	InfluxUsername string
 	InfluxPassword string
 	InfluxDBName   string
 	InfluxSSL      bool
+
+	// The targets must serve, or publish in DNS, this token before they are
+	// load tested. Any target can be load tested if it's empty
+	TargetVerificationToken string
+	// how long a target stays verified
+	TargetVerificationTTL time.Duration
+	// hosts that can be load tested without being verified
+	TargetAllowlist []string
+
+	// The executors register with this token, when the callers of the
+	// scheduler are authenticated
+	ExecutorToken string
+	// The certificate the scheduler serves with, the executors register over
+	// TLS trusting it if set
+	TLSCertFile string
 }
 
 type Server struct {
@@ -43,10 +62,18 @@ type Server struct {
 
 	db    *DB
 	clock clock.Clock
+	tests *registry
+	// nil if the targets aren't verified
+	targets *targetVerifier
 }
 
 func NewServer(cfg *Config, db *DB) *Server {
-	return &Server{cfg: cfg, db: db, clock: clock.New()}
+	clk := clock.New()
+	s := &Server{cfg: cfg, db: db, clock: clk, tests: newRegistry(clk)}
+	if cfg.TargetVerificationToken != "" {
+		s.targets = newTargetVerifier(cfg.TargetVerificationToken, cfg.TargetAllowlist, cfg.TargetVerificationTTL, clk)
+	}
+	return s
 }
 
 func (s *Server) RegisterExecutor(ctx context.Context, req *pb.RegisterExecutorReq) (*pb.RegisterExecutorResp, error) {
@@ -57,104 +84,401 @@ func (s *Server) RegisterExecutor(ctx context.Context, req *pb.RegisterExecutorR
 		InfluxDb:       s.cfg.InfluxDBName,
 		InfluxSsl:      s.cfg.InfluxSSL,
 	}
-	err := s.db.RegisterExecutorUp(int(req.DropletId), int(req.Port))
+	err := s.db.RegisterExecutorUp(int(req.DropletId), registration{
+		port:        int(req.Port),
+		version:     executorpb.ProtocolVersion(req.ProtocolVersion),
+		certificate: req.Certificate,
+	})
 	return resp, err
 }
 
 func (s *Server) LoadTest(req *pb.LoadTestReq, srv pb.Scheduler_LoadTestServer) error {
-	ctx := srv.Context()
+	ctx, stop := context.WithCancel(srv.Context())
+	defer stop()
 
 	if err := verifyScript(req); err != nil {
 		return err
 	}
+	thresholds, err := parseThresholds(req.Thresholds)
+	if err != nil {
+		return err
+	}
+	aborts, err := parseAbortConditions(req.AbortConditions)
+	if err != nil {
+		return err
+	}
+	dataFeed, err := parseDataFeed(req.DataFeed)
+	if err != nil {
+		return err
+	}
+	params := scriptParams(req, int32(s.cfg.MaxWorkerPerExecutor))
+	params.DataFeed = dataFeed
+	if len(req.Scenarios) > 0 {
+		params.Script, params.Stages = "", nil
+		params.Scenarios = scenarioParams(req, int32(s.cfg.MaxWorkerPerExecutor))
+		// Enough executors must be launched for the scenarios together
+		params.RunTime, params.MaxRequestsPerSecond = 0, 0
+		for _, scenario := range params.Scenarios {
+			params.MaxRequestsPerSecond += scenario.Params.MaxRequestsPerSecond
+			if scenario.Params.RunTime > params.RunTime {
+				params.RunTime = scenario.Params.RunTime
+			}
+		}
+	}
+	caller := callerFrom(ctx)
+	if s.targets != nil {
+		// Whatever the roles of the caller, the scripts can't send their
+		// load elsewhere than to the verified target
+		host, err := s.targets.verify(ctx, req.Url)
+		if err != nil {
+			return err
+		}
+		params.AllowedHosts = []string{host}
+		for _, scenario := range params.Scenarios {
+			scenario.Params.AllowedHosts = params.AllowedHosts
+		}
+	}
+	if params.MaxRequestsPerSecond <= 0 {
+		return fmt.Errorf("The load test needs more than 0 requests per second")
+	}
 	needExecutors := int(math.Ceil(
-		float64(req.MaxRequestsPerSecond) / float64(s.cfg.MaxExecPSPerExecutor),
+		float64(params.MaxRequestsPerSecond) / float64(s.cfg.MaxExecPSPerExecutor),
 	))
-	if req.StartingRequestsPerSecond/int32(needExecutors) <= 10 {
+	if len(params.Scenarios) == 0 && len(params.Stages) == 0 && req.StartingRequestsPerSecond/int32(needExecutors) <= 10 {
 		return fmt.Errorf("You need more than %d starting requests per second to deal with %d max request per second",
 			needExecutors*11, req.MaxRequestsPerSecond)
 	}
-	if err := s.answerPreparing(srv, needExecutors); err != nil {
+	for _, scenario := range params.Scenarios {
+		if len(scenario.Params.Stages) == 0 && scenario.Params.StartingRequestsPerSecond/int32(needExecutors) <= 10 {
+			return fmt.Errorf("Scenario %q needs more than %d starting requests per second, it has %d",
+				scenario.Name, needExecutors*11-1, scenario.Params.StartingRequestsPerSecond)
+		}
+	}
+
+	var owner string
+	if caller != nil {
+		owner = caller.Name
+	}
+	test := s.tests.add(req, stop, owner)
+	ll := logrus.WithFields(logrus.Fields{"load_test.id": test.info.Id, "owner": owner})
+	if err := s.answerPreparing(srv, needExecutors, test.info.Id); err != nil {
+		s.tests.end(test, err)
 		return err
 	}
 
+	// The setups run once for all the executors, before they are launched
+	// so that a failing setup doesn't cost any. The teardowns run after the
+	// executors are killed. A load test without scenarios is like one with
+	// a single unnamed one
+	scripts := []*executorpb.Scenario{{Params: params, ScriptConfig: req.ScriptConfig}}
+	if len(params.Scenarios) > 0 {
+		scripts = params.Scenarios
+	}
+	for _, script := range scripts {
+		hooks, setupData, err := runSetup(ctx, script.Params, script.ScriptConfig, s.cfg.HooksTimeout, ll)
+		if err != nil {
+			if s.tests.cancelled(test) {
+				ll.Info("load test cancelled while preparing")
+				s.tests.end(test, nil)
+				s.answerCancelled(srv)
+				return nil
+			}
+			ll.WithError(err).Error("running setup")
+			s.tests.end(test, err)
+			s.answerErrored(srv, err)
+			return nil
+		}
+		script.Params.SetupData = string(setupData)
+		defer hooks.teardown()
+	}
+
 	executors, err := s.db.LaunchExecutors(ctx, needExecutors)
 	if err != nil {
+		if s.tests.cancelled(test) {
+			ll.Info("load test cancelled while preparing")
+			s.tests.end(test, nil)
+			s.answerCancelled(srv)
+			return nil
+		}
+		s.tests.end(test, err)
 		return err
 	}
 	defer func() {
-		logrus.Info("killing all executors")
+		ll.Info("killing all executors")
 		if err := executors.killall(); err != nil {
-			logrus.WithError(err).Error("couldn't kill all executors!")
+			ll.WithError(err).Error("couldn't kill all executors!")
 		}
 	}()
+	if !s.tests.running(test, len(executors.executors)) {
+		ll.Info("load test cancelled while preparing")
+		s.tests.end(test, nil)
+		s.answerCancelled(srv)
+		return nil
+	}
 
-	err = executors.executeCommand(
-		ctx,
-		req.Url,
-		req.Script,
-		req.ScriptName,
-		req.RunTime,
-		int32(s.cfg.MaxWorkerPerExecutor),
-		req.GrowthFactor,
-		req.TimeBetweenGrowth,
-		req.StartingRequestsPerSecond,
-		req.MaxRequestsPerSecond,
-		req.ScriptConfig,
-	)
+	err = executors.executeCommand(ctx, params, req.ScriptConfig)
 	if err != nil {
-		logrus.WithError(err).Error("sending command")
+		ll.WithError(err).Error("sending command")
+		s.tests.end(test, err)
 		s.answerErrored(srv, err)
 		return nil
 	}
 	s.answerStarted(srv)
 
+	// merged is reset every time progress is sent, totals covers the whole
+	// run for the thresholds
+	merged, totals := newProgress(), newProgress()
+	// the abort conditions with a window look back at the snapshots
+	recent := newHistory(aborts)
+	started := s.clock.Now()
 	completion := make(chan error, 1)
 	go func() {
 		defer close(completion)
-		if err := executors.waitCompletion(ctx); err != nil {
+		onSnapshot := func(snap *executorpb.Snapshot) {
+			merged.add(snap)
+			totals.add(snap)
+			recent.add(s.clock.Now(), snap)
+		}
+		if err := executors.waitCompletion(ctx, onSnapshot); err != nil {
 			completion <- err
 		}
 	}()
 
-	select {
-	case err := <-completion:
+	progressTicker := s.clock.Ticker(progressInterval)
+	defer progressTicker.Stop()
+	lastProgress := s.clock.Now()
 
-		if err != nil {
-			logrus.WithError(err).Error("waiting for completeion")
+	var abortChecks <-chan time.Time
+	if len(aborts) > 0 {
+		abortTicker := s.clock.Ticker(abortInterval)
+		defer abortTicker.Stop()
+		abortChecks = abortTicker.C
+	}
+
+	for {
+		select {
+		case now := <-progressTicker.C:
+			s.answerProgress(srv, merged.flush(now.Sub(lastProgress)))
+			lastProgress = now
+		case err := <-completion:
+			s.tests.end(test, err)
+			if err != nil {
+				ll.WithError(err).Error("waiting for completeion")
+				s.answerErrored(srv, err)
+			} else {
+				verdict := judge(thresholds, totals, s.clock.Now().Sub(started))
+				if verdict != nil {
+					ll.WithField("passed", verdict.Passed).Info("thresholds evaluated")
+				}
+				s.answerFinished(srv, verdict, totals.checkResults())
+			}
+			return nil
+		case <-test.halt:
+			// Halted executors flush their metrics before answering, wait for
+			// them before their droplets are destroyed
+			ll.Info("halting executors")
+			err := executors.haltCommand(ctx)
+			if err == nil {
+				err = <-completion
+			}
+			if err != nil {
+				ll.WithError(err).Error("halting executors")
+			}
+			s.tests.end(test, err)
+			s.answerCancelled(srv)
+			return nil
+		case now := <-abortChecks:
+			abort := checkAborts(aborts, recent, totals, now, now.Sub(started))
+			if abort == nil {
+				continue
+			}
+			ll.WithFields(logrus.Fields{
+				"condition": abort.Condition,
+				"observed":  abort.Observed,
+			}).Warn("abort condition held, halting executors")
+			err := executors.haltCommand(ctx)
+			if err == nil {
+				err = <-completion
+			}
+			if err != nil {
+				ll.WithError(err).Error("halting executors")
+			}
+			aborted := &abortError{abort: abort}
+			s.tests.end(test, aborted)
+			s.answerErrored(srv, aborted)
+			return nil
+		case ctrl := <-test.controls:
+			err := s.control(ctx, executors, params.OpenModel, ctrl.req)
+			if err == nil && ctrl.req.Action != pb.ControlLoadTestReq_SET_RATE {
+				s.tests.paused(test, ctrl.req.Action == pb.ControlLoadTestReq_PAUSE)
+			}
+			ctrl.result <- err
+		case <-ctx.Done():
+			ll.WithError(err).Error("timing out execution")
+			err := fmt.Errorf("forcing destruction of executors")
+			s.tests.end(test, err)
 			s.answerErrored(srv, err)
-		} else {
-			s.answerFinished(srv)
+			return nil
 		}
+	}
+}
+
+func (s *Server) CancelLoadTest(ctx context.Context, req *pb.CancelLoadTestReq) (*pb.CancelLoadTestResp, error) {
+	test, err := s.tests.cancel(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	logrus.WithField("load_test.id", req.Id).Info("cancelling load test")
+	return &pb.CancelLoadTestResp{LoadTest: test}, nil
+}
+
+func (s *Server) ControlLoadTest(ctx context.Context, req *pb.ControlLoadTestReq) (*pb.ControlLoadTestResp, error) {
+	test, err := s.tests.runningTest(req.Id)
+	if err != nil {
+		return nil, err
+	}
+	// The load test applies it between two progresses
+	ctrl := &control{req: req, result: make(chan error, 1)}
+	select {
+	case test.controls <- ctrl:
+	case <-test.ended:
+		return nil, fmt.Errorf("load test %q ended", req.Id)
 	case <-ctx.Done():
-		logrus.WithError(err).Error("timing out execution")
-		s.answerErrored(srv, fmt.Errorf("forcing destruction of executors"))
+		return nil, ctx.Err()
+	}
+	if err := <-ctrl.result; err != nil {
+		return nil, err
 	}
+	logrus.WithFields(logrus.Fields{
+		"load_test.id":        req.Id,
+		"action":              req.Action,
+		"requests_per_second": req.RequestsPerSecond,
+		"workers":             req.Workers,
+	}).Info("controlled load test")
+	info, _ := s.tests.get(req.Id)
+	return &pb.ControlLoadTestResp{LoadTest: info}, nil
+}
 
-	return nil
+// control sends a control to the executors of a running load test, the rate
+// and the workers being shared between them.
+func (s *Server) control(ctx context.Context, executors *executors, openModel bool, req *pb.ControlLoadTestReq) error {
+	count := int32(len(executors.executors))
+	// the command of every executor
+	cmds := make([]*executorpb.CommandMessage, count)
+	switch req.Action {
+	case pb.ControlLoadTestReq_PAUSE:
+		for i := range cmds {
+			cmds[i] = &executorpb.CommandMessage{Kind: &executorpb.CommandMessage_Pause{Pause: &executorpb.Pause{}}}
+		}
+	case pb.ControlLoadTestReq_RESUME:
+		for i := range cmds {
+			cmds[i] = &executorpb.CommandMessage{Kind: &executorpb.CommandMessage_Resume{Resume: &executorpb.Resume{}}}
+		}
+	case pb.ControlLoadTestReq_SET_RATE:
+		rate, workers := req.RequestsPerSecond, req.Workers
+		if rate < 0 || workers < 0 || rate == 0 && workers == 0 {
+			return fmt.Errorf("a rate or workers greater than 0 must be given")
+		}
+		if max := count * int32(s.cfg.MaxExecPSPerExecutor); rate > max {
+			return fmt.Errorf("The load test runs on %d executors, it can't go over %d requests per second", count, max)
+		}
+		if rate > 0 && !openModel && rate/count <= 10 {
+			return fmt.Errorf("You need more than %d requests per second on %d executors", count*11-1, count)
+		}
+		if rate > 0 && rate < count {
+			return fmt.Errorf("The load test runs on %d executors, it needs at least as many requests per second", count)
+		}
+		if max := count * int32(s.cfg.MaxWorkerPerExecutor); workers > max {
+			return fmt.Errorf("The load test runs on %d executors, it can't have more than %d workers", count, max)
+		}
+		if workers > 0 && workers < count {
+			return fmt.Errorf("The load test runs on %d executors, it needs at least as many workers", count)
+		}
+		// The first executors get one more when it can't be divided evenly
+		for i := range cmds {
+			cmds[i] = &executorpb.CommandMessage{Kind: &executorpb.CommandMessage_SetRate{SetRate: &executorpb.SetRate{
+				RequestsPerSecond: shareOf(rate, int(count), i),
+				Workers:           shareOf(workers, int(count), i),
+			}}}
+		}
+	default:
+		return fmt.Errorf("unknown action %v", req.Action)
+	}
+	return executors.controlCommand(ctx, cmds)
+}
+
+func (s *Server) ListLoadTests(ctx context.Context, req *pb.ListLoadTestsReq) (*pb.ListLoadTestsResp, error) {
+	return &pb.ListLoadTestsResp{LoadTests: s.tests.list()}, nil
+}
+
+func (s *Server) GetLoadTest(ctx context.Context, req *pb.GetLoadTestReq) (*pb.GetLoadTestResp, error) {
+	test, ok := s.tests.get(req.Id)
+	if !ok {
+		return nil, fmt.Errorf("no load test with id %q", req.Id)
+	}
+	return &pb.GetLoadTestResp{LoadTest: test}, nil
+}
+
+// scriptParams is the command described by a request, for all the executors
+// together.
+func scriptParams(req *pb.LoadTestReq, maxWorkers int32) *executorpb.ScriptParams {
+	params := &executorpb.ScriptParams{
+		Url:                       req.Url,
+		Script:                    req.Script,
+		ScriptId:                  req.ScriptName,
+		RunTime:                   req.RunTime,
+		MaxWorkers:                maxWorkers,
+		GrowthFactor:              req.GrowthFactor,
+		TimeBetweenGrowth:         req.TimeBetweenGrowth,
+		StartingRequestsPerSecond: req.StartingRequestsPerSecond,
+		MaxRequestsPerSecond:      req.MaxRequestsPerSecond,
+		KeepCookies:               req.KeepCookies,
+		OpenModel:                 req.OpenModel,
+		Rollups:                   req.Rollups,
+	}
+	var runTime float64
+	for _, stage := range req.Stages {
+		params.Stages = append(params.Stages, &executorpb.Stage{
+			Duration:                stage.Duration,
+			TargetRequestsPerSecond: stage.TargetRequestsPerSecond,
+			Interpolation:           executorpb.Stage_Interpolation(stage.Interpolation),
+		})
+		runTime += stage.Duration
+		// Enough executors must be launched for the peak of the profile
+		if stage.TargetRequestsPerSecond > params.MaxRequestsPerSecond {
+			params.MaxRequestsPerSecond = stage.TargetRequestsPerSecond
+		}
+	}
+	if len(params.Stages) > 0 {
+		params.RunTime = int32(math.Ceil(runTime))
+	}
+	return params
 }
 
 func verifyScript(req *pb.LoadTestReq) error {
-	script := strings.NewReader(req.Script)
+	if len(req.Scenarios) > 0 {
+		return verifyScenarios(req)
+	}
+	return verifySource(req.Script, req.ScriptConfig)
+}
+
+func verifySource(source, config string) error {
+	script := strings.NewReader(source)
 	_, err := engine.Lua(script)
 	if err != nil {
 		return err
 	}
-	if req.ScriptConfig != "" {
-		cfg := make(map[string]interface{})
-		if err = json.Unmarshal([]byte(req.ScriptConfig), &cfg); err != nil {
-			return err
-		}
-		if err = engine.VerifyConfig(cfg); err != nil {
+	if config != "" {
+		if _, err = engine.ParseConfig(config); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (s *Server) answerPreparing(srv pb.Scheduler_LoadTestServer, count int) error {
+func (s *Server) answerPreparing(srv pb.Scheduler_LoadTestServer, count int, id string) error {
 	preparing := &pb.LoadTestResp_Preparing_{}
-	preparing.Preparing = &pb.LoadTestResp_Preparing{Count: int32(count)}
+	preparing.Preparing = &pb.LoadTestResp_Preparing{Count: int32(count), Id: id}
 	err := srv.Send(&pb.LoadTestResp{Phase: preparing})
 	if err != nil {
 		logrus.WithError(err).Error("can't send message to client")
@@ -170,16 +494,34 @@ func (s *Server) answerStarted(srv pb.Scheduler_LoadTestServer) {
 	}
 }
 
-func (s *Server) answerFinished(srv pb.Scheduler_LoadTestServer) {
-	finished := &pb.LoadTestResp_Finish{Finish: &pb.LoadTestResp_Finished{}}
+func (s *Server) answerFinished(srv pb.Scheduler_LoadTestServer, verdict *pb.Verdict, checks []*pb.Check) {
+	finished := &pb.LoadTestResp_Finish{Finish: &pb.LoadTestResp_Finished{Verdict: verdict, Checks: checks}}
 	err := srv.Send(&pb.LoadTestResp{Phase: finished})
 	if err != nil {
 		logrus.WithError(err).Error("can't send message to client")
 	}
 }
 
+func (s *Server) answerProgress(srv pb.Scheduler_LoadTestServer, progress *pb.LoadTestResp_Progress) {
+	err := srv.Send(&pb.LoadTestResp{Phase: &pb.LoadTestResp_Progress_{Progress: progress}})
+	if err != nil {
+		logrus.WithError(err).Error("can't send message to client")
+	}
+}
+
+func (s *Server) answerCancelled(srv pb.Scheduler_LoadTestServer) {
+	cancelled := &pb.LoadTestResp_Cancel{Cancel: &pb.LoadTestResp_Cancelled{}}
+	err := srv.Send(&pb.LoadTestResp{Phase: cancelled})
+	if err != nil {
+		logrus.WithError(err).Error("can't send message to client")
+	}
+}
+
 func (s *Server) answerErrored(srv pb.Scheduler_LoadTestServer, ansErr error) {
 	errored := &pb.LoadTestResp_Error{Error: &pb.LoadTestResp_Errored{Error: ansErr.Error()}}
+	if aborted, ok := ansErr.(*abortError); ok {
+		errored.Error.Abort = aborted.abort
+	}
 	err := srv.Send(&pb.LoadTestResp{Phase: errored})
 	if err != nil {
 		logrus.WithError(err).Error("can't send message to client")
@@ -0,0 +1,231 @@
+package scheduler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lgpeterson/loadtests/executor/stats"
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+// threshold is a condition a load test must meet to pass:
+//
+//	p95(step:login) < 300ms    -- also p50, p99.9, avg and max of a step,
+//	                              or p95 of every step together
+//	error_rate < 1%            -- failed requests over all the requests
+//	errors <= 10               -- failed executions plus failed requests,
+//	                              the expected errors of requests aside
+//	rps >= 500                 -- requests per second over the whole run
+//	check_rate >= 99%          -- checks that passed over all the checks,
+//	                              or check_rate(status is 200) for one
+type threshold struct {
+	expr string
+	// one of the metrics above, pNN is "p" with `quantile` set
+	metric   string
+	quantile float64
+	// empty for every step
+	step string
+	// the name of the check for check_rate, empty for all of them
+	check string
+	op    string
+	// in seconds for latencies, a ratio for error_rate and check_rate
+	value float64
+}
+
+var (
+	stepMetric  = regexp.MustCompile(`^(p\d+(?:\.\d+)?|avg|max)(?:\(step:([^)]+)\))?$`)
+	checkMetric = regexp.MustCompile(`^check_rate(?:\((.+)\))?$`)
+)
+
+func parseThreshold(expr string) (*threshold, error) {
+	t, err := parseCondition(expr)
+	if err != nil {
+		return nil, fmt.Errorf("threshold %q: %v", expr, err)
+	}
+	return t, nil
+}
+
+// parseCondition parses the expression of a threshold, or of an abort
+// condition.
+func parseCondition(expr string) (*threshold, error) {
+	t := &threshold{expr: expr}
+	var lhs, rhs string
+	for _, op := range []string{"<=", ">=", "<", ">"} {
+		if i := strings.Index(expr, op); i >= 0 {
+			t.op = op
+			lhs, rhs = strings.TrimSpace(expr[:i]), strings.TrimSpace(expr[i+len(op):])
+			break
+		}
+	}
+	if t.op == "" {
+		return nil, fmt.Errorf("needs one of <, <=, > or >=")
+	}
+
+	var err error
+	if match := checkMetric.FindStringSubmatch(lhs); match != nil {
+		t.metric, t.check = "check_rate", strings.TrimSpace(match[1])
+		t.value, err = parseRatio(rhs)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	switch lhs {
+	case "error_rate":
+		t.metric = lhs
+		t.value, err = parseRatio(rhs)
+	case "errors", "rps":
+		t.metric = lhs
+		t.value, err = strconv.ParseFloat(rhs, 64)
+	default:
+		match := stepMetric.FindStringSubmatch(lhs)
+		if match == nil {
+			return nil, fmt.Errorf("unknown metric %q", lhs)
+		}
+		t.metric, t.step = match[1], match[2]
+		if strings.HasPrefix(t.metric, "p") {
+			var percentile float64
+			percentile, err = strconv.ParseFloat(t.metric[1:], 64)
+			if err != nil {
+				break
+			}
+			if percentile <= 0 || percentile > 100 {
+				err = fmt.Errorf("percentile must be within (0, 100]")
+				break
+			}
+			t.metric, t.quantile = "p", percentile/100
+		}
+		var d time.Duration
+		d, err = time.ParseDuration(rhs)
+		t.value = d.Seconds()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseRatio parses a ratio, or a percentage if it ends with %.
+func parseRatio(s string) (float64, error) {
+	if strings.HasSuffix(s, "%") {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		return v / 100, err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseThresholds(exprs []string) ([]*threshold, error) {
+	var thresholds []*threshold
+	for _, expr := range exprs {
+		t, err := parseThreshold(expr)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, nil
+}
+
+// observe measures the metric of the threshold over a whole run. It returns
+// false if the run has no data for it.
+func (t *threshold) observe(totals *progress, elapsed time.Duration) (float64, string, bool) {
+	totals.lock.Lock()
+	defer totals.lock.Unlock()
+
+	switch t.metric {
+	case "error_rate":
+		if totals.requests == 0 {
+			return 0, "no requests", false
+		}
+		rate := float64(totals.failedRequests) / float64(totals.requests)
+		return rate, fmt.Sprintf("%.2f%%", rate*100), true
+	case "errors":
+		failed := totals.errors + totals.failedRequests
+		return float64(failed), strconv.FormatInt(failed, 10), true
+	case "rps":
+		if elapsed <= 0 {
+			return 0, "no time elapsed", false
+		}
+		rps := float64(totals.requests) / elapsed.Seconds()
+		return rps, fmt.Sprintf("%.1f", rps), true
+
+	case "check_rate":
+		var passes, fails int64
+		for name, check := range totals.checks {
+			if t.check == "" || t.check == name {
+				passes += check.Passes
+				fails += check.Fails
+			}
+		}
+		if passes+fails == 0 {
+			if t.check != "" {
+				return 0, fmt.Sprintf("check %q never ran", t.check), false
+			}
+			return 0, "no checks", false
+		}
+		rate := float64(passes) / float64(passes+fails)
+		return rate, fmt.Sprintf("%.2f%%", rate*100), true
+	}
+
+	var latency stats.Histogram
+	if t.step == "" {
+		for _, step := range totals.steps {
+			latency.Merge(&step.latency)
+		}
+		if latency.Count() == 0 {
+			return 0, "no step completed", false
+		}
+	} else {
+		step, ok := totals.steps[t.step]
+		if !ok || step.latency.Count() == 0 {
+			return 0, fmt.Sprintf("step %q never completed", t.step), false
+		}
+		latency = step.latency
+	}
+	var d time.Duration
+	switch t.metric {
+	case "p":
+		d = latency.Quantile(t.quantile)
+	case "avg":
+		d = latency.Mean()
+	case "max":
+		d = latency.Max()
+	}
+	return d.Seconds(), d.String(), true
+}
+
+func (t *threshold) passes(observed float64) bool {
+	switch t.op {
+	case "<":
+		return observed < t.value
+	case "<=":
+		return observed <= t.value
+	case ">":
+		return observed > t.value
+	default:
+		return observed >= t.value
+	}
+}
+
+// judge evaluates every threshold against the metrics of a whole run, nil if
+// there are no thresholds.
+func judge(thresholds []*threshold, totals *progress, elapsed time.Duration) *pb.Verdict {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	verdict := &pb.Verdict{Passed: true}
+	for _, t := range thresholds {
+		observed, text, ok := t.observe(totals, elapsed)
+		passed := ok && t.passes(observed)
+		verdict.Thresholds = append(verdict.Thresholds, &pb.Verdict_Threshold{
+			Expression: t.expr,
+			Passed:     passed,
+			Observed:   text,
+		})
+		verdict.Passed = verdict.Passed && passed
+	}
+	return verdict
+}
@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lgpeterson/loadtests/scheduler/pb"
+)
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    threshold
+		wantErr string
+	}{
+		{expr: "p95(step:login) < 300ms", want: threshold{metric: "p", quantile: 0.95, step: "login", op: "<", value: 0.3}},
+		{expr: "p99.9 <= 1s", want: threshold{metric: "p", quantile: 0.999, op: "<=", value: 1}},
+		{expr: "p100<2s", want: threshold{metric: "p", quantile: 1, op: "<", value: 2}},
+		{expr: "avg(step:search) < 50ms", want: threshold{metric: "avg", step: "search", op: "<", value: 0.05}},
+		{expr: "max > 1m", want: threshold{metric: "max", op: ">", value: 60}},
+		{expr: "error_rate < 1%", want: threshold{metric: "error_rate", op: "<", value: 0.01}},
+		{expr: "error_rate <= 0.05", want: threshold{metric: "error_rate", op: "<=", value: 0.05}},
+		{expr: "errors <= 10", want: threshold{metric: "errors", op: "<=", value: 10}},
+		{expr: "rps >= 500", want: threshold{metric: "rps", op: ">=", value: 500}},
+		{expr: "check_rate >= 99%", want: threshold{metric: "check_rate", op: ">=", value: 0.99}},
+		{expr: "check_rate( status is 200 ) > 0.5", want: threshold{metric: "check_rate", check: "status is 200", op: ">", value: 0.5}},
+
+		{expr: "p95 = 300ms", wantErr: "needs one of <, <=, > or >="},
+		{expr: "latency < 300ms", wantErr: `unknown metric "latency"`},
+		{expr: "p0 < 300ms", wantErr: "percentile must be within (0, 100]"},
+		{expr: "p101 < 300ms", wantErr: "percentile must be within (0, 100]"},
+		{expr: "p95(login) < 300ms", wantErr: `unknown metric "p95(login)"`},
+		{expr: "p95 < 300", wantErr: "missing unit"},
+		{expr: "error_rate < lots", wantErr: "invalid syntax"},
+		{expr: "rps >= 5%", wantErr: "invalid syntax"},
+	}
+	for _, tt := range tests {
+		got, err := parseThreshold(tt.expr)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: want an error with %q, got %v", tt.expr, tt.wantErr, err)
+			} else if !strings.Contains(err.Error(), tt.expr) {
+				t.Errorf("%s: want the expression in the error, got %v", tt.expr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		tt.want.expr = tt.expr
+		if math.Abs(got.quantile-tt.want.quantile) < 1e-9 {
+			got.quantile = tt.want.quantile
+		}
+		if *got != tt.want {
+			t.Errorf("%s: want %+v, got %+v", tt.expr, tt.want, *got)
+		}
+	}
+}
+
+// testProgress is the outcome of a run: 90 logins of 100ms and 10 of 1s, 20
+// searches of 10ms, 1000 requests of which 20 failed, 3 errors, and 99 out
+// of 100 checks passed.
+func testProgress() *progress {
+	p := newProgress()
+	p.requests, p.failedRequests, p.errors = 1000, 20, 3
+	login, search := &progressStep{}, &progressStep{}
+	for i := 0; i < 90; i++ {
+		login.latency.Record(100 * time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		login.latency.Record(time.Second)
+	}
+	for i := 0; i < 20; i++ {
+		search.latency.Record(10 * time.Millisecond)
+	}
+	p.steps["login"], p.steps["search"] = login, search
+	p.checks["status is 200"] = &pb.Check{Name: "status is 200", Passes: 90}
+	p.checks["has a body"] = &pb.Check{Name: "has a body", Passes: 9, Fails: 1}
+	return p
+}
+
+func TestObserve(t *testing.T) {
+	// Latencies are observed from histograms, which are off by up to 10%
+	const latencyError = 0.1
+	tests := []struct {
+		expr    string
+		elapsed time.Duration
+		want    float64
+		// what is reported, not checked for latencies
+		text     string
+		observed bool
+	}{
+		{"error_rate < 1%", time.Minute, 0.02, "2.00%", true},
+		{"errors < 1", time.Minute, 23, "23", true},
+		{"rps > 1", 10 * time.Second, 100, "100.0", true},
+		{"rps > 1", 0, 0, "no time elapsed", false},
+		{"check_rate > 1%", time.Minute, 0.99, "99.00%", true},
+		{"check_rate(has a body) > 1%", time.Minute, 0.9, "90.00%", true},
+		{"check_rate(logged in) > 1%", time.Minute, 0, `check "logged in" never ran`, false},
+		{"p50(step:login) < 1s", time.Minute, 0.1, "", true},
+		{"p95(step:login) < 1s", time.Minute, 1, "", true},
+		{"max(step:search) < 1s", time.Minute, 0.01, "", true},
+		{"avg(step:login) < 1s", time.Minute, 0.19, "", true},
+		// Every step together: 110 of the 120 are at most 100ms
+		{"p90 < 1s", time.Minute, 0.1, "", true},
+		{"p95 < 1s", time.Minute, 1, "", true},
+		{"p50(step:logout) < 1s", time.Minute, 0, `step "logout" never completed`, false},
+	}
+	for _, tt := range tests {
+		th, err := parseThreshold(tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, text, ok := th.observe(testProgress(), tt.elapsed)
+		if ok != tt.observed || math.Abs(got-tt.want) > tt.want*latencyError {
+			t.Errorf("%s: want %v (%v), got %v (%v)", tt.expr, tt.want, tt.observed, got, ok)
+		}
+		if tt.text != "" && text != tt.text {
+			t.Errorf("%s: want %q reported, got %q", tt.expr, tt.text, text)
+		}
+	}
+
+	empty := newProgress()
+	for _, expr := range []string{"error_rate < 1%", "check_rate > 1%", "p95 < 1s"} {
+		th, _ := parseThreshold(expr)
+		if _, text, ok := th.observe(empty, time.Minute); ok {
+			t.Errorf("%s: want nothing observed without data, got %q", expr, text)
+		}
+	}
+}
+
+func TestJudge(t *testing.T) {
+	if verdict := judge(nil, testProgress(), time.Minute); verdict != nil {
+		t.Errorf("want no verdict without thresholds, got %v", verdict)
+	}
+
+	tests := []struct {
+		exprs []string
+		// whether each threshold passes
+		want []bool
+	}{
+		{[]string{"p90(step:login) <= 110ms", "p95(step:login) < 1s", "p95(step:login) <= 1s"}, []bool{true, false, true}},
+		{[]string{"p99 < 2s", "p50 > 200ms", "max(step:search) < 20ms"}, []bool{true, false, true}},
+		{[]string{"error_rate < 1%", "error_rate <= 2%", "error_rate < 0.03"}, []bool{false, true, true}},
+		{[]string{"rps >= 100", "rps > 100"}, []bool{true, false}},
+		{[]string{"check_rate >= 99%", "check_rate(has a body) >= 99%"}, []bool{true, false}},
+		// A threshold without data fails
+		{[]string{"check_rate(logged in) >= 0", "errors <= 23", "errors <= 3"}, []bool{false, true, false}},
+	}
+	for _, tt := range tests {
+		thresholds, err := parseThresholds(tt.exprs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verdict := judge(thresholds, testProgress(), 10*time.Second)
+		passed := true
+		for i, th := range verdict.Thresholds {
+			if th.Expression != tt.exprs[i] || th.Passed != tt.want[i] {
+				t.Errorf("%s: want passed %v, got %v (observed %s)", tt.exprs[i], tt.want[i], th.Passed, th.Observed)
+			}
+			passed = passed && tt.want[i]
+		}
+		if verdict.Passed != passed {
+			t.Errorf("%v: want passed %v, got %v", tt.exprs, passed, verdict.Passed)
+		}
+	}
+
+	if _, err := parseThresholds([]string{"rps > 1", "rps"}); err == nil {
+		t.Error("want an error with a bad threshold")
+	}
+}
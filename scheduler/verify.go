@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/lgpeterson/loadtests/executor/engine"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+const (
+	// VerificationPath is where a target serves the verification token
+	VerificationPath = "/.well-known/loadtests-verification.txt"
+	// verificationRecord prefixes the token in a DNS TXT record of a target
+	verificationRecord = "loadtests-verification="
+)
+
+// verificationTimeout bounds the request for the token of a target
+var verificationTimeout = 10 * time.Second
+
+// targetVerifier makes sure the owners of a target agreed to be load tested
+// before the first load test against it: the target serves the token at
+// VerificationPath, or publishes it in a DNS TXT record. The hosts of the
+// allowlist are never checked, on any port unless given with one.
+type targetVerifier struct {
+	token     string
+	allowlist map[string]bool
+	// how long a host stays verified
+	ttl   time.Duration
+	clock clock.Clock
+
+	client    *http.Client
+	lookupTXT func(host string) ([]string, error)
+
+	lock sync.Mutex
+	// when each host:port was verified
+	verified map[string]time.Time
+}
+
+func newTargetVerifier(token string, allowlist []string, ttl time.Duration, clk clock.Clock) *targetVerifier {
+	v := &targetVerifier{
+		token:     token,
+		allowlist: make(map[string]bool, len(allowlist)),
+		ttl:       ttl,
+		clock:     clk,
+		client: &http.Client{
+			Timeout: verificationTimeout,
+			// The token must be served by the target itself
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		lookupTXT: net.LookupTXT,
+		verified:  make(map[string]time.Time),
+	}
+	for _, host := range allowlist {
+		v.allowlist[strings.ToLower(host)] = true
+	}
+	return v
+}
+
+// verify checks that the target can be load tested, and returns its host and
+// port, the only ones the scripts are allowed to request.
+func (v *targetVerifier) verify(ctx context.Context, target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid target URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "" {
+		return "", fmt.Errorf("the target URL must be absolute, like http://example.com, not %q", target)
+	}
+	host, hostPort := strings.ToLower(u.Hostname()), engine.HostPort(u)
+	if v.allowlist[host] || v.allowlist[hostPort] {
+		return hostPort, nil
+	}
+
+	v.lock.Lock()
+	at, ok := v.verified[hostPort]
+	v.lock.Unlock()
+	if ok && v.clock.Now().Sub(at) < v.ttl {
+		return hostPort, nil
+	}
+
+	served := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: VerificationPath}
+	httpErr := v.served(ctx, served.String())
+	if httpErr != nil {
+		if dnsErr := v.published(host); dnsErr != nil {
+			return "", fmt.Errorf("can't verify that %q agreed to be load tested, serve %q at %s or publish the TXT record %q: %v, and %v",
+				hostPort, v.token, served, verificationRecord+v.token, httpErr, dnsErr)
+		}
+	}
+
+	v.lock.Lock()
+	v.verified[hostPort] = v.clock.Now()
+	v.lock.Unlock()
+	return hostPort, nil
+}
+
+// served checks the token served at `location`.
+func (v *targetVerifier) served(ctx context.Context, location string) error {
+	resp, err := ctxhttp.Get(ctx, v.client, location)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %s", location, resp.Status)
+	}
+	// More than the token is a page that isn't about it
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, int64(len(v.token))+64))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(body)) != v.token {
+		return fmt.Errorf("%s doesn't serve the token", location)
+	}
+	return nil
+}
+
+// published checks the token published in the TXT records of `host`.
+func (v *targetVerifier) published(host string) error {
+	if net.ParseIP(host) != nil {
+		return fmt.Errorf("%s is an IP address, it has no TXT record", host)
+	}
+	records, err := v.lookupTXT(host)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if strings.TrimSpace(record) == verificationRecord+v.token {
+			return nil
+		}
+	}
+	return fmt.Errorf("no TXT record of %s holds the token", host)
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"golang.org/x/net/context"
+)
+
+func TestTargetVerifier(t *testing.T) {
+	token := "s3cr3t"
+	var served string
+	var requests int
+	owned := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != VerificationPath {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		fmt.Fprintln(w, served)
+	}))
+	defer owned.Close()
+	redirected := httptest.NewServer(http.RedirectHandler(owned.URL+VerificationPath, http.StatusFound))
+	defer redirected.Close()
+
+	records := map[string][]string{
+		"published.example.com": {"v=spf1 -all", verificationRecord + token},
+		"other.example.com":     {verificationRecord + "not-it"},
+	}
+	clk := clock.NewMock()
+	v := newTargetVerifier(token, []string{"Allowed.Example.com", "pinned.example.com:8443"}, time.Hour, clk)
+	v.lookupTXT = func(host string) ([]string, error) {
+		if r, ok := records[host]; ok {
+			return r, nil
+		}
+		return nil, fmt.Errorf("no such host")
+	}
+	ctx := context.Background()
+
+	verify := func(target, wantHost, wantErr string) {
+		host, err := v.verify(ctx, target)
+		switch {
+		case wantErr == "" && err != nil:
+			t.Errorf("%s: %v", target, err)
+		case wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)):
+			t.Errorf("%s: want an error with %q, got %v", target, wantErr, err)
+		case host != wantHost:
+			t.Errorf("%s: want host %q, got %q", target, wantHost, host)
+		}
+	}
+
+	// The target doesn't serve the token yet
+	served = "something else"
+	verify(owned.URL, "", "doesn't serve the token")
+	verify(owned.URL+"/missing", "", "doesn't serve the token")
+
+	// The scripts are only allowed the port of the target
+	ownedHost := strings.TrimPrefix(owned.URL, "http://")
+	served = token
+	verify(owned.URL+"/some/page", ownedHost, "")
+	// Verified hosts are cached until the TTL expires, for their port only
+	served = "something else"
+	verify(owned.URL, ownedHost, "")
+	if requests != 3 {
+		t.Errorf("want 3 requests for the token, got %d", requests)
+	}
+	verify(redirected.URL, "", "answered 302 Found")
+	clk.Add(time.Hour)
+	verify(owned.URL, "", "doesn't serve the token")
+
+	// The token must be served by the target itself
+	verify(strings.Replace(redirected.URL, "127.0.0.1", "localhost", 1), "", "answered 302 Found")
+
+	verify("http://published.example.com:1/", "published.example.com:1", "")
+	verify("http://other.example.com:1/", "", "no TXT record of other.example.com holds the token")
+	verify("https://allowed.example.com/", "allowed.example.com:443", "")
+	verify("http://allowed.example.com:8080/", "allowed.example.com:8080", "")
+	verify("https://pinned.example.com:8443/", "pinned.example.com:8443", "")
+	verify("https://pinned.example.com/", "", "no such host")
+
+	verify("example.com", "", "must be absolute")
+	verify("ftp://example.com", "", "must be absolute")
+}